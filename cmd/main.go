@@ -2,73 +2,116 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
+	"os"
 	"time"
 
-	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	authHandler "github.com/syned13/ticket-support-back/internal/handlers/auth"
-	ticketsHandler "github.com/syned13/ticket-support-back/internal/handlers/tickets"
-	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets/postgres"
-	usersRepo "github.com/syned13/ticket-support-back/internal/repositories/users/postgres"
-	authService "github.com/syned13/ticket-support-back/internal/service/auth"
-	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
 	"github.com/syned13/ticket-support-back/pkg/config"
+	appLogger "github.com/syned13/ticket-support-back/pkg/logger"
 )
 
+const usage = `usage: main [command]
+
+commands:
+  serve                                    start the API, the default command
+  migrate status | up | down N | force V   manage the migrations of the database
+  create-admin --email EMAIL --name NAME   create an admin, its password is read from the standard input
+  reset-password --email EMAIL             replace the password of a user, read from the standard input
+  seed --demo                              add demo users, a team and tickets`
+
+// set at build time with -ldflags "-X main.gitSHA=... -X main.buildTime=...", see the Dockerfile
+var (
+	gitSHA    = "unknown"
+	buildTime = "unknown"
+)
+
+// errUsage wrong command or arguments
+var errUsage = errors.New("invalid arguments")
+
+// command runs a subcommand with its arguments
+type command func(ctx context.Context, config *config.AppConfig, pool *pgxpool.Pool, logger *slog.Logger, args []string) error
+
+var commands = map[string]command{
+	"serve":          serve,
+	"migrate":        migrateCommand,
+	"create-admin":   createAdmin,
+	"reset-password": resetPassword,
+	"seed":           seed,
+}
+
 func main() {
+	name, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		name, args = os.Args[1], os.Args[2:]
+	}
+
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
 	config, err := config.GetConfigFromEnv()
 	if err != nil {
-		log.Fatal("getting_config_failed: " + err.Error())
+		fatal(slog.Default(), "getting_config_failed", err)
 	}
 
-	fmt.Println(config.DatabaseConfig.Connection)
+	logger, err := appLogger.New(os.Stderr, config.LogConfig)
+	if err != nil {
+		fatal(slog.Default(), "logger_initialization_failed", err)
+	}
 
-	ctx := context.Background()
+	// whatever still logs through the default logger gets the same format and redaction
+	slog.SetDefault(logger)
 
-	var pool *pgxpool.Pool
-	isConnected := false
+	ctx := context.Background()
 
-	for i := 0; i < 10; i++ {
-		pool, err = pgxpool.Connect(ctx, config.DatabaseConfig.Connection)
-		if err != nil {
-			time.Sleep(time.Second * 2)
-			continue
-		} else {
-			isConnected = true
-			break
-		}
+	pool, err := connect(ctx, config.DatabaseConfig.Connection, logger)
+	if err != nil {
+		fatal(logger, "connecting_to_database_failed", err)
 	}
 
-	if !isConnected {
-		log.Fatal("failed_connecting_to_database: " + err.Error())
+	err = run(ctx, config, pool, logger, args)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
 	}
 
-	usersRepo, err := usersRepo.New(pool)
 	if err != nil {
-		log.Fatal("users_repo_initialization_failed")
+		fatal(logger, name+"_failed", err)
 	}
+}
 
-	authService := authService.New(usersRepo)
-
-	router := mux.NewRouter()
-
-	authHandler.SetupRoutes(ctx, authService, router)
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
 
-	ticketsRepo, err := ticketsRepository.New(pool)
+// connect retries connecting to the database for a while, as it may still be starting. The queries
+// are logged as debug
+func connect(ctx context.Context, connection string, logger *slog.Logger) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(connection)
 	if err != nil {
-		log.Fatal("tickets_repo_initialization_failed")
+		return nil, err
 	}
 
-	ticketsService := ticketsService.New(ticketsRepo, usersRepo)
+	poolConfig.ConnConfig.Logger = appLogger.NewPgxLogger(logger)
+	poolConfig.ConnConfig.LogLevel = pgx.LogLevelInfo
 
-	ticketsHandler.SetupRoutes(ctx, ticketsService, router)
-	fmt.Printf("Listeting on port :%s\n", config.Port)
+	var pool *pgxpool.Pool
 
-	err = http.ListenAndServe(":"+config.Port, router)
-	if err != nil {
-		log.Fatal("initializing_server_failed: " + err.Error())
+	for i := 0; i < 10; i++ {
+		pool, err = pgxpool.ConnectConfig(ctx, poolConfig)
+		if err == nil {
+			return pool, nil
+		}
+
+		time.Sleep(time.Second * 2)
 	}
+
+	return nil, err
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/internal/migrations"
+	"github.com/syned13/ticket-support-back/pkg/config"
+	"github.com/syned13/ticket-support-back/pkg/migrate"
+)
+
+// migrateCommand shows, applies, rolls back or forces the migrations of the database
+func migrateCommand(ctx context.Context, config *config.AppConfig, pool *pgxpool.Pool, logger *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Printf("%04d_%s: %s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return nil
+	case "up":
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		applied, err := migrator.Up(ctx)
+		printMigrations("applied", applied)
+
+		return err
+	case "down":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		steps, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errUsage
+		}
+
+		rolledBack, err := migrator.Down(ctx, steps)
+		printMigrations("rolled back", rolledBack)
+
+		return err
+	case "force":
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errUsage
+		}
+
+		err = migrator.Force(ctx, version)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("forced version %d\n", version)
+
+		return nil
+	default:
+		return errUsage
+	}
+}
+
+func printMigrations(action string, migrations []migrate.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("nothing " + action)
+	}
+
+	for _, migration := range migrations {
+		fmt.Printf("%s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/internal/models"
+	rolesRepository "github.com/syned13/ticket-support-back/internal/repositories/roles/postgres"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams/postgres"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets/postgres"
+	authService "github.com/syned13/ticket-support-back/internal/service/auth"
+	rbacService "github.com/syned13/ticket-support-back/internal/service/rbac"
+	teamsService "github.com/syned13/ticket-support-back/internal/service/teams"
+	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
+	"github.com/syned13/ticket-support-back/pkg/config"
+)
+
+// demoPassword the password of every demo user
+const demoPassword = "demo-password"
+
+const demoTeamName = "Support"
+
+var demoUsers = []models.User{
+	{Name: "Ana Agent", Email: "agent@demo.local", Role: models.RoleAgent},
+	{Name: "Sam Supervisor", Email: "supervisor@demo.local", Role: models.RoleSupervisor},
+	{Name: "Rita Requester", Email: "requester@demo.local", Role: models.RoleRequester},
+}
+
+var demoTickets = []models.Ticket{
+	{Title: "Printer on the second floor", Description: "It jams with every page", Type: models.TicketTypeSupport, Severity: models.TicketSeverityMedium, Priority: models.TicketPriorityMedium},
+	{Title: "VPN access", Description: "I need to connect from home", Type: models.TicketTypeAsistance, Severity: models.TicketSeverityLow, Priority: models.TicketPriorityHigh},
+	{Title: "Dark mode", Description: "The portal would be easier on the eyes", Type: models.TicketTypeSugestion, Severity: models.TicketSeverityLow, Priority: models.TicketPriorityLow},
+}
+
+// seed adds demo users, a team working on the support tickets and a few tickets. Running it again
+// leaves the existing demo data as it is
+func seed(ctx context.Context, config *config.AppConfig, pool *pgxpool.Pool, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	demo := flags.Bool("demo", false, "add the demo data")
+
+	err := flags.Parse(args)
+	if err != nil || !*demo || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	usersRepo, auth, err := newAuthService(config, pool)
+	if err != nil {
+		return err
+	}
+
+	users := map[models.Role]models.User{}
+	created := map[models.Role]bool{}
+
+	for _, demoUser := range demoUsers {
+		demoUser.Password = demoPassword
+
+		user, err := auth.CreateUser(ctx, demoUser)
+		if errors.Is(err, authService.ErrDuplicateFields) {
+			user, err = usersRepo.GetUserByEmail(ctx, demoUser.Email)
+		} else if err == nil {
+			created[demoUser.Role] = true
+			fmt.Printf("created %s %s with password %s\n", user.Role, user.Email, demoPassword)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		users[demoUser.Role] = user
+	}
+
+	rolesRepo, err := rolesRepository.New(pool)
+	if err != nil {
+		return err
+	}
+
+	rbac := rbacService.New(rolesRepo, config.RolesCacheTTL)
+
+	permissions, err := rbac.GetPermissions(ctx, models.RoleSupervisor)
+	if err != nil {
+		return err
+	}
+
+	supervisor := models.Requester{UserID: users[models.RoleSupervisor].UserID, Role: models.RoleSupervisor, Permissions: permissions}
+
+	teamsRepo, err := teamsRepository.New(pool)
+	if err != nil {
+		return err
+	}
+
+	teams := teamsService.New(teamsRepo, usersRepo)
+
+	team, err := seedTeam(ctx, teams, supervisor)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range []models.Role{models.RoleAgent, models.RoleSupervisor} {
+		team, err = teams.AddMember(ctx, team.TeamID, users[role].UserID, supervisor)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the tickets are only added along with their requester, so they are not repeated
+	if !created[models.RoleRequester] {
+		return nil
+	}
+
+	ticketsRepo, err := ticketsRepository.New(pool)
+	if err != nil {
+		return err
+	}
+
+	assigner, err := ticketsService.NewAssignmentStrategy(config.AssignmentStrategy, ticketsRepo, usersRepo, teamsRepo)
+	if err != nil {
+		return err
+	}
+
+	tickets := ticketsService.New(ticketsRepo, usersRepo, teamsRepo, assigner, nil, logger)
+
+	for _, ticket := range demoTickets {
+		ticket.CreatorID = users[models.RoleRequester].UserID
+
+		ticket, err = tickets.CreateTicket(ctx, ticket)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("created ticket %d %s\n", ticket.TicketID, ticket.Title)
+	}
+
+	return nil
+}
+
+// seedTeam returns the demo team, creating it when it does not exist
+func seedTeam(ctx context.Context, teams teamsService.Service, supervisor models.Requester) (models.Team, error) {
+	team, err := teams.CreateTeam(ctx, teamsService.CreateTeamRequest{Name: demoTeamName, TicketTypes: []models.TicketType{models.TicketTypeSupport}}, supervisor)
+	if err == nil {
+		fmt.Printf("created team %d %s\n", team.TeamID, team.Name)
+		return team, nil
+	}
+
+	if !errors.Is(err, teamsService.ErrDuplicateName) {
+		return models.Team{}, err
+	}
+
+	existing, err := teams.GetTeams(ctx, supervisor)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	for _, team := range existing {
+		if team.Name == demoTeamName {
+			return team, nil
+		}
+	}
+
+	return models.Team{}, teamsService.ErrDuplicateName
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v4/pgxpool"
+	authHandler "github.com/syned13/ticket-support-back/internal/handlers/auth"
+	collaborationHandler "github.com/syned13/ticket-support-back/internal/handlers/collaboration"
+	eventsHandler "github.com/syned13/ticket-support-back/internal/handlers/events"
+	healthHandler "github.com/syned13/ticket-support-back/internal/handlers/health"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	notificationsHandler "github.com/syned13/ticket-support-back/internal/handlers/notifications"
+	rbacHandler "github.com/syned13/ticket-support-back/internal/handlers/rbac"
+	teamsHandler "github.com/syned13/ticket-support-back/internal/handlers/teams"
+	ticketsHandler "github.com/syned13/ticket-support-back/internal/handlers/tickets"
+	webhooksHandler "github.com/syned13/ticket-support-back/internal/handlers/webhooks"
+	"github.com/syned13/ticket-support-back/internal/migrations"
+	notificationsRepository "github.com/syned13/ticket-support-back/internal/repositories/notifications/postgres"
+	rolesRepository "github.com/syned13/ticket-support-back/internal/repositories/roles/postgres"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams/postgres"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets/postgres"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users/postgres"
+	webhooksRepository "github.com/syned13/ticket-support-back/internal/repositories/webhooks/postgres"
+	authService "github.com/syned13/ticket-support-back/internal/service/auth"
+	collaborationService "github.com/syned13/ticket-support-back/internal/service/collaboration"
+	eventsService "github.com/syned13/ticket-support-back/internal/service/events"
+	healthService "github.com/syned13/ticket-support-back/internal/service/health"
+	notificationsService "github.com/syned13/ticket-support-back/internal/service/notifications"
+	rbacService "github.com/syned13/ticket-support-back/internal/service/rbac"
+	teamsService "github.com/syned13/ticket-support-back/internal/service/teams"
+	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
+	webhooksService "github.com/syned13/ticket-support-back/internal/service/webhooks"
+	"github.com/syned13/ticket-support-back/pkg/config"
+	"github.com/syned13/ticket-support-back/pkg/email"
+	"github.com/syned13/ticket-support-back/pkg/storage"
+)
+
+// serve applies the pending migrations, unless told not to, and runs the API until SIGINT or SIGTERM.
+// On either signal the server stops taking connections and waits for the in-flight requests, then the
+// background workers are stopped and the database pool is closed
+func serve(ctx context.Context, config *config.AppConfig, pool *pgxpool.Pool, logger *slog.Logger, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		return err
+	}
+
+	if !config.SkipMigrations {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range applied {
+			logger.Info("migration_applied", "version", migration.Version, "name", migration.Name)
+		}
+	}
+
+	usersRepo, err := usersRepository.New(pool)
+	if err != nil {
+		return fmt.Errorf("initializing users repo: %w", err)
+	}
+
+	rolesRepo, err := rolesRepository.New(pool)
+	if err != nil {
+		return fmt.Errorf("initializing roles repo: %w", err)
+	}
+
+	// the lifetime of the server, open streams end when it is done
+	serverCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// workers keep running while the in-flight requests drain, as those may still publish events
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	workers := &sync.WaitGroup{}
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+
+	rbac := rbacService.New(rolesRepo, config.RolesCacheTTL)
+
+	auth := authService.New(usersRepo, rbac)
+
+	router := mux.NewRouter()
+	router.Use(middleware.RequestID(logger))
+
+	buildInfo := healthService.BuildInfo{GitSHA: gitSHA, BuildTime: buildTime, Environment: config.Environment}
+	healthHandler.SetupRoutes(healthService.New(pool, migrator, buildInfo, logger), router)
+
+	authHandler.SetupRoutes(auth, router, logger)
+	rbacHandler.SetupRoutes(rbac, auth, router, logger)
+
+	ticketsRepo, err := ticketsRepository.New(pool)
+	if err != nil {
+		return fmt.Errorf("initializing tickets repo: %w", err)
+	}
+
+	teamsRepo, err := teamsRepository.New(pool)
+	if err != nil {
+		return fmt.Errorf("initializing teams repo: %w", err)
+	}
+
+	teams := teamsService.New(teamsRepo, usersRepo)
+
+	assigner, err := ticketsService.NewAssignmentStrategy(config.AssignmentStrategy, ticketsRepo, usersRepo, teamsRepo)
+	if err != nil {
+		return fmt.Errorf("initializing assignment strategy: %w", err)
+	}
+
+	slaChecker := ticketsService.NewSLAChecker(ticketsRepo, config.SLACheckInterval, logger)
+	runWorker(slaChecker.Run)
+
+	attachmentsStorage, err := storage.New(config.StorageConfig)
+	if err != nil {
+		return fmt.Errorf("initializing storage: %w", err)
+	}
+
+	notificationsRepo, err := notificationsRepository.New(pool)
+	if err != nil {
+		return fmt.Errorf("initializing notifications repo: %w", err)
+	}
+
+	var emailSender email.Sender
+	if config.SMTPConfig.Host != "" {
+		emailSender, err = email.NewSMTPSender(config.SMTPConfig)
+		if err != nil {
+			return fmt.Errorf("initializing email sender: %w", err)
+		}
+	}
+
+	notifications := notificationsService.New(notificationsRepo, usersRepo, emailSender, logger)
+	runWorker(notifications.Run)
+
+	webhooksRepo, err := webhooksRepository.New(pool)
+	if err != nil {
+		return fmt.Errorf("initializing webhooks repo: %w", err)
+	}
+
+	webhooks := webhooksService.New(webhooksRepo, nil, 0, logger)
+	runWorker(webhooks.Run)
+
+	events := eventsService.New(0)
+
+	tickets := ticketsService.New(ticketsRepo, usersRepo, teamsRepo, assigner, attachmentsStorage, logger)
+
+	collaboration := collaborationService.New(tickets, usersRepo, 0)
+
+	consumers := ticketsService.Consumers{
+		"notifications": notifications,
+		"webhooks":      webhooks,
+		"events":        events,
+		"collaboration": collaboration,
+	}
+
+	outboxDispatcher := ticketsService.NewOutboxDispatcher(ticketsRepo, consumers, config.OutboxInterval, logger)
+	runWorker(outboxDispatcher.Run)
+
+	ticketsHandler.SetupRoutes(tickets, auth, router, logger)
+	teamsHandler.SetupRoutes(teams, auth, router, logger)
+	notificationsHandler.SetupRoutes(notifications, auth, router, logger)
+	webhooksHandler.SetupRoutes(webhooks, auth, router, logger)
+	// streams end a bit before the write timeout, clients reconnect on their own
+	eventsHandler.SetupRoutes(serverCtx, events, auth, router, logger, config.ServerConfig.WriteTimeout*9/10)
+	collaborationHandler.SetupRoutes(serverCtx, collaboration, auth, router, logger)
+
+	server := &http.Server{
+		Addr:         ":" + config.Port,
+		Handler:      router,
+		ReadTimeout:  config.ServerConfig.ReadTimeout,
+		WriteTimeout: config.ServerConfig.WriteTimeout,
+		IdleTimeout:  config.ServerConfig.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		logger.Info("listening", "port", config.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+	case <-serverCtx.Done():
+		logger.Info("shutting_down")
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, config.ServerConfig.ShutdownTimeout)
+		defer cancel()
+
+		err = server.Shutdown(shutdownCtx)
+	}
+
+	stopWorkers()
+	workers.Wait()
+	pool.Close()
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/internal/models"
+	rolesRepository "github.com/syned13/ticket-support-back/internal/repositories/roles/postgres"
+	users "github.com/syned13/ticket-support-back/internal/repositories/users"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users/postgres"
+	authService "github.com/syned13/ticket-support-back/internal/service/auth"
+	rbacService "github.com/syned13/ticket-support-back/internal/service/rbac"
+	"github.com/syned13/ticket-support-back/pkg/config"
+)
+
+// errMissingPassword missing password
+var errMissingPassword = errors.New("missing password")
+
+// createAdmin creates a user with the admin role. The password goes through the same hashing as the
+// ones of the users signing up
+func createAdmin(ctx context.Context, config *config.AppConfig, pool *pgxpool.Pool, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the admin")
+	name := flags.String("name", "", "name of the admin")
+
+	err := flags.Parse(args)
+	if err != nil || *email == "" || *name == "" || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	_, auth, err := newAuthService(config, pool)
+	if err != nil {
+		return err
+	}
+
+	admin, err := auth.CreateUser(ctx, models.User{Name: *name, Email: *email, Password: password, Role: models.RoleAdmin})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created admin %d %s\n", admin.UserID, admin.Email)
+
+	return nil
+}
+
+// resetPassword replaces the password of the user with the email and ends its sessions
+func resetPassword(ctx context.Context, config *config.AppConfig, pool *pgxpool.Pool, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the user")
+
+	err := flags.Parse(args)
+	if err != nil || *email == "" || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	_, auth, err := newAuthService(config, pool)
+	if err != nil {
+		return err
+	}
+
+	user, err := auth.ResetPassword(ctx, *email, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("reset the password of user %d %s\n", user.UserID, user.Email)
+
+	return nil
+}
+
+func newAuthService(config *config.AppConfig, pool *pgxpool.Pool) (users.Repository, authService.Service, error) {
+	usersRepo, err := usersRepository.New(pool)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rolesRepo, err := rolesRepository.New(pool)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return usersRepo, authService.New(usersRepo, rbacService.New(rolesRepo, config.RolesCacheTTL)), nil
+}
+
+// readPassword reads a password from the first line of the standard input, so it is neither kept in
+// the shell history nor seen in the list of processes. Terminals are prompted for it
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "password: ")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errMissingPassword
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errMissingPassword
+	}
+
+	return password, nil
+}
@@ -1,12 +1,12 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
 	"github.com/syned13/ticket-support-back/internal/models"
 	authService "github.com/syned13/ticket-support-back/internal/service/auth"
 	"github.com/syned13/ticket-support-back/pkg/httputils"
@@ -20,23 +20,45 @@ var (
 )
 
 type HTTPHandler interface {
-	HandleLogin(ctx context.Context) http.HandlerFunc
-	HandleSignup(ctx context.Context) http.HandlerFunc
+	HandleLogin() http.HandlerFunc
+	HandleSignup() http.HandlerFunc
+	HandleRefreshToken() http.HandlerFunc
+	HandleLogout() http.HandlerFunc
+	HandleGetUsers() http.HandlerFunc
+	HandleGetUser() http.HandlerFunc
+	HandleUpdateUser() http.HandlerFunc
+	HandleSetUserActive(active bool) http.HandlerFunc
+	HandleChangeUserRole() http.HandlerFunc
 }
 
 type httpHandler struct {
 	service authService.Service
+	logger  *slog.Logger
 }
 
-func SetupRoutes(ctx context.Context, service authService.Service, router *mux.Router) {
-	handler := httpHandler{service: service}
-
-	router.HandleFunc("/login", handler.HandleLogin(ctx)).Methods(http.MethodPost, http.MethodOptions)
-	router.HandleFunc("/signup", handler.HandleSignup(ctx)).Methods(http.MethodPost, http.MethodOptions)
+func SetupRoutes(service authService.Service, router *mux.Router, logger *slog.Logger) {
+	handler := httpHandler{service: service, logger: logger}
+	authMiddleWare := middleware.AuthMiddleWare(service, logger)
+
+	router.HandleFunc("/login", handler.HandleLogin()).Methods(http.MethodPost, http.MethodOptions)
+	router.HandleFunc("/signup", handler.HandleSignup()).Methods(http.MethodPost, http.MethodOptions)
+	router.HandleFunc("/token/refresh", handler.HandleRefreshToken()).Methods(http.MethodPost, http.MethodOptions)
+	router.HandleFunc("/logout", authMiddleWare(handler.HandleLogout())).Methods(http.MethodPost, http.MethodOptions)
+
+	router.HandleFunc("/users", authMiddleWare(handler.HandleGetUsers())).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc("/users/{id}", authMiddleWare(handler.HandleGetUser())).Methods(http.MethodGet, http.MethodOptions)
+	router.HandleFunc("/users/{id}", authMiddleWare(handler.HandleUpdateUser())).Methods(http.MethodPatch)
+	router.HandleFunc("/users/{id}/deactivate", authMiddleWare(handler.HandleSetUserActive(false))).Methods(http.MethodPost, http.MethodOptions)
+	router.HandleFunc("/users/{id}/activate", authMiddleWare(handler.HandleSetUserActive(true))).Methods(http.MethodPost, http.MethodOptions)
+	router.HandleFunc("/users/{id}/role", authMiddleWare(handler.HandleChangeUserRole())).Methods(http.MethodPut, http.MethodOptions)
+	// deprecated: kept for clients from before roles, takes the old user types
+	router.HandleFunc("/users/{id}/type", authMiddleWare(handler.HandleChangeUserRole())).Methods(http.MethodPut, http.MethodOptions)
 }
 
-func (h httpHandler) HandleLogin(ctx context.Context) http.HandlerFunc {
+func (h httpHandler) HandleLogin() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
 		setupPreflightResponse(&rw, r)
 
 		if r.Method == http.MethodOptions {
@@ -58,7 +80,7 @@ func (h httpHandler) HandleLogin(ctx context.Context) http.HandlerFunc {
 
 		loginResponse, err := h.service.Login(ctx, loginRequest.Email, loginRequest.Password)
 		if err != nil {
-			fmt.Println("login_failed: " + err.Error())
+			h.logger.ErrorContext(ctx, "login_failed", "error", err)
 			httputils.RespondWithError(rw, err)
 			return
 		}
@@ -67,8 +89,10 @@ func (h httpHandler) HandleLogin(ctx context.Context) http.HandlerFunc {
 	}
 }
 
-func (h httpHandler) HandleSignup(ctx context.Context) http.HandlerFunc {
+func (h httpHandler) HandleSignup() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
 		setupPreflightResponse(&rw, r)
 
 		if r.Method == http.MethodOptions {
@@ -89,11 +113,11 @@ func (h httpHandler) HandleSignup(ctx context.Context) http.HandlerFunc {
 			return
 		}
 
-		user.Type = models.UserTypeUser
+		user.Role = models.RoleRequester
 
 		user, err = h.service.CreateUser(ctx, user)
 		if err != nil {
-			fmt.Println("creating_user_failed: " + err.Error())
+			h.logger.ErrorContext(ctx, "creating_user_failed", "error", err)
 			httputils.RespondWithError(rw, err)
 			return
 		}
@@ -102,6 +126,73 @@ func (h httpHandler) HandleSignup(ctx context.Context) http.HandlerFunc {
 	}
 }
 
+func (h httpHandler) HandleRefreshToken() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		if r.Method == http.MethodOptions {
+			return
+		}
+
+		err := validateContentType(*r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		refreshRequest := RefreshTokenRequest{}
+		err = json.NewDecoder(r.Body).Decode(&refreshRequest)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		loginResponse, err := h.service.RefreshToken(ctx, refreshRequest.RefreshToken)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "refreshing_token_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, loginResponse)
+	}
+}
+
+func (h httpHandler) HandleLogout() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		token, err := middleware.GetToken(*r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		// the refresh token is optional, without it only the access token is revoked
+		logoutRequest := LogoutRequest{}
+		if r.ContentLength != 0 {
+			err = json.NewDecoder(r.Body).Decode(&logoutRequest)
+			if err != nil {
+				httputils.RespondWithError(rw, ErrInvalidBody)
+				return
+			}
+		}
+
+		err = h.service.Logout(ctx, token, logoutRequest.RefreshToken)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "logout_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
 func validateContentType(r http.Request) error {
 	if r.Header.Get("Content-Type") == "" {
 		return ErrMissingContentType
@@ -1,7 +1,45 @@
 package handlers
 
+import "github.com/syned13/ticket-support-back/internal/models"
+
 // LoginRequest has the fields for a login request body
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+// RefreshTokenRequest has the fields for a token refresh request body
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// LogoutRequest has the fields for a logout request body
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// ChangeUserRoleRequest has the fields for a request changing the role of a user. UserType is the
+// field used before roles, its user and admin types are the requester and admin roles
+type ChangeUserRoleRequest struct {
+	Role     models.Role `json:"role"`
+	UserType string      `json:"userType"`
+}
+
+// legacyUserTypes the roles of the user types from before roles
+var legacyUserTypes = map[string]models.Role{
+	"user":  models.RoleRequester,
+	"admin": models.RoleAdmin,
+}
+
+// role returns the role asked for, translating the old user types
+func (r ChangeUserRoleRequest) role() models.Role {
+	if r.Role != "" {
+		return r.Role
+	}
+
+	if role, ok := legacyUserTypes[r.UserType]; ok {
+		return role
+	}
+
+	return models.Role(r.UserType)
+}
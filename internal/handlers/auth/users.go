@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	authService "github.com/syned13/ticket-support-back/internal/service/auth"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrInvalidUserID invalid user id
+	ErrInvalidUserID = httputils.NewBadRequestError("invalid user id")
+	// ErrInvalidID invalid pagination id start
+	ErrInvalidID = httputils.NewBadRequestError("invalid pagination start id")
+)
+
+func (h httpHandler) HandleGetUsers() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		lastIDStr := r.URL.Query().Get("after_id")
+
+		var lastID int64 = 0
+
+		if lastIDStr != "" {
+			if id, err := strconv.ParseInt(lastIDStr, 10, 64); err == nil {
+				lastID = id
+			} else {
+				httputils.RespondWithError(rw, ErrInvalidID)
+				return
+			}
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		response, err := h.service.GetUsers(ctx, lastID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_users_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, response)
+	}
+}
+
+func (h httpHandler) HandleGetUser() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		userID, err := getUserID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		user, err := h.service.GetUser(ctx, userID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_user_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, user)
+	}
+}
+
+func (h httpHandler) HandleUpdateUser() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		err := validateContentType(*r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		userID, err := getUserID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		updateRequest := authService.UpdateUserRequest{}
+		err = json.NewDecoder(r.Body).Decode(&updateRequest)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		user, err := h.service.UpdateUser(ctx, userID, updateRequest, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "updating_user_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, user)
+	}
+}
+
+func (h httpHandler) HandleSetUserActive(active bool) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		userID, err := getUserID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		user, err := h.service.SetUserActive(ctx, userID, active, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "setting_user_active_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, user)
+	}
+}
+
+func (h httpHandler) HandleChangeUserRole() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		err := validateContentType(*r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		userID, err := getUserID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		changeRequest := ChangeUserRoleRequest{}
+		err = json.NewDecoder(r.Body).Decode(&changeRequest)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		user, err := h.service.ChangeUserRole(ctx, userID, changeRequest.role(), requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "changing_user_role_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, user)
+	}
+}
+
+// getUserID returns the user id from the request path
+func getUserID(r *http.Request) (int64, error) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidUserID
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	collaborationService "github.com/syned13/ticket-support-back/internal/service/collaboration"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	writeTimeout   = 10 * time.Second
+	pongTimeout    = 60 * time.Second
+	pingInterval   = pongTimeout * 9 / 10
+	maxMessageSize = 1024
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the API is open to any origin and authenticated with bearer tokens rather than cookies, so
+	// other origins can not ride on the session of the browser
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type HTTPHandler interface {
+	HandleLiveTicket(ctx context.Context) http.HandlerFunc
+	HandlePreflightRequest() http.HandlerFunc
+}
+
+type httpHandler struct {
+	service collaborationService.Service
+	logger  *slog.Logger
+}
+
+// SetupRoutes registers the routes of the live tickets. ctx is the lifetime of the server, the open
+// connections are closed when it is done
+func SetupRoutes(ctx context.Context, service collaborationService.Service, tokenValidator middleware.TokenValidator, router *mux.Router, logger *slog.Logger) {
+	handler := httpHandler{service: service, logger: logger}
+	authMiddleWare := middleware.AuthMiddleWare(tokenValidator, logger)
+
+	router.HandleFunc("/tickets/{id}/live", tokenFromQuery(authMiddleWare(handler.HandleLiveTicket(ctx)))).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}/live", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+}
+
+func (h httpHandler) HandlePreflightRequest() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+	}
+}
+
+func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
+
+// tokenFromQuery lets browsers, which can not set headers on WebSocket requests, send the access
+// token in the access_token query parameter
+func tokenFromQuery(handler http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		handler(rw, r)
+	}
+}
+
+// HandleLiveTicket upgrades the request to a WebSocket that sends the messages of the room of the
+// ticket and takes the typing indicators of the viewer. The first message is a snapshot of the
+// ticket and its viewers, so clients resync by reconnecting
+func (h httpHandler) HandleLiveTicket(ctx context.Context) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ticketID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			httputils.RespondWithError(rw, httputils.NewBadRequestError("invalid ticket id"))
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		session, err := h.service.Join(r.Context(), ticketID, requester)
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "joining_ticket_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		defer session.Close()
+
+		conn, err := upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "upgrading_connection_failed", "error", err)
+			return
+		}
+
+		defer conn.Close()
+
+		go h.readMessages(r.Context(), conn, session)
+
+		writeMessages(ctx, conn, session)
+	}
+}
+
+// readMessages hands the messages of the client to the session until the connection fails, then
+// closes the session so writeMessages returns. ctx only carries the request ID for the logs
+func (h httpHandler) readMessages(ctx context.Context, conn *websocket.Conn, session *collaborationService.Session) {
+	defer session.Close()
+
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		message := collaborationService.ClientMessage{}
+
+		err := conn.ReadJSON(&message)
+		if err != nil {
+			return
+		}
+
+		err = session.Handle(message)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "handling_live_message_failed", "error", err)
+		}
+	}
+}
+
+// writeMessages sends the messages of the session and keeps the connection alive with pings until
+// the session or the connection is closed
+func writeMessages(ctx context.Context, conn *websocket.Conn, session *collaborationService.Session) {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(writeTimeout))
+			return
+		case <-ping.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+			if err != nil {
+				return
+			}
+		case message, ok := <-session.Messages():
+			if !ok {
+				// the session fell behind or the client went away, either way the client reconnects
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, ""), time.Now().Add(writeTimeout))
+				return
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+
+			err := conn.WriteJSON(message)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	"github.com/syned13/ticket-support-back/internal/models"
+	eventsService "github.com/syned13/ticket-support-back/internal/service/events"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	// heartbeatInterval how often a comment is sent so proxies do not close idle streams
+	heartbeatInterval = 30 * time.Second
+)
+
+var (
+	// ErrStreamingUnsupported streaming unsupported
+	ErrStreamingUnsupported = httputils.ErrorResponse{Code: http.StatusInternalServerError, Message: "streaming unsupported"}
+)
+
+type HTTPHandler interface {
+	HandleStream(ctx context.Context) http.HandlerFunc
+	HandlePreflightRequest() http.HandlerFunc
+}
+
+type httpHandler struct {
+	service       eventsService.Service
+	streamTimeout time.Duration
+	logger        *slog.Logger
+}
+
+// SetupRoutes registers the routes of the event stream. ctx is the lifetime of the server, the open
+// streams end when it is done. Streams also end after streamTimeout, so the write timeout of the
+// server does not cut them in the middle of an event, there is no limit when it is zero
+func SetupRoutes(ctx context.Context, service eventsService.Service, tokenValidator middleware.TokenValidator, router *mux.Router, logger *slog.Logger, streamTimeout time.Duration) {
+	handler := httpHandler{service: service, logger: logger, streamTimeout: streamTimeout}
+	authMiddleWare := middleware.AuthMiddleWare(tokenValidator, logger)
+
+	router.HandleFunc("/events/stream", authMiddleWare(handler.HandleStream(ctx))).Methods(http.MethodGet)
+	router.HandleFunc("/events/stream", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+}
+
+func (h httpHandler) HandlePreflightRequest() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+	}
+}
+
+func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
+
+// HandleStream pushes the ticket events visible to the requester as Server-Sent Events until the
+// client goes away. The stream ends when the client falls behind or it times out, clients are expected
+// to reconnect
+func (h httpHandler) HandleStream(ctx context.Context) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		flusher, ok := rw.(http.Flusher)
+		if !ok {
+			httputils.RespondWithError(rw, ErrStreamingUnsupported)
+			return
+		}
+
+		subscription := h.service.Subscribe(requester)
+		defer subscription.Close()
+
+		rw.Header().Set("Content-Type", "text/event-stream")
+		rw.Header().Set("Cache-Control", "no-cache")
+		rw.Header().Set("Connection", "keep-alive")
+		rw.Header().Set("X-Accel-Buffering", "no")
+		rw.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		var timeout <-chan time.Time
+		if h.streamTimeout > 0 {
+			timer := time.NewTimer(h.streamTimeout)
+			defer timer.Stop()
+
+			timeout = timer.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.Context().Done():
+				return
+			case <-timeout:
+				return
+			case <-heartbeat.C:
+				_, err = fmt.Fprint(rw, ": heartbeat\n\n")
+			case event, ok := <-subscription.Events():
+				if !ok {
+					return
+				}
+
+				err = writeEvent(rw, event)
+			}
+
+			if err != nil {
+				h.logger.ErrorContext(r.Context(), "streaming_events_failed", "error", err)
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes the event in the text/event-stream format, named after its type and with its
+// outbox id so clients can tell repeated deliveries apart
+func writeEvent(rw http.ResponseWriter, event models.TicketEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if event.EventID != 0 {
+		_, err = fmt.Fprintf(rw, "id: %d\n", event.EventID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event.Type, data)
+
+	return err
+}
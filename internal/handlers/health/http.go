@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	healthService "github.com/syned13/ticket-support-back/internal/service/health"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+type HTTPHandler interface {
+	HandleLiveness() http.HandlerFunc
+	HandleReadiness() http.HandlerFunc
+	HandleVersion() http.HandlerFunc
+}
+
+type httpHandler struct {
+	service healthService.Service
+}
+
+// StatusResponse the answer of the liveness probe
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+// SetupRoutes registers the probes of the orchestrator, they do not need a token
+func SetupRoutes(service healthService.Service, router *mux.Router) {
+	handler := httpHandler{service: service}
+
+	router.HandleFunc("/healthz", handler.HandleLiveness()).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", handler.HandleReadiness()).Methods(http.MethodGet)
+	router.HandleFunc("/version", handler.HandleVersion()).Methods(http.MethodGet)
+}
+
+// HandleLiveness answers as long as the process is able to serve requests, it does not check any
+// dependency so a database outage does not get the service restarted
+func (h httpHandler) HandleLiveness() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		httputils.RespondJSON(rw, http.StatusOK, StatusResponse{Status: "ok"})
+	}
+}
+
+// HandleReadiness answers 503 while the database is unreachable or its schema is behind the build
+func (h httpHandler) HandleReadiness() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		readiness := h.service.Ready(r.Context())
+
+		statusCode := http.StatusOK
+		if !readiness.Ready {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		httputils.RespondJSON(rw, statusCode, readiness)
+	}
+}
+
+func (h httpHandler) HandleVersion() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		httputils.RespondJSON(rw, http.StatusOK, h.service.Version())
+	}
+}
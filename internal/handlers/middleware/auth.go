@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	authService "github.com/syned13/ticket-support-back/internal/service/auth"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+// TokenValidator validates access tokens
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, accessToken string) (authService.TokenClaims, error)
+}
+
+// AuthMiddleWare returns a middleware that only lets through requests with a valid access token. The
+// id, role and permissions of the user are set in the sub, role and permissions headers
+func AuthMiddleWare(validator TokenValidator, logger *slog.Logger) func(http.HandlerFunc) http.HandlerFunc {
+	return func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			setupPreflightResponse(&rw, r)
+			if r.Method == http.MethodOptions {
+				return
+			}
+
+			token, err := GetToken(*r)
+			if err != nil {
+				logger.DebugContext(r.Context(), "missing_token")
+				httputils.RespondWithError(rw, err)
+				return
+			}
+
+			claims, err := validator.ValidateToken(r.Context(), token)
+			if err != nil {
+				logger.WarnContext(r.Context(), "validating_token_failed", "error", err)
+				httputils.RespondWithError(rw, err)
+				return
+			}
+
+			r.Header.Set("sub", claims.Subject)
+			r.Header.Set("role", string(claims.Role))
+			r.Header.Set("permissions", joinPermissions(claims.Permissions))
+
+			handler.ServeHTTP(rw, r)
+		}
+	}
+}
+
+// GetToken returns the bearer token of the request
+func GetToken(r http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", httputils.UnauthorizedError
+	}
+
+	splittedToken := strings.Split(authHeader, "Bearer ")
+	if len(splittedToken) < 2 {
+		return "", httputils.UnauthorizedError
+	}
+
+	return splittedToken[1], nil
+}
+
+// GetRequester returns the identity of the user making the request, set by the auth middleware
+func GetRequester(r *http.Request) (models.Requester, error) {
+	userID, err := strconv.ParseInt(r.Header.Get("sub"), 10, 64)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "parsing_sub_failed", "error", err)
+		return models.Requester{}, errors.New("invalid user id")
+	}
+
+	return models.Requester{
+		UserID:      userID,
+		Role:        models.Role(r.Header.Get("role")),
+		Permissions: splitPermissions(r.Header.Get("permissions")),
+	}, nil
+}
+
+// RequirePermission returns a middleware that only lets through requesters whose role grants the
+// permission. It goes after the auth middleware
+func RequirePermission(permission models.Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			requester, err := GetRequester(r)
+			if err != nil {
+				httputils.RespondWithError(rw, err)
+				return
+			}
+
+			if !requester.Can(permission) {
+				httputils.RespondWithError(rw, httputils.ForbiddenError)
+				return
+			}
+
+			handler.ServeHTTP(rw, r)
+		}
+	}
+}
+
+func joinPermissions(permissions models.Permissions) string {
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = string(permission)
+	}
+
+	return strings.Join(names, ",")
+}
+
+func splitPermissions(header string) models.Permissions {
+	permissions := models.Permissions{}
+	if header == "" {
+		return permissions
+	}
+
+	for _, name := range strings.Split(header, ",") {
+		permissions = append(permissions, models.Permission(name))
+	}
+
+	return permissions
+}
+
+func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
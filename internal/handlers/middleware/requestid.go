@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/syned13/ticket-support-back/pkg/logger"
+)
+
+// RequestIDHeader carries the id of the request, taken from the client when it sends a valid one
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID keeps ids sent by clients short and safe to log
+var validRequestID = regexp.MustCompile(`^[\w\-.]{1,64}$`)
+
+// RequestID returns a middleware that gives every request an id, put in its context for the logs and
+// echoed in the RequestIDHeader of the response. Every request is logged once it is answered
+func RequestID(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID.MatchString(requestID) {
+				requestID = newRequestID()
+			}
+
+			ctx := logger.WithRequestID(r.Context(), requestID)
+
+			rw.Header().Set(RequestIDHeader, requestID)
+			rw.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+
+			recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+			start := time.Now()
+
+			handler.ServeHTTP(recorder, r.WithContext(ctx))
+
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			log.Log(ctx, level, "request_handled",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", recorder.status,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+		})
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+
+	_, err := rand.Read(id)
+	if err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// statusRecorder keeps the status code of the response. It lets through flushes and hijacks, which the
+// event stream and the live tickets need
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+
+	r.status = http.StatusSwitchingProtocols
+	r.wroteHeader = true
+
+	return hijacker.Hijack()
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/pkg/logger"
+)
+
+func TestRequestID(t *testing.T) {
+	c := require.New(t)
+
+	buffer := &bytes.Buffer{}
+
+	log, err := logger.New(buffer, logger.Config{})
+	c.Nil(err)
+
+	var contextID string
+
+	handler := RequestID(log)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		contextID = logger.RequestID(r.Context())
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/tickets", nil))
+
+	c.Len(contextID, 32)
+	c.Equal(contextID, recorder.Header().Get(RequestIDHeader))
+	c.Contains(buffer.String(), `"status":418`)
+	c.Contains(buffer.String(), `"request_id":"`+contextID+`"`)
+
+	request := httptest.NewRequest(http.MethodGet, "/tickets", nil)
+	request.Header.Set(RequestIDHeader, "from-the-client")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	c.Equal("from-the-client", contextID)
+	c.Equal("from-the-client", recorder.Header().Get(RequestIDHeader))
+
+	request = httptest.NewRequest(http.MethodGet, "/tickets", nil)
+	request.Header.Set(RequestIDHeader, "bad id\nforged=log")
+
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	c.Len(contextID, 32)
+}
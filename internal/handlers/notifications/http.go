@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	notificationsService "github.com/syned13/ticket-support-back/internal/service/notifications"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrInvalidBody invalid request body
+	ErrInvalidBody = httputils.NewBadRequestError("invalid request body")
+)
+
+type HTTPHandler interface {
+	HandleGetPreferences() http.HandlerFunc
+	HandleUpdatePreferences() http.HandlerFunc
+	HandlePreflightRequest() http.HandlerFunc
+}
+
+type httpHandler struct {
+	service notificationsService.Service
+	logger  *slog.Logger
+}
+
+func SetupRoutes(service notificationsService.Service, tokenValidator middleware.TokenValidator, router *mux.Router, logger *slog.Logger) {
+	handler := httpHandler{service: service, logger: logger}
+	authMiddleWare := middleware.AuthMiddleWare(tokenValidator, logger)
+
+	router.HandleFunc("/notifications/preferences", authMiddleWare(handler.HandleGetPreferences())).Methods(http.MethodGet)
+	router.HandleFunc("/notifications/preferences", authMiddleWare(handler.HandleUpdatePreferences())).Methods(http.MethodPut)
+	router.HandleFunc("/notifications/preferences", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+}
+
+func (h httpHandler) HandlePreflightRequest() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+	}
+}
+
+func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
+
+func (h httpHandler) HandleGetPreferences() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		preferences, err := h.service.GetPreferences(ctx, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_notification_preferences_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, preferences)
+	}
+}
+
+func (h httpHandler) HandleUpdatePreferences() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		preferences := notificationsService.Preferences{}
+		err = json.NewDecoder(r.Body).Decode(&preferences)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		updatedPreferences, err := h.service.UpdatePreferences(ctx, preferences, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "updating_notification_preferences_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, updatedPreferences)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	"github.com/syned13/ticket-support-back/internal/models"
+	rbacService "github.com/syned13/ticket-support-back/internal/service/rbac"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+type HTTPHandler interface {
+	HandleGetRoles() http.HandlerFunc
+	HandleGetOwnPermissions() http.HandlerFunc
+	HandlePreflightRequest() http.HandlerFunc
+}
+
+type httpHandler struct {
+	service rbacService.Service
+	logger  *slog.Logger
+}
+
+// OwnPermissionsResponse the role of the requester and the permissions it grants
+type OwnPermissionsResponse struct {
+	Role        models.Role        `json:"role"`
+	Permissions models.Permissions `json:"permissions"`
+}
+
+func SetupRoutes(service rbacService.Service, tokenValidator middleware.TokenValidator, router *mux.Router, logger *slog.Logger) {
+	handler := httpHandler{service: service, logger: logger}
+	authMiddleWare := middleware.AuthMiddleWare(tokenValidator, logger)
+
+	router.HandleFunc("/roles", authMiddleWare(handler.HandleGetRoles())).Methods(http.MethodGet)
+	router.HandleFunc("/roles", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/users/me/permissions", authMiddleWare(handler.HandleGetOwnPermissions())).Methods(http.MethodGet)
+	router.HandleFunc("/users/me/permissions", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+}
+
+func (h httpHandler) HandlePreflightRequest() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+	}
+}
+
+func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
+
+func (h httpHandler) HandleGetRoles() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		roles, err := h.service.GetRoles(ctx, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_roles_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, roles)
+	}
+}
+
+// HandleGetOwnPermissions tells clients what the requester is allowed to do, so they can hide what
+// it is not
+func (h httpHandler) HandleGetOwnPermissions() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, OwnPermissionsResponse{Role: requester.Role, Permissions: requester.Permissions})
+	}
+}
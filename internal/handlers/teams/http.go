@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	"github.com/syned13/ticket-support-back/internal/models"
+	teamsService "github.com/syned13/ticket-support-back/internal/service/teams"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrInvalidBody invalid request body
+	ErrInvalidBody = httputils.NewBadRequestError("invalid request body")
+)
+
+// AddMemberRequest the user to add to a team
+type AddMemberRequest struct {
+	UserID int64 `json:"userID"`
+}
+
+// SetTicketTypesRequest the ticket types to route to a team
+type SetTicketTypesRequest struct {
+	TicketTypes []models.TicketType `json:"ticketTypes"`
+}
+
+type HTTPHandler interface {
+	HandleCreateTeam() http.HandlerFunc
+	HandleGetTeams() http.HandlerFunc
+	HandleGetTeam() http.HandlerFunc
+	HandleDeleteTeam() http.HandlerFunc
+	HandleAddMember() http.HandlerFunc
+	HandleRemoveMember() http.HandlerFunc
+	HandleSetTicketTypes() http.HandlerFunc
+	HandlePreflightRequest() http.HandlerFunc
+}
+
+type httpHandler struct {
+	service teamsService.Service
+	logger  *slog.Logger
+}
+
+func SetupRoutes(service teamsService.Service, tokenValidator middleware.TokenValidator, router *mux.Router, logger *slog.Logger) {
+	handler := httpHandler{service: service, logger: logger}
+	authMiddleWare := middleware.AuthMiddleWare(tokenValidator, logger)
+
+	router.HandleFunc("/teams", authMiddleWare(handler.HandleCreateTeam())).Methods(http.MethodPost)
+	router.HandleFunc("/teams", authMiddleWare(handler.HandleGetTeams())).Methods(http.MethodGet)
+	router.HandleFunc("/teams", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/teams/{id}", authMiddleWare(handler.HandleGetTeam())).Methods(http.MethodGet)
+	router.HandleFunc("/teams/{id}", authMiddleWare(handler.HandleDeleteTeam())).Methods(http.MethodDelete)
+	router.HandleFunc("/teams/{id}", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/teams/{id}/members", authMiddleWare(handler.HandleAddMember())).Methods(http.MethodPost)
+	router.HandleFunc("/teams/{id}/members", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/teams/{id}/members/{userID}", authMiddleWare(handler.HandleRemoveMember())).Methods(http.MethodDelete)
+	router.HandleFunc("/teams/{id}/members/{userID}", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/teams/{id}/ticket-types", authMiddleWare(handler.HandleSetTicketTypes())).Methods(http.MethodPut)
+	router.HandleFunc("/teams/{id}/ticket-types", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+}
+
+func (h httpHandler) HandlePreflightRequest() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+	}
+}
+
+func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
+
+func (h httpHandler) HandleCreateTeam() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		request := teamsService.CreateTeamRequest{}
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		team, err := h.service.CreateTeam(ctx, request, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "creating_team_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusCreated, team)
+	}
+}
+
+func (h httpHandler) HandleGetTeams() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		teams, err := h.service.GetTeams(ctx, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_teams_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, teams)
+	}
+}
+
+func (h httpHandler) HandleGetTeam() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		teamID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		team, err := h.service.GetTeam(ctx, teamID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_team_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, team)
+	}
+}
+
+func (h httpHandler) HandleDeleteTeam() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		teamID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		err = h.service.DeleteTeam(ctx, teamID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "deleting_team_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h httpHandler) HandleAddMember() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		teamID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		request := AddMemberRequest{}
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		team, err := h.service.AddMember(ctx, teamID, request.UserID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "adding_team_member_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, team)
+	}
+}
+
+func (h httpHandler) HandleRemoveMember() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		teamID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		userID, err := getPathID(r, "userID")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		team, err := h.service.RemoveMember(ctx, teamID, userID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "removing_team_member_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, team)
+	}
+}
+
+func (h httpHandler) HandleSetTicketTypes() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		teamID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		request := SetTicketTypesRequest{}
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		team, err := h.service.SetTicketTypes(ctx, teamID, request.TicketTypes, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "setting_team_ticket_types_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, team)
+	}
+}
+
+// getPathID returns the id under the given name of the request path
+func getPathID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		return 0, httputils.NewBadRequestError("invalid " + name)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	attachmentFormField = "file"
+	// multipartMemory how much of an upload is kept in memory, the rest goes to a temporary file
+	multipartMemory = 1 << 20
+	// multipartOverhead room for the multipart boundaries and headers on top of the file itself
+	multipartOverhead = 1 << 20
+)
+
+var (
+	// ErrMissingFile missing file
+	ErrMissingFile = httputils.NewBadRequestError("missing file")
+)
+
+func (h httpHandler) HandleCreateAttachment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(rw, r.Body, ticketsService.MaxAttachmentSize+multipartOverhead)
+
+		err = r.ParseMultipartForm(multipartMemory)
+		if err != nil {
+			if strings.Contains(err.Error(), "request body too large") {
+				httputils.RespondWithError(rw, ticketsService.ErrAttachmentTooLarge)
+				return
+			}
+
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		defer r.MultipartForm.RemoveAll()
+
+		file, fileHeader, err := r.FormFile(attachmentFormField)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrMissingFile)
+			return
+		}
+
+		defer file.Close()
+
+		attachment, err := h.service.CreateAttachment(ctx, ticketsService.AttachmentUpload{
+			TicketID: ticketID,
+			FileName: fileHeader.Filename,
+			Size:     fileHeader.Size,
+			Content:  file,
+		}, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "creating_attachment_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusCreated, attachment)
+	}
+}
+
+func (h httpHandler) HandleGetAttachments() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		attachments, err := h.service.GetAttachments(ctx, ticketID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_attachments_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, attachments)
+	}
+}
+
+// HandleDownloadAttachment streams the content of an attachment. It is always sent as a download
+// so uploaded files are never rendered in the context of the API
+func (h httpHandler) HandleDownloadAttachment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		attachmentID, err := strconv.ParseInt(mux.Vars(r)["attachmentID"], 10, 64)
+		if err != nil {
+			httputils.RespondWithError(rw, httputils.NewBadRequestError("invalid attachment id"))
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		attachment, content, err := h.service.OpenAttachment(ctx, ticketID, attachmentID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "downloading_attachment_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		defer content.Close()
+
+		rw.Header().Set("Content-Type", attachment.ContentType)
+		rw.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+		rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+		rw.Header().Set("X-Content-Type-Options", "nosniff")
+		rw.WriteHeader(http.StatusOK)
+
+		_, err = io.Copy(rw, content)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "streaming_attachment_failed", "error", err)
+		}
+	}
+}
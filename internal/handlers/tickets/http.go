@@ -1,17 +1,17 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"mime"
 	"net/http"
-	"os"
 	"strconv"
-	"strings"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
 	"github.com/syned13/ticket-support-back/internal/models"
 	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
 	"github.com/syned13/ticket-support-back/pkg/httputils"
@@ -22,41 +22,93 @@ var (
 	ErrMissingContentType = httputils.NewBadRequestError("missing content type")
 	// ErrInvalidBody invalid request body
 	ErrInvalidBody = httputils.NewBadRequestError("invalid request body")
-	// ErrInvalidTokenSigningMethod invalid token signing method
-	ErrInvalidTokenSigningMethod = errors.New("invalid token signing method")
+	// ErrInvalidContentType invalid content type
+	ErrInvalidContentType = httputils.NewBadRequestError("invalid content type")
+	// ErrInvalidOffset invalid pagination offset
+	ErrInvalidOffset = httputils.NewBadRequestError("invalid offset")
 	// ErrInvalidID invalid pagination id start
 	ErrInvalidID = httputils.NewBadRequestError("invalid pagination start id")
+	// ErrInvalidWithin invalid at risk window
+	ErrInvalidWithin = httputils.NewBadRequestError("invalid within duration")
+	// ErrInvalidHistoryView invalid history view
+	ErrInvalidHistoryView = httputils.NewBadRequestError("invalid view, it must be changes or timeline")
 )
 
-type claims struct {
-	UserType string `json:"userType"`
-	Sub      string `json:"sub"`
-}
-
 type HTTPHandler interface {
-	HandleCreateTicket(ctx context.Context) http.HandlerFunc
-	HandleGetTickets(ctx context.Context) http.HandlerFunc
-	HandleGetTicket(ctx context.Context) http.HandlerFunc
-	HandleGetChanges(ctx context.Context) http.HandlerFunc
+	HandleCreateTicket() http.HandlerFunc
+	HandleGetTickets() http.HandlerFunc
+	HandleGetTicket() http.HandlerFunc
+	HandleSearchTickets() http.HandlerFunc
+	HandleGetActivity() http.HandlerFunc
+	HandleGetChanges() http.HandlerFunc
+	HandleAssignTicket() http.HandlerFunc
+	HandleUnassignTicket() http.HandlerFunc
+	HandleTransferTicket() http.HandlerFunc
+	HandleCreateComment() http.HandlerFunc
+	HandleGetComments() http.HandlerFunc
+	HandleGetHistory() http.HandlerFunc
+	HandleCreateAttachment() http.HandlerFunc
+	HandleGetAttachments() http.HandlerFunc
+	HandleDownloadAttachment() http.HandlerFunc
+	HandleGetSLATickets() http.HandlerFunc
+	HandleGetWorkflow() http.HandlerFunc
 	HandlePreflightRequest() http.HandlerFunc
 }
 
 type httpHandler struct {
 	service ticketsService.Service
+	logger  *slog.Logger
 }
 
-func SetupRoutes(ctx context.Context, service ticketsService.Service, router *mux.Router) {
-	handler := httpHandler{service: service}
+func SetupRoutes(service ticketsService.Service, tokenValidator middleware.TokenValidator, router *mux.Router, logger *slog.Logger) {
+	handler := httpHandler{service: service, logger: logger}
+	authMiddleWare := middleware.AuthMiddleWare(tokenValidator, logger)
 
-	router.HandleFunc("/tickets", authMiddleWare(handler.HandleCreateTicket(ctx))).Methods(http.MethodPost)
-	router.HandleFunc("/tickets", authMiddleWare(handler.HandleGetTickets(ctx))).Methods(http.MethodGet)
+	router.HandleFunc("/tickets", authMiddleWare(handler.HandleCreateTicket())).Methods(http.MethodPost)
+	router.HandleFunc("/tickets", authMiddleWare(handler.HandleGetTickets())).Methods(http.MethodGet)
 	router.HandleFunc("/tickets", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
 
-	router.HandleFunc("/tickets/{id}", authMiddleWare(handler.HandleGetTicket(ctx))).Methods(http.MethodGet)
-	router.HandleFunc("/tickets/{id}", authMiddleWare(handler.HandleUpdateTicket(ctx))).Methods(http.MethodPatch)
-	router.HandleFunc("/tickets/{id}", handler.HandlePreflightRequest()).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/search", authMiddleWare(handler.HandleSearchTickets())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/search", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/tickets/workflow", authMiddleWare(handler.HandleGetWorkflow())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/workflow", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/tickets/sla", authMiddleWare(handler.HandleGetSLATickets())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/sla", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/tickets/{id}", authMiddleWare(handler.HandleGetTicket())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}", authMiddleWare(handler.HandleUpdateTicket())).Methods(http.MethodPatch)
+	router.HandleFunc("/tickets/{id}", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/tickets/{id}/assign", authMiddleWare(handler.HandleAssignTicket())).Methods(http.MethodPost)
+	router.HandleFunc("/tickets/{id}/assign", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+	router.HandleFunc("/tickets/{id}/unassign", authMiddleWare(handler.HandleUnassignTicket())).Methods(http.MethodPost)
+	router.HandleFunc("/tickets/{id}/unassign", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+	router.HandleFunc("/tickets/{id}/transfer", authMiddleWare(handler.HandleTransferTicket())).Methods(http.MethodPost)
+	router.HandleFunc("/tickets/{id}/transfer", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/tickets/{id}/comments", authMiddleWare(handler.HandleCreateComment())).Methods(http.MethodPost)
+	router.HandleFunc("/tickets/{id}/comments", authMiddleWare(handler.HandleGetComments())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}/comments", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/tickets/{id}/history", authMiddleWare(handler.HandleGetHistory())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}/history", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/tickets/{id}/activity", authMiddleWare(handler.HandleGetActivity())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}/activity", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
 
-	router.HandleFunc("/changes", authMiddleWare(handler.HandleGetChanges(ctx))).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}/attachments", authMiddleWare(handler.HandleCreateAttachment())).Methods(http.MethodPost)
+	router.HandleFunc("/tickets/{id}/attachments", authMiddleWare(handler.HandleGetAttachments())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}/attachments", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+	router.HandleFunc("/tickets/{id}/attachments/{attachmentID}", authMiddleWare(handler.HandleDownloadAttachment())).Methods(http.MethodGet)
+	router.HandleFunc("/tickets/{id}/attachments/{attachmentID}", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/activity", authMiddleWare(handler.HandleGetActivity())).Methods(http.MethodGet)
+	router.HandleFunc("/activity", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	// Deprecated: /changes is kept for older clients, /activity replaces it
+	router.HandleFunc("/changes", authMiddleWare(handler.HandleGetChanges())).Methods(http.MethodGet)
 	router.HandleFunc("/changes", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
 }
 
@@ -72,8 +124,10 @@ func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
 	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 }
 
-func (h httpHandler) HandleCreateTicket(ctx context.Context) http.HandlerFunc {
+func (h httpHandler) HandleCreateTicket() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
 		setupPreflightResponse(&rw, r)
 
 		err := validateContentType(*r)
@@ -115,39 +169,27 @@ func (h httpHandler) HandleCreateTicket(ctx context.Context) http.HandlerFunc {
 	}
 }
 
-func (h httpHandler) HandleGetTickets(ctx context.Context) http.HandlerFunc {
+func (h httpHandler) HandleGetTickets() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
-		setupPreflightResponse(&rw, r)
-
-		lastIDStr := r.URL.Query().Get("after_id")
+		ctx := r.Context()
 
-		var lastID int64 = 0
+		setupPreflightResponse(&rw, r)
 
-		if lastIDStr != "" {
-			if id, err := strconv.ParseInt(lastIDStr, 10, 64); err == nil {
-				lastID = id
-			} else {
-				httputils.RespondWithError(rw, ErrInvalidID)
-				return
-			}
+		filter, err := parseTicketsFilter(r.URL.Query())
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
 		}
 
-		userIDStr := r.Header.Get("sub")
-		var userID int64
-
-		if id, err := strconv.ParseInt(userIDStr, 10, 64); err == nil {
-			userID = id
-		} else {
-			fmt.Println("parsing_sub_failed: " + err.Error())
-			httputils.RespondWithError(rw, errors.New("invalid user id"))
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		userType := r.Header.Get("userType")
-
-		response, err := h.service.GetTickets(ctx, userID, models.UserType(userType), lastID)
+		response, err := h.service.GetTickets(ctx, filter, requester)
 		if err != nil {
-			fmt.Println("getting_tickets_failed: " + err.Error())
+			h.logger.ErrorContext(ctx, "getting_tickets_failed", "error", err)
 			httputils.RespondWithError(rw, err)
 			return
 		}
@@ -156,13 +198,14 @@ func (h httpHandler) HandleGetTickets(ctx context.Context) http.HandlerFunc {
 	}
 }
 
-func (h httpHandler) HandleGetTicket(ctx context.Context) http.HandlerFunc {
+func (h httpHandler) HandleGetTicket() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
 		setupPreflightResponse(&rw, r)
 
 		vars := mux.Vars(r)
 
-		// TODO: take the sub from headers and verify the one requesting the ticket is either the creatoe or an admin
 		ticketIDStr := vars["id"]
 		if ticketIDStr == "" {
 			httputils.RespondWithError(rw, errors.New("missing ticket id"))
@@ -177,9 +220,15 @@ func (h httpHandler) HandleGetTicket(ctx context.Context) http.HandlerFunc {
 			return
 		}
 
-		ticket, err := h.service.GetTicket(ctx, ticketID)
+		requester, err := middleware.GetRequester(r)
 		if err != nil {
-			fmt.Println("getting_ticket_failed: " + err.Error())
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		ticket, err := h.service.GetTicket(ctx, ticketID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_ticket_failed", "error", err)
 			httputils.RespondWithError(rw, err)
 			return
 		}
@@ -188,8 +237,46 @@ func (h httpHandler) HandleGetTicket(ctx context.Context) http.HandlerFunc {
 	}
 }
 
-func (h httpHandler) HandleUpdateTicket(ctx context.Context) http.HandlerFunc {
+func (h httpHandler) HandleSearchTickets() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		offsetStr := r.URL.Query().Get("offset")
+
+		offset := 0
+
+		if offsetStr != "" {
+			if o, err := strconv.Atoi(offsetStr); err == nil {
+				offset = o
+			} else {
+				httputils.RespondWithError(rw, ErrInvalidOffset)
+				return
+			}
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		response, err := h.service.SearchTickets(ctx, r.URL.Query().Get("q"), offset, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "searching_tickets_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, response)
+	}
+}
+
+func (h httpHandler) HandleUpdateTicket() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
 		setupPreflightResponse(&rw, r)
 		if r.Method == http.MethodOptions {
 			return
@@ -203,7 +290,6 @@ func (h httpHandler) HandleUpdateTicket(ctx context.Context) http.HandlerFunc {
 
 		vars := mux.Vars(r)
 
-		// TODO: take the sub from headers and verify the one requesting the ticket is either the creatoe or an admin
 		ticketIDStr := vars["id"]
 		if ticketIDStr == "" {
 			httputils.RespondWithError(rw, errors.New("missing ticket id"))
@@ -218,14 +304,19 @@ func (h httpHandler) HandleUpdateTicket(ctx context.Context) http.HandlerFunc {
 			return
 		}
 
-		patchRequest := httputils.PatchRequest{}
-		err = json.NewDecoder(r.Body).Decode(&patchRequest)
+		requester, err := middleware.GetRequester(r)
 		if err != nil {
-			httputils.RespondWithError(rw, ErrInvalidBody)
+			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		updatedTicket, err := h.service.UpdateTicket(ctx, patchRequest, ticketID)
+		patch, err := decodePatch(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		updatedTicket, err := h.service.UpdateTicket(ctx, patch, ticketID, requester)
 		if err != nil {
 			httputils.RespondWithError(rw, err)
 			return
@@ -235,24 +326,64 @@ func (h httpHandler) HandleUpdateTicket(ctx context.Context) http.HandlerFunc {
 	}
 }
 
-func (h httpHandler) HandleGetChanges(ctx context.Context) http.HandlerFunc {
+// HandleGetActivity returns a page of the activity feed. Under /tickets/{id}/activity it is scoped
+// to that ticket
+func (h httpHandler) HandleGetActivity() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
 		setupPreflightResponse(&rw, r)
 
-		userIDStr := r.Header.Get("sub")
-		var userID int64
+		filter, err := parseActivityFilter(r.URL.Query())
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
 
-		if id, err := strconv.ParseInt(userIDStr, 10, 64); err == nil {
-			userID = id
-		} else {
-			fmt.Println("parsing_sub_failed: " + err.Error())
-			httputils.RespondWithError(rw, errors.New("invalid user id"))
+		if _, ok := mux.Vars(r)["id"]; ok {
+			ticketID, err := getTicketID(r)
+			if err != nil {
+				httputils.RespondWithError(rw, err)
+				return
+			}
+
+			filter.TicketID = &ticketID
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		changes, err := h.service.GetTicketChanges(ctx, userID)
+		response, err := h.service.GetActivity(ctx, filter, requester)
 		if err != nil {
-			fmt.Println("getting_changes_failed: " + err.Error())
+			h.logger.ErrorContext(ctx, "getting_activity_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, response)
+	}
+}
+
+// HandleGetChanges returns, as a plain array with no paging, every change made to the tickets the
+// requester created. It is what /changes answered before /activity replaced it
+func (h httpHandler) HandleGetChanges() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		changes, err := h.service.GetTicketChanges(ctx, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_changes_failed", "error", err)
 			httputils.RespondWithError(rw, err)
 			return
 		}
@@ -261,85 +392,319 @@ func (h httpHandler) HandleGetChanges(ctx context.Context) http.HandlerFunc {
 	}
 }
 
-func authMiddleWare(handler http.HandlerFunc) http.HandlerFunc {
+func (h httpHandler) HandleAssignTicket() http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
 		setupPreflightResponse(&rw, r)
-		if r.Method == http.MethodOptions {
+
+		err := validateContentType(*r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		token, err := getToken(*r)
+		ticketID, err := getTicketID(r)
 		if err != nil {
-			fmt.Println(err.Error())
 			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		authToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				fmt.Println("aqui tambien")
-				return nil, ErrInvalidTokenSigningMethod
-			}
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
 
-			return []byte(os.Getenv("TOKEN_SECRET")), nil
-		})
+		assignRequest := AssignTicketRequest{}
+		err = json.NewDecoder(r.Body).Decode(&assignRequest)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
 
+		ticket, err := h.service.AssignTicket(ctx, ticketID, assignRequest.OwnerID, requester)
 		if err != nil {
-			fmt.Println("here")
-			httputils.RespondWithError(rw, httputils.ForbiddenError)
+			h.logger.ErrorContext(ctx, "assigning_ticket_failed", "error", err)
+			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		if !authToken.Valid {
-			fmt.Println("hier")
-			httputils.RespondWithError(rw, httputils.ForbiddenError)
+		httputils.RespondJSON(rw, http.StatusOK, ticket)
+	}
+}
+
+func (h httpHandler) HandleUnassignTicket() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		claims, err := getTokenClaims(authToken)
+		requester, err := middleware.GetRequester(r)
 		if err != nil {
-			fmt.Println("aqui")
-			httputils.RespondWithError(rw, httputils.ForbiddenError)
+			httputils.RespondWithError(rw, err)
 			return
 		}
 
-		r.Header.Set("sub", claims.Sub)
-		r.Header.Set("userType", claims.UserType)
+		ticket, err := h.service.UnassignTicket(ctx, ticketID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "unassigning_ticket_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
 
-		handler.ServeHTTP(rw, r)
+		httputils.RespondJSON(rw, http.StatusOK, ticket)
 	}
 }
 
-func getToken(r http.Request) (string, error) {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return "", httputils.UnauthorizedError
+func (h httpHandler) HandleTransferTicket() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		err := validateContentType(*r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		transferRequest := TransferTicketRequest{}
+		err = json.NewDecoder(r.Body).Decode(&transferRequest)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		ticket, err := h.service.TransferTicket(ctx, ticketID, transferRequest.TeamID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "transferring_ticket_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, ticket)
+	}
+}
+
+func (h httpHandler) HandleCreateComment() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		err := validateContentType(*r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		comment := models.TicketComment{}
+		err = json.NewDecoder(r.Body).Decode(&comment)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		comment.TicketID = ticketID
+
+		createdComment, err := h.service.CreateComment(ctx, comment, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "creating_comment_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusCreated, createdComment)
+	}
+}
+
+func (h httpHandler) HandleGetComments() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		comments, err := h.service.GetComments(ctx, ticketID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_comments_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, comments)
 	}
+}
+
+// HandleGetHistory returns the changes made to a ticket. With view=timeline they are merged with
+// the comments of the ticket
+func (h httpHandler) HandleGetHistory() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
 
-	splittedToken := strings.Split(authHeader, "Bearer ")
-	if len(splittedToken) < 2 {
-		return "", httputils.UnauthorizedError
+		ticketID, err := getTicketID(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		switch r.URL.Query().Get("view") {
+		case "", "changes":
+			changes, err := h.service.GetTicketHistory(ctx, ticketID, requester)
+			if err != nil {
+				h.logger.ErrorContext(ctx, "getting_history_failed", "error", err)
+				httputils.RespondWithError(rw, err)
+				return
+			}
+
+			httputils.RespondJSON(rw, http.StatusOK, changes)
+		case "timeline":
+			timeline, err := h.service.GetTicketTimeline(ctx, ticketID, requester)
+			if err != nil {
+				h.logger.ErrorContext(ctx, "getting_timeline_failed", "error", err)
+				httputils.RespondWithError(rw, err)
+				return
+			}
+
+			httputils.RespondJSON(rw, http.StatusOK, timeline)
+		default:
+			httputils.RespondWithError(rw, ErrInvalidHistoryView)
+		}
 	}
+}
+
+func (h httpHandler) HandleGetSLATickets() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
 
-	return splittedToken[1], nil
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		var within time.Duration
+		if withinStr := r.URL.Query().Get("within"); withinStr != "" {
+			within, err = time.ParseDuration(withinStr)
+			if err != nil || within <= 0 {
+				httputils.RespondWithError(rw, ErrInvalidWithin)
+				return
+			}
+		}
+
+		tickets, err := h.service.GetSLATickets(ctx, within, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_sla_tickets_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, tickets)
+	}
+}
+
+func (h httpHandler) HandleGetWorkflow() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+
+		httputils.RespondJSON(rw, http.StatusOK, h.service.GetWorkflow())
+	}
 }
 
-func getTokenClaims(token *jwt.Token) (claims, error) {
-	claimsBytes, err := json.Marshal(token.Claims)
+// decodePatch decodes the body of a patch request as a JSON Merge Patch when sent with the
+// merge patch content type and as a JSON Patch otherwise
+func decodePatch(r *http.Request) (httputils.Patch, error) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
-		fmt.Println(err.Error())
-		return claims{}, err
+		return nil, ErrInvalidContentType
+	}
+
+	switch contentType {
+	case httputils.ContentTypeMergePatch:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil || !json.Valid(body) {
+			return nil, ErrInvalidBody
+		}
+
+		return httputils.MergePatchRequest(body), nil
+	case httputils.ContentTypeJSONPatch, "application/json":
+		patchRequest := httputils.PatchRequest{}
+		err = json.NewDecoder(r.Body).Decode(&patchRequest)
+		if err != nil {
+			return nil, ErrInvalidBody
+		}
+
+		return patchRequest, nil
+	default:
+		return nil, ErrInvalidContentType
+	}
+}
+
+// getTicketID returns the ticket id from the request path
+func getTicketID(r *http.Request) (int64, error) {
+	ticketIDStr := mux.Vars(r)["id"]
+	if ticketIDStr == "" {
+		return 0, httputils.NewBadRequestError("missing ticket id")
 	}
 
-	tokenClaims := claims{}
-	err = json.Unmarshal(claimsBytes, &tokenClaims)
+	ticketID, err := strconv.ParseInt(ticketIDStr, 10, 64)
 	if err != nil {
-		fmt.Println("yes yes")
-		fmt.Println(err.Error())
-		return claims{}, err
+		return 0, httputils.NewBadRequestError("invalid ticket id")
 	}
 
-	return tokenClaims, nil
+	return ticketID, nil
 }
 
 func validateContentType(r http.Request) error {
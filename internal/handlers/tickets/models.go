@@ -0,0 +1,12 @@
+package handlers
+
+// AssignTicketRequest has the fields for a ticket assignment request body
+type AssignTicketRequest struct {
+	OwnerID int64 `json:"ownerID"`
+}
+
+// TransferTicketRequest has the fields for a ticket transfer request body. A null team takes the
+// ticket out of any team
+type TransferTicketRequest struct {
+	TeamID *int64 `json:"teamID"`
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+// parseTicketsFilter reads the filters of a tickets listing from the query string. Fields accepting
+// many values take them comma separated, e.g. status=pending,in_progress. scope=all lists the tickets
+// of every team instead of the queues of the teams of the requester
+func parseTicketsFilter(query url.Values) (ticketsService.GetTicketsFilter, error) {
+	filter := ticketsService.GetTicketsFilter{
+		SortBy: query.Get("sort_by"),
+		Order:  query.Get("order"),
+		Cursor: query.Get("cursor"),
+	}
+
+	for _, status := range splitValues(query.Get("status")) {
+		filter.Statuses = append(filter.Statuses, models.TicketStatus(status))
+	}
+
+	for _, ticketType := range splitValues(query.Get("type")) {
+		filter.Types = append(filter.Types, models.TicketType(ticketType))
+	}
+
+	for _, value := range splitValues(query.Get("severity")) {
+		severity, err := strconv.Atoi(value)
+		if err != nil {
+			return ticketsService.GetTicketsFilter{}, httputils.NewBadRequestError("invalid severity")
+		}
+
+		filter.Severities = append(filter.Severities, models.TicketSeverity(severity))
+	}
+
+	for _, value := range splitValues(query.Get("priority")) {
+		priority, err := strconv.Atoi(value)
+		if err != nil {
+			return ticketsService.GetTicketsFilter{}, httputils.NewBadRequestError("invalid priority")
+		}
+
+		filter.Priorities = append(filter.Priorities, models.TicketPriority(priority))
+	}
+
+	switch query.Get("scope") {
+	case "", "team":
+	case "all":
+		filter.AllTeams = true
+	default:
+		return ticketsService.GetTicketsFilter{}, httputils.NewBadRequestError("invalid scope")
+	}
+
+	var err error
+
+	if filter.OwnerID, err = parseOptionalID(query, "owner_id"); err != nil {
+		return ticketsService.GetTicketsFilter{}, err
+	}
+
+	if filter.TeamID, err = parseOptionalID(query, "team_id"); err != nil {
+		return ticketsService.GetTicketsFilter{}, err
+	}
+
+	if filter.CreatorID, err = parseOptionalID(query, "creator_id"); err != nil {
+		return ticketsService.GetTicketsFilter{}, err
+	}
+
+	if filter.CreatedAfter, err = parseOptionalTime(query, "created_after"); err != nil {
+		return ticketsService.GetTicketsFilter{}, err
+	}
+
+	if filter.CreatedBefore, err = parseOptionalTime(query, "created_before"); err != nil {
+		return ticketsService.GetTicketsFilter{}, err
+	}
+
+	if filter.UpdatedAfter, err = parseOptionalTime(query, "updated_after"); err != nil {
+		return ticketsService.GetTicketsFilter{}, err
+	}
+
+	if filter.UpdatedBefore, err = parseOptionalTime(query, "updated_before"); err != nil {
+		return ticketsService.GetTicketsFilter{}, err
+	}
+
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		filter.PageSize, err = strconv.Atoi(pageSizeStr)
+		if err != nil {
+			return ticketsService.GetTicketsFilter{}, httputils.NewBadRequestError("invalid page size")
+		}
+	}
+
+	if lastIDStr := query.Get("after_id"); lastIDStr != "" {
+		filter.AfterID, err = strconv.ParseInt(lastIDStr, 10, 64)
+		if err != nil {
+			return ticketsService.GetTicketsFilter{}, ErrInvalidID
+		}
+	}
+
+	return filter, nil
+}
+
+// parseActivityFilter reads the filters of the activity feed from the query string. scope=all lists
+// the activity of every ticket
+func parseActivityFilter(query url.Values) (ticketsService.GetActivityFilter, error) {
+	filter := ticketsService.GetActivityFilter{
+		Cursor: query.Get("cursor"),
+	}
+
+	for _, activityType := range splitValues(query.Get("type")) {
+		filter.Types = append(filter.Types, models.TicketActivityType(activityType))
+	}
+
+	switch query.Get("scope") {
+	case "", "mine":
+	case "all":
+		filter.All = true
+	default:
+		return ticketsService.GetActivityFilter{}, httputils.NewBadRequestError("invalid scope")
+	}
+
+	var err error
+
+	if filter.TicketID, err = parseOptionalID(query, "ticket_id"); err != nil {
+		return ticketsService.GetActivityFilter{}, err
+	}
+
+	if filter.ActorID, err = parseOptionalID(query, "actor_id"); err != nil {
+		return ticketsService.GetActivityFilter{}, err
+	}
+
+	if filter.After, err = parseOptionalTime(query, "after"); err != nil {
+		return ticketsService.GetActivityFilter{}, err
+	}
+
+	if filter.Before, err = parseOptionalTime(query, "before"); err != nil {
+		return ticketsService.GetActivityFilter{}, err
+	}
+
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		filter.PageSize, err = strconv.Atoi(pageSizeStr)
+		if err != nil {
+			return ticketsService.GetActivityFilter{}, httputils.NewBadRequestError("invalid page size")
+		}
+	}
+
+	return filter, nil
+}
+
+func splitValues(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func parseOptionalID(query url.Values, key string) (*int64, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, httputils.NewBadRequestError("invalid " + key)
+	}
+
+	return &id, nil
+}
+
+// parseOptionalTime parses RFC 3339 timestamps and plain dates such as 2021-04-30
+func parseOptionalTime(query url.Values, key string) (*time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, httputils.NewBadRequestError("invalid " + key)
+}
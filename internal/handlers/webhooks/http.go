@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/syned13/ticket-support-back/internal/handlers/middleware"
+	"github.com/syned13/ticket-support-back/internal/models"
+	webhooksService "github.com/syned13/ticket-support-back/internal/service/webhooks"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrInvalidBody invalid request body
+	ErrInvalidBody = httputils.NewBadRequestError("invalid request body")
+	// ErrInvalidID invalid pagination id start
+	ErrInvalidID = httputils.NewBadRequestError("invalid pagination start id")
+)
+
+type HTTPHandler interface {
+	HandleCreateWebhook() http.HandlerFunc
+	HandleGetWebhooks() http.HandlerFunc
+	HandleDeleteWebhook() http.HandlerFunc
+	HandleGetDeliveries() http.HandlerFunc
+	HandleReplayDelivery() http.HandlerFunc
+	HandlePreflightRequest() http.HandlerFunc
+}
+
+type httpHandler struct {
+	service webhooksService.Service
+	logger  *slog.Logger
+}
+
+func SetupRoutes(service webhooksService.Service, tokenValidator middleware.TokenValidator, router *mux.Router, logger *slog.Logger) {
+	handler := httpHandler{service: service, logger: logger}
+	authMiddleWare := middleware.AuthMiddleWare(tokenValidator, logger)
+	requirePermission := middleware.RequirePermission(models.PermissionWebhookManage)
+
+	router.HandleFunc("/webhooks", authMiddleWare(requirePermission(handler.HandleCreateWebhook()))).Methods(http.MethodPost)
+	router.HandleFunc("/webhooks", authMiddleWare(requirePermission(handler.HandleGetWebhooks()))).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/webhooks/{id}", authMiddleWare(requirePermission(handler.HandleDeleteWebhook()))).Methods(http.MethodDelete)
+	router.HandleFunc("/webhooks/{id}", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/webhooks/{id}/deliveries", authMiddleWare(requirePermission(handler.HandleGetDeliveries()))).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{id}/deliveries", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+
+	router.HandleFunc("/webhooks/{id}/deliveries/{deliveryID}/replay", authMiddleWare(requirePermission(handler.HandleReplayDelivery()))).Methods(http.MethodPost)
+	router.HandleFunc("/webhooks/{id}/deliveries/{deliveryID}/replay", handler.HandlePreflightRequest()).Methods(http.MethodOptions)
+}
+
+func (h httpHandler) HandlePreflightRequest() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		setupPreflightResponse(&rw, r)
+	}
+}
+
+func setupPreflightResponse(w *http.ResponseWriter, req *http.Request) {
+	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
+
+func (h httpHandler) HandleCreateWebhook() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		request := webhooksService.CreateWebhookRequest{}
+		err = json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			httputils.RespondWithError(rw, ErrInvalidBody)
+			return
+		}
+
+		webhook, err := h.service.CreateWebhook(ctx, request, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "creating_webhook_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusCreated, webhook)
+	}
+}
+
+func (h httpHandler) HandleGetWebhooks() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		webhooks, err := h.service.GetWebhooks(ctx, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_webhooks_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, webhooks)
+	}
+}
+
+func (h httpHandler) HandleDeleteWebhook() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		webhookID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		err = h.service.DeleteWebhook(ctx, webhookID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "deleting_webhook_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h httpHandler) HandleGetDeliveries() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		webhookID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		var lastID int64
+		if lastIDStr := r.URL.Query().Get("before_id"); lastIDStr != "" {
+			lastID, err = strconv.ParseInt(lastIDStr, 10, 64)
+			if err != nil || lastID < 0 {
+				httputils.RespondWithError(rw, ErrInvalidID)
+				return
+			}
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		deliveries, err := h.service.GetDeliveries(ctx, webhookID, lastID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "getting_webhook_deliveries_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, deliveries)
+	}
+}
+
+func (h httpHandler) HandleReplayDelivery() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		setupPreflightResponse(&rw, r)
+
+		webhookID, err := getPathID(r, "id")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		deliveryID, err := getPathID(r, "deliveryID")
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		requester, err := middleware.GetRequester(r)
+		if err != nil {
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		delivery, err := h.service.ReplayDelivery(ctx, webhookID, deliveryID, requester)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "replaying_webhook_delivery_failed", "error", err)
+			httputils.RespondWithError(rw, err)
+			return
+		}
+
+		httputils.RespondJSON(rw, http.StatusOK, delivery)
+	}
+}
+
+// getPathID returns the id under the given name of the request path
+func getPathID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		return 0, httputils.NewBadRequestError("invalid " + name)
+	}
+
+	return id, nil
+}
@@ -1,3 +1,7 @@
+-- the schema of scripts/create_tables.sql before the migrations existed. Every statement can run on a
+-- database created by that script, so those are adopted by this migration and brought up to date by
+-- the following ones. The admins the script used to insert are created with create-admin instead
+
 CREATE TABLE IF NOT EXISTS users(
     id SERIAL PRIMARY KEY,
     name TEXT NOT NULL,
@@ -7,23 +11,6 @@ CREATE TABLE IF NOT EXISTS users(
     created_at TIMESTAMP NOT NULL
 );
 
-INSERT INTO users 
-(name, email, password, user_type, created_at)
-VALUES ('Erica Ross', 'erica@erica.com', '$2y$12$ZPmLiyARMnzTFZuvhj42y.7PyPh5TVQfvu4IGpPFOopAs4c9rA1km', 'admin', NOW());
-
-INSERT INTO users 
-(name, email, password, user_type, created_at)
-VALUES ('Denys Rosario', 'denys@denys.com', '$2y$12$ZPmLiyARMnzTFZuvhj42y.7PyPh5TVQfvu4IGpPFOopAs4c9rA1km', 'admin', NOW());
-
-INSERT INTO users 
-(name, email, password, user_type, created_at)
-VALUES ('Angelica Pena', 'angelica@angelica.com', '$2y$12$ZPmLiyARMnzTFZuvhj42y.7PyPh5TVQfvu4IGpPFOopAs4c9rA1km', 'admin', NOW());
-
-INSERT INTO users 
-(name, email, password, user_type, created_at)
-VALUES ('Leiscar Trinidad', 'leiscar@leiscar.com', '$2y$12$ZPmLiyARMnzTFZuvhj42y.7PyPh5TVQfvu4IGpPFOopAs4c9rA1km', 'admin', NOW());
-
-
 CREATE TABLE IF NOT EXISTS tickets (
     id SERIAL PRIMARY KEY,
     title TEXT NOT NULL,
@@ -45,4 +32,4 @@ CREATE TABLE IF NOT EXISTS tickets_changes (
     creator_id INT NOT NULL REFERENCES users (id),
     to_status TEXT NOT NULL,
     changed_at TIMESTAMP NOT NULL
-);
\ No newline at end of file
+);
@@ -0,0 +1 @@
+DROP TABLE tickets_comments;
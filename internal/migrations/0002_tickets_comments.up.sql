@@ -0,0 +1,8 @@
+CREATE TABLE tickets_comments (
+    id SERIAL PRIMARY KEY,
+    ticket_id INT NOT NULL REFERENCES tickets (id),
+    author_id INT NOT NULL REFERENCES users (id),
+    body TEXT NOT NULL,
+    internal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL
+);
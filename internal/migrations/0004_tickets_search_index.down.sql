@@ -0,0 +1 @@
+DROP INDEX tickets_search_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX tickets_search_idx ON tickets
+    USING GIN (to_tsvector('english', title || ' ' || ticket_description));
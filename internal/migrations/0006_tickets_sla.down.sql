@@ -0,0 +1,5 @@
+ALTER TABLE tickets
+    DROP COLUMN first_response_due_at,
+    DROP COLUMN first_responded_at,
+    DROP COLUMN due_at,
+    DROP COLUMN breached;
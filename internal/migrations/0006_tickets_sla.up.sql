@@ -0,0 +1,6 @@
+-- the deadlines of the tickets opened before are left empty, so they are never reported as breached
+ALTER TABLE tickets
+    ADD COLUMN first_response_due_at TIMESTAMP,
+    ADD COLUMN first_responded_at TIMESTAMP,
+    ADD COLUMN due_at TIMESTAMP,
+    ADD COLUMN breached BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+CREATE TABLE tickets_attachments (
+    id SERIAL PRIMARY KEY,
+    ticket_id INT NOT NULL REFERENCES tickets (id),
+    uploader_id INT NOT NULL REFERENCES users (id),
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
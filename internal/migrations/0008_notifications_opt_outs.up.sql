@@ -0,0 +1,5 @@
+CREATE TABLE notifications_opt_outs (
+    user_id INT NOT NULL REFERENCES users (id),
+    event_type TEXT NOT NULL,
+    PRIMARY KEY (user_id, event_type)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    creator_id INT NOT NULL REFERENCES users (id),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhooks_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempt INT NOT NULL,
+    replay_of INT REFERENCES webhooks_deliveries (id) ON DELETE SET NULL,
+    status_code INT,
+    error TEXT,
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
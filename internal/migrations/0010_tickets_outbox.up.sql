@@ -0,0 +1,14 @@
+CREATE TABLE tickets_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    ticket_id INT NOT NULL REFERENCES tickets (id),
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL,
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX tickets_outbox_pending_idx ON tickets_outbox (next_attempt_at)
+    WHERE dispatched_at IS NULL;
@@ -0,0 +1,15 @@
+-- only the status changes fit in the previous log, the rest are lost
+DROP INDEX tickets_changes_ticket_idx;
+
+DELETE FROM tickets_changes WHERE field <> 'status' OR to_value IS NULL;
+
+ALTER TABLE tickets_changes ADD COLUMN to_status TEXT;
+
+UPDATE tickets_changes SET to_status = to_value;
+
+ALTER TABLE tickets_changes
+    ALTER COLUMN to_status SET NOT NULL,
+    DROP COLUMN changed_by,
+    DROP COLUMN field,
+    DROP COLUMN from_value,
+    DROP COLUMN to_value;
@@ -0,0 +1,25 @@
+-- the log only had status changes before, without the previous status nor who made them, so the
+-- previous status is taken from the change before it and the creator of the ticket is kept as the author
+ALTER TABLE tickets_changes
+    ADD COLUMN changed_by INT REFERENCES users (id),
+    ADD COLUMN field TEXT,
+    ADD COLUMN from_value TEXT,
+    ADD COLUMN to_value TEXT;
+
+UPDATE tickets_changes c SET
+    changed_by = c.creator_id,
+    field = 'status',
+    from_value = previous.from_value,
+    to_value = c.to_status
+FROM (
+    SELECT id, LAG(to_status) OVER (PARTITION BY ticket_id ORDER BY changed_at, id) AS from_value
+    FROM tickets_changes
+) previous
+WHERE previous.id = c.id;
+
+ALTER TABLE tickets_changes
+    ALTER COLUMN changed_by SET NOT NULL,
+    ALTER COLUMN field SET NOT NULL,
+    DROP COLUMN to_status;
+
+CREATE INDEX tickets_changes_ticket_idx ON tickets_changes (ticket_id);
@@ -0,0 +1,12 @@
+-- every role but admin goes back to being a user
+ALTER TABLE users ADD COLUMN user_type TEXT;
+
+UPDATE users SET user_type = CASE WHEN role = 'admin' THEN 'admin' ELSE 'user' END;
+
+ALTER TABLE users
+    ALTER COLUMN user_type SET NOT NULL,
+    DROP COLUMN role;
+
+DROP TABLE roles_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
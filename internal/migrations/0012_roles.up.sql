@@ -0,0 +1,65 @@
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+CREATE TABLE permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+CREATE TABLE roles_permissions (
+    role TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+('requester', 'Opens tickets and follows up on their own ones'),
+('agent', 'Works on the tickets'),
+('supervisor', 'Works on the tickets and distributes them among the agents'),
+('admin', 'Can do everything, including managing users and integrations');
+
+INSERT INTO permissions (name, description) VALUES
+('ticket:read_all', 'See every ticket, not only the own ones'),
+('ticket:update_all', 'Change every ticket, not only the own ones'),
+('ticket:work', 'Own tickets and move them through the workflow'),
+('ticket:assign', 'Assign and unassign tickets'),
+('ticket:internal', 'Read and write internal comments'),
+('sla:read', 'See the SLA report'),
+('user:read', 'See every user'),
+('user:manage', 'Change, deactivate and set the role of users'),
+('webhook:manage', 'Manage the webhooks and their deliveries');
+
+INSERT INTO roles_permissions (role, permission) VALUES
+('agent', 'ticket:read_all'),
+('agent', 'ticket:update_all'),
+('agent', 'ticket:work'),
+('agent', 'ticket:internal'),
+('agent', 'sla:read'),
+('agent', 'user:read'),
+('supervisor', 'ticket:read_all'),
+('supervisor', 'ticket:update_all'),
+('supervisor', 'ticket:work'),
+('supervisor', 'ticket:assign'),
+('supervisor', 'ticket:internal'),
+('supervisor', 'sla:read'),
+('supervisor', 'user:read'),
+('admin', 'ticket:read_all'),
+('admin', 'ticket:update_all'),
+('admin', 'ticket:work'),
+('admin', 'ticket:assign'),
+('admin', 'ticket:internal'),
+('admin', 'sla:read'),
+('admin', 'user:read'),
+('admin', 'user:manage'),
+('admin', 'webhook:manage');
+
+-- the admins keep every permission they had and the users become requesters
+ALTER TABLE users ADD COLUMN role TEXT REFERENCES roles (name);
+
+UPDATE users SET role = CASE WHEN user_type = 'admin' THEN 'admin' ELSE 'requester' END;
+
+ALTER TABLE users
+    ALTER COLUMN role SET NOT NULL,
+    DROP COLUMN user_type;
@@ -0,0 +1,8 @@
+ALTER TABLE tickets DROP COLUMN team_id;
+
+DROP TABLE teams_routes;
+DROP TABLE teams_members;
+DROP TABLE teams;
+
+-- the grants of the permission are removed along with it
+DELETE FROM permissions WHERE name = 'team:manage';
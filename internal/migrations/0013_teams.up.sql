@@ -0,0 +1,30 @@
+INSERT INTO permissions (name, description) VALUES
+('team:manage', 'Create teams, change their members and the tickets routed to them');
+
+INSERT INTO roles_permissions (role, permission) VALUES
+('supervisor', 'team:manage'),
+('admin', 'team:manage');
+
+CREATE TABLE teams (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE teams_members (
+    team_id INT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX teams_members_user_idx ON teams_members (user_id);
+
+-- every ticket type is routed to at most one team
+CREATE TABLE teams_routes (
+    ticket_type TEXT PRIMARY KEY,
+    team_id INT NOT NULL REFERENCES teams (id) ON DELETE CASCADE
+);
+
+ALTER TABLE tickets ADD COLUMN team_id INT REFERENCES teams (id) ON DELETE SET NULL;
+
+CREATE INDEX tickets_team_idx ON tickets (team_id);
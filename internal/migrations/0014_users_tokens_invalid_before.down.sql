@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN tokens_invalid_before;
@@ -0,0 +1,2 @@
+-- the access tokens of a user issued before this time are rejected, e.g. after a password reset
+ALTER TABLE users ADD COLUMN tokens_invalid_before TIMESTAMP;
@@ -0,0 +1,7 @@
+DROP INDEX webhooks_deliveries_event_idx;
+DROP INDEX webhooks_deliveries_pending_idx;
+
+-- the deliveries that were never attempted have no place in the previous log
+DELETE FROM webhooks_deliveries WHERE next_attempt_at IS NOT NULL AND status_code IS NULL AND error IS NULL;
+
+ALTER TABLE webhooks_deliveries DROP COLUMN next_attempt_at;
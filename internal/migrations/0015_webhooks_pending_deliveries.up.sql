@@ -0,0 +1,9 @@
+-- deliveries waiting to be attempted have a next_attempt_at, so they survive restarts. Only the first
+-- attempt of an event is queued per webhook, the rest follow from it
+ALTER TABLE webhooks_deliveries ADD COLUMN next_attempt_at TIMESTAMP;
+
+CREATE INDEX webhooks_deliveries_pending_idx ON webhooks_deliveries (next_attempt_at)
+    WHERE next_attempt_at IS NOT NULL;
+
+CREATE UNIQUE INDEX webhooks_deliveries_event_idx ON webhooks_deliveries (webhook_id, event_id)
+    WHERE attempt = 1 AND replay_of IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE tickets_outbox DROP COLUMN delivered_to;
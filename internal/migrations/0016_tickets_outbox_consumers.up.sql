@@ -0,0 +1,2 @@
+-- the consumers that already took the event, so a retry only goes to the ones that failed it
+ALTER TABLE tickets_outbox ADD COLUMN delivered_to TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,17 @@
+-- the emails to send, one per event and recipient. next_attempt_at is empty once the email was
+-- sent or given up on
+CREATE TABLE notifications_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (event_id, user_id)
+);
+
+CREATE INDEX notifications_jobs_pending_idx ON notifications_jobs (next_attempt_at)
+    WHERE next_attempt_at IS NOT NULL;
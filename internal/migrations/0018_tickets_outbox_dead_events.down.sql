@@ -0,0 +1,4 @@
+-- the events given up on are left out of the previous outbox as if they were dispatched
+UPDATE tickets_outbox SET dispatched_at = NOW(), next_attempt_at = created_at WHERE next_attempt_at IS NULL;
+
+ALTER TABLE tickets_outbox ALTER COLUMN next_attempt_at SET NOT NULL;
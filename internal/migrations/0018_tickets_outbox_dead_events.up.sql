@@ -0,0 +1,3 @@
+-- events whose payload cannot be read are given up on and kept with an empty next_attempt_at and
+-- the reason in last_error
+ALTER TABLE tickets_outbox ALTER COLUMN next_attempt_at DROP NOT NULL;
@@ -0,0 +1,18 @@
+// Package migrations holds the versioned schema of the database. Every change to it is a new pair of
+// NNNN_name.up.sql and NNNN_name.down.sql files, the ones already released are never edited
+package migrations
+
+import (
+	"embed"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/pkg/migrate"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// New returns a migrator of the schema of the application
+func New(pool *pgxpool.Pool) (*migrate.Migrator, error) {
+	return migrate.New(pool, files)
+}
@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/pkg/migrate"
+)
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	c := require.New(t)
+
+	loaded, err := migrate.Load(files)
+	c.Nil(err)
+	c.NotEmpty(loaded)
+
+	for i, migration := range loaded {
+		c.Equal(int64(i+1), migration.Version, migration.Name)
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TicketActivityType what an activity entry is about
+type TicketActivityType string
+
+const (
+	// TicketActivityChange a field of the ticket changed
+	TicketActivityChange TicketActivityType = "change"
+	// TicketActivityComment somebody commented on the ticket
+	TicketActivityComment TicketActivityType = "comment"
+)
+
+// IsValid returns whether the activity type is one of the known ones
+func (t TicketActivityType) IsValid() bool {
+	return t == TicketActivityChange || t == TicketActivityComment
+}
+
+// TicketActivity either a change or a comment of a ticket, by who and when it was made
+type TicketActivity struct {
+	Type     TicketActivityType `json:"type"`
+	TicketID int64              `json:"ticketID"`
+	ActorID  int64              `json:"actorID"`
+	At       time.Time          `json:"at"`
+	Change   *TicketChange      `json:"change,omitempty"`
+	Comment  *TicketComment     `json:"comment,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TicketAttachment represents a file uploaded to a ticket. The content lives in the storage
+// under StorageKey
+type TicketAttachment struct {
+	AttachmentID int64      `json:"attachmentID" db:"id"`
+	TicketID     int64      `json:"ticketID" db:"ticket_id"`
+	UploaderID   int64      `json:"uploaderID" db:"uploader_id"`
+	FileName     string     `json:"fileName" db:"file_name"`
+	ContentType  string     `json:"contentType" db:"content_type"`
+	Size         int64      `json:"size" db:"size_bytes"`
+	StorageKey   string     `json:"-" db:"storage_key"`
+	CreatedAt    *time.Time `json:"createdAt" db:"created_at"`
+	// URL is where the attachment can be downloaded from
+	URL string `json:"url" db:"-"`
+}
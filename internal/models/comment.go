@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TicketComment represents a comment made on a ticket
+type TicketComment struct {
+	CommentID int64      `json:"commentID" db:"id"`
+	TicketID  int64      `json:"ticketID" db:"ticket_id"`
+	AuthorID  int64      `json:"authorID" db:"author_id"`
+	Body      string     `json:"body" db:"body"`
+	Internal  bool       `json:"internal" db:"internal"`
+	CreatedAt *time.Time `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// TicketEventType defines what happened to a ticket
+type TicketEventType string
+
+const (
+	// TicketEventCreated a ticket was created
+	TicketEventCreated TicketEventType = "ticket.created"
+	// TicketEventUpdated any field of a ticket changed
+	TicketEventUpdated TicketEventType = "ticket.updated"
+	// TicketEventAssigned a ticket got a new owner
+	TicketEventAssigned TicketEventType = "ticket.assigned"
+	// TicketEventStatusChanged a ticket moved to another status
+	TicketEventStatusChanged TicketEventType = "ticket.status_changed"
+	// TicketEventCommented somebody commented on a ticket
+	TicketEventCommented TicketEventType = "ticket.commented"
+)
+
+// TicketEventTypes holds every known ticket event type
+var TicketEventTypes = []TicketEventType{
+	TicketEventCreated,
+	TicketEventUpdated,
+	TicketEventAssigned,
+	TicketEventStatusChanged,
+	TicketEventCommented,
+}
+
+// IsValid returns whether the event type is one of the known ones
+func (t TicketEventType) IsValid() bool {
+	for _, eventType := range TicketEventTypes {
+		if eventType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TicketEvent represents something that happened to a ticket. Ticket is its state right after the event.
+// EventID is given once the event is in the outbox and stays the same when it is delivered again
+type TicketEvent struct {
+	EventID        int64           `json:"id,omitempty"`
+	Type           TicketEventType `json:"type"`
+	Ticket         Ticket          `json:"ticket"`
+	ActorID        int64           `json:"actorID"`
+	PreviousStatus TicketStatus    `json:"previousStatus,omitempty"`
+	Comment        *TicketComment  `json:"comment,omitempty"`
+	OccurredAt     time.Time       `json:"occurredAt"`
+}
+
+// NewTicketEvent returns an event of the given type that happened now
+func NewTicketEvent(eventType TicketEventType, ticket Ticket, actorID int64) TicketEvent {
+	return TicketEvent{
+		Type:       eventType,
+		Ticket:     ticket,
+		ActorID:    actorID,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// OutboxEvent is a ticket event waiting in the outbox to be relayed to its consumers. DeliveredTo
+// has the names of the consumers that already took it. Err is why the event could not be read, it
+// cannot be relayed when set
+type OutboxEvent struct {
+	Event       TicketEvent
+	Attempts    int
+	DeliveredTo []string
+	Err         error
+}
+
+// NotificationJob is the email about an event still to be sent to a user
+type NotificationJob struct {
+	JobID    int64
+	UserID   int64
+	Event    TicketEvent
+	Attempts int
+}
@@ -0,0 +1,110 @@
+package models
+
+// Role the set of permissions a user has
+type Role string
+
+const (
+	// RoleRequester opens tickets and follows up on their own ones
+	RoleRequester Role = "requester"
+	// RoleAgent works on the tickets
+	RoleAgent Role = "agent"
+	// RoleSupervisor works on the tickets and distributes them among the agents
+	RoleSupervisor Role = "supervisor"
+	// RoleAdmin can do everything, including managing users and integrations
+	RoleAdmin Role = "admin"
+)
+
+var validRoles = map[Role]bool{
+	RoleRequester:  true,
+	RoleAgent:      true,
+	RoleSupervisor: true,
+	RoleAdmin:      true,
+}
+
+// IsValid returns whether the role exists
+func (r Role) IsValid() bool {
+	return validRoles[r]
+}
+
+// Permission something a role allows to do
+type Permission string
+
+const (
+	// PermissionTicketReadAll see every ticket, not only the own ones
+	PermissionTicketReadAll Permission = "ticket:read_all"
+	// PermissionTicketUpdateAll change every ticket, not only the own ones
+	PermissionTicketUpdateAll Permission = "ticket:update_all"
+	// PermissionTicketWork own tickets and move them through the workflow
+	PermissionTicketWork Permission = "ticket:work"
+	// PermissionTicketAssign assign and unassign tickets
+	PermissionTicketAssign Permission = "ticket:assign"
+	// PermissionTicketInternal read and write internal comments
+	PermissionTicketInternal Permission = "ticket:internal"
+	// PermissionSLARead see the SLA report
+	PermissionSLARead Permission = "sla:read"
+	// PermissionUserRead see every user
+	PermissionUserRead Permission = "user:read"
+	// PermissionUserManage change, deactivate and set the role of users
+	PermissionUserManage Permission = "user:manage"
+	// PermissionWebhookManage manage the webhooks and their deliveries
+	PermissionWebhookManage Permission = "webhook:manage"
+	// PermissionTeamManage create teams, change their members and the tickets routed to them
+	PermissionTeamManage Permission = "team:manage"
+)
+
+// Permissions a set of permissions
+type Permissions []Permission
+
+// Has returns whether the permission is in the set
+func (p Permissions) Has(permission Permission) bool {
+	for _, granted := range p {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultRolePermissions the permissions the roles are created with by the migrations. The
+// permissions actually granted are the ones stored in the database
+var DefaultRolePermissions = map[Role]Permissions{
+	RoleRequester: {},
+	RoleAgent: {
+		PermissionTicketReadAll,
+		PermissionTicketUpdateAll,
+		PermissionTicketWork,
+		PermissionTicketInternal,
+		PermissionSLARead,
+		PermissionUserRead,
+	},
+	RoleSupervisor: {
+		PermissionTicketReadAll,
+		PermissionTicketUpdateAll,
+		PermissionTicketWork,
+		PermissionTicketAssign,
+		PermissionTicketInternal,
+		PermissionSLARead,
+		PermissionUserRead,
+		PermissionTeamManage,
+	},
+	RoleAdmin: {
+		PermissionTicketReadAll,
+		PermissionTicketUpdateAll,
+		PermissionTicketWork,
+		PermissionTicketAssign,
+		PermissionTicketInternal,
+		PermissionSLARead,
+		PermissionUserRead,
+		PermissionUserManage,
+		PermissionWebhookManage,
+		PermissionTeamManage,
+	},
+}
+
+// RoleDefinition a role and the permissions it grants
+type RoleDefinition struct {
+	Role        Role        `json:"role"`
+	Description string      `json:"description"`
+	Permissions Permissions `json:"permissions"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Team a group of agents working on the tickets of some types
+type Team struct {
+	TeamID int64  `json:"teamID"`
+	Name   string `json:"name"`
+	// TicketTypes the types of the tickets routed to the team when created
+	TicketTypes []TicketType `json:"ticketTypes"`
+	MemberIDs   []int64      `json:"memberIDs"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// HasMember returns whether the user is a member of the team
+func (t Team) HasMember(userID int64) bool {
+	for _, memberID := range t.MemberIDs {
+		if memberID == userID {
+			return true
+		}
+	}
+
+	return false
+}
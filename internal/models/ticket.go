@@ -39,6 +39,21 @@ const (
 	TicketStatusCancelled TicketStatus = "cancelled"
 )
 
+var (
+	ValidTicketStatuses = map[TicketStatus]bool{
+		TicketTypePending:     true,
+		TicketTypeInProgress:  true,
+		TicketStatusResolved:  true,
+		TicketStatusCancelled: true,
+	}
+
+	// FinalTicketStatuses statuses a ticket can not leave once reached
+	FinalTicketStatuses = map[TicketStatus]bool{
+		TicketStatusResolved:  true,
+		TicketStatusCancelled: true,
+	}
+)
+
 type TicketPriority int
 
 const (
@@ -59,20 +74,73 @@ type Ticket struct {
 	Status      TicketStatus   `json:"status" db:"ticket_status"`
 	CreatorID   int64          `json:"creatorID" db:"creator_id"`
 	OwnerID     *int64         `json:"ownerID,omitempty" db:"owner_id"`
+	TeamID      *int64         `json:"teamID,omitempty" db:"team_id"`
 	CreatedAt   *time.Time     `json:"createdAt" db:"created_at"`
 	UpdatedAt   *time.Time     `json:"updatedAt" db:"updated_at"`
 	ResolvedAt  *time.Time     `json:"resolvedAt,omitempty" db:"resolved_at"`
+
+	// FirstResponseDueAt and DueAt are the SLA deadlines to first respond to and to resolve the ticket
+	FirstResponseDueAt *time.Time `json:"firstResponseDueAt,omitempty" db:"first_response_due_at"`
+	FirstRespondedAt   *time.Time `json:"firstRespondedAt,omitempty" db:"first_responded_at"`
+	DueAt              *time.Time `json:"dueAt,omitempty" db:"due_at"`
+	// Breached is set once any of the SLA deadlines is missed
+	Breached bool `json:"breached" db:"breached"`
 }
 
+// TicketField a field of a ticket whose changes are recorded
+type TicketField string
+
+const (
+	TicketFieldTitle       TicketField = "title"
+	TicketFieldDescription TicketField = "description"
+	TicketFieldOwner       TicketField = "owner"
+	TicketFieldTeam        TicketField = "team"
+	TicketFieldPriority    TicketField = "priority"
+	TicketFieldSeverity    TicketField = "severity"
+	TicketFieldStatus      TicketField = "status"
+)
+
+// TicketChange a change of a field of a ticket. From and To are the values before and after the
+// change, nil when the field had no value
 type TicketChange struct {
-	ChangeID  int64        `json:"id" db:"id"`
-	TicketID  int64        `json:"ticketID" db:"ticket_id"`
-	CreatorID int64        `json:"creatorID" db:"creator_id"`
-	ChangedBy int64        `json:"changedBy" db:"changed_by"`
-	To        TicketStatus `json:"toStatus" db:"to_status"`
-	ChangedAt time.Time    `json:"changed_at" db:"changed_at"`
+	ChangeID  int64       `json:"id" db:"id"`
+	TicketID  int64       `json:"ticketID" db:"ticket_id"`
+	CreatorID int64       `json:"creatorID" db:"creator_id"`
+	ChangedBy int64       `json:"changedBy" db:"changed_by"`
+	Field     TicketField `json:"field" db:"field"`
+	From      *string     `json:"from" db:"from_value"`
+	To        *string     `json:"to" db:"to_value"`
+	ChangedAt time.Time   `json:"changedAt" db:"changed_at"`
 }
 
 func IsValidTicketType(ticketType TicketType) bool {
 	return ValidTicketTypes[ticketType]
 }
+
+func IsValidTicketStatus(status TicketStatus) bool {
+	return ValidTicketStatuses[status]
+}
+
+// IsFinal returns whether the status is a final one
+func (s TicketStatus) IsFinal() bool {
+	return FinalTicketStatuses[s]
+}
+
+// IsValid returns whether the severity is one of the known ones
+func (s TicketSeverity) IsValid() bool {
+	return s >= TicketSeverityLow && s <= TicketSeverityVeryHigh
+}
+
+// IsValid returns whether the priority is one of the known ones
+func (p TicketPriority) IsValid() bool {
+	return p >= TicketPriorityLow && p <= TicketPriorityVeryHigh
+}
+
+// TicketSearchResult represents a ticket matching a search, with the matching terms highlighted. The
+// highlights are HTML: the text of the ticket is escaped and the terms are wrapped in <mark> tags
+type TicketSearchResult struct {
+	Ticket               Ticket  `json:"ticket"`
+	Rank                 float32 `json:"rank"`
+	TitleHighlight       string  `json:"titleHighlight"`
+	DescriptionHighlight string  `json:"descriptionHighlight"`
+}
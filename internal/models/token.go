@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RefreshToken represents a stored refresh token. Only the hash of the token is kept
+type RefreshToken struct {
+	TokenID   int64      `json:"tokenID"`
+	UserID    int64      `json:"userID"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
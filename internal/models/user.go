@@ -2,32 +2,32 @@ package models
 
 import "time"
 
-// UserType defines the type of a given user
-type UserType string
-
-const (
-	// UserTypeAdmin the admin will handle the tickets
-	UserTypeAdmin UserType = "admin"
-	// UserTypeUser the one who will create the tickets
-	UserTypeUser UserType = "user"
-)
-
-var validUserTypes = map[UserType]bool{
-	UserTypeAdmin: true,
-	UserTypeUser:  true,
-}
-
 // User represents a user of the application
 type User struct {
 	UserID   int64     `json:"userID"`
 	Name     string    `json:"name"`
 	Email    string    `json:"email"`
 	Password string    `json:"password"`
-	Type     UserType  `json:"userType"`
+	Role     Role      `json:"role"`
+	Active   bool      `json:"active"`
 	CreateAt time.Time `json:"createdAt"`
+	// TokensInvalidBefore the access tokens of the user issued before it are rejected
+	TokensInvalidBefore *time.Time `json:"-"`
+}
+
+// HasValidRole returns whether the user has a valid role or not
+func (u User) HasValidRole() bool {
+	return u.Role.IsValid()
+}
+
+// Requester represents the authenticated user making a request
+type Requester struct {
+	UserID      int64
+	Role        Role
+	Permissions Permissions
 }
 
-// HasValidType returns whether the user has a valid type or not
-func (u User) HasValidType() bool {
-	return validUserTypes[u.Type]
+// Can returns whether the role of the requester grants the permission
+func (r Requester) Can(permission Permission) bool {
+	return r.Permissions.Has(permission)
 }
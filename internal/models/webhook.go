@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook represents an endpoint that is sent the ticket events it subscribed to
+type Webhook struct {
+	WebhookID  int64             `json:"webhookID"`
+	URL        string            `json:"url"`
+	EventTypes []TicketEventType `json:"eventTypes"`
+	// Secret signs the payloads, it is only shown when the webhook is created
+	Secret    string     `json:"secret,omitempty"`
+	Active    bool       `json:"active"`
+	CreatorID int64      `json:"creatorID"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// Subscribes returns whether the webhook wants to receive events of the given type
+func (w Webhook) Subscribes(eventType TicketEventType) bool {
+	for _, subscribed := range w.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery represents an attempt to send an event to a webhook. Attempts still to be made
+// have the time they are due at in NextAttemptAt
+type WebhookDelivery struct {
+	DeliveryID int64           `json:"deliveryID"`
+	WebhookID  int64           `json:"webhookID"`
+	EventID    string          `json:"eventID"`
+	EventType  TicketEventType `json:"eventType"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"`
+	// ReplayOf is the delivery this one replays, if any
+	ReplayOf   *int64     `json:"replayOf,omitempty"`
+	StatusCode *int       `json:"statusCode,omitempty"`
+	Error      *string    `json:"error,omitempty"`
+	Success    bool       `json:"success"`
+	CreatedAt  *time.Time `json:"createdAt"`
+	// NextAttemptAt is when the attempt is due, nil once it was made
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+}
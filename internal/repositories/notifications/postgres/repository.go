@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/internal/models"
+	repository "github.com/syned13/ticket-support-back/internal/repositories/notifications"
+)
+
+var (
+	// ErrMissingPool missing pool
+	ErrMissingPool = errors.New("missing pool")
+)
+
+type postgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// New returns a new postgress repository
+func New(pool *pgxpool.Pool) (repository.Repository, error) {
+	if pool == nil {
+		return nil, ErrMissingPool
+	}
+
+	return postgresRepository{
+		pool: pool,
+	}, nil
+}
+
+// GetOptOuts returns the events the user does not want to be notified about
+func (r postgresRepository) GetOptOuts(ctx context.Context, userID int64) ([]models.TicketEventType, error) {
+	query := `SELECT event_type FROM notifications_opt_outs WHERE user_id = $1 ORDER BY event_type`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	eventTypes := []models.TicketEventType{}
+
+	for rows.Next() {
+		var eventType models.TicketEventType
+
+		if err := rows.Scan(&eventType); err != nil {
+			return nil, err
+		}
+
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return eventTypes, nil
+}
+
+// SetOptOuts replaces the events the user does not want to be notified about
+func (r postgresRepository) SetOptOuts(ctx context.Context, userID int64, eventTypes []models.TicketEventType) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM notifications_opt_outs WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, eventType := range eventTypes {
+		_, err = tx.Exec(ctx, `INSERT INTO notifications_opt_outs (user_id, event_type) VALUES ($1, $2)`, userID, eventType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// QueueNotifications saves a job to email each user about the event, due now. Users that already
+// have one for the event are skipped, so queueing an event again is harmless
+func (r postgresRepository) QueueNotifications(ctx context.Context, event models.TicketEvent, userIDs []int64) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO notifications_jobs (event_id, user_id, event, next_attempt_at, created_at)
+			  SELECT NULLIF($1::BIGINT, 0), user_id, $2, NOW(), NOW() FROM UNNEST($3::BIGINT[]) AS user_id
+			  ON CONFLICT (event_id, user_id) DO NOTHING`
+
+	_, err = r.pool.Exec(ctx, query, event.EventID, payload, userIDs)
+
+	return err
+}
+
+// ClaimDueNotification returns the oldest job due by now and moves it to claimedUntil, so it is
+// skipped meanwhile and taken again if it is never marked
+func (r postgresRepository) ClaimDueNotification(ctx context.Context, now time.Time, claimedUntil time.Time) (models.NotificationJob, error) {
+	query := `UPDATE notifications_jobs SET next_attempt_at = $2
+			  WHERE id = (
+				  SELECT id FROM notifications_jobs
+				  WHERE next_attempt_at <= $1
+				  ORDER BY next_attempt_at, id
+				  LIMIT 1
+				  FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING id, user_id, event, attempts`
+
+	job := models.NotificationJob{}
+	var payload []byte
+
+	err := r.pool.QueryRow(ctx, query, now, claimedUntil).Scan(&job.JobID, &job.UserID, &payload, &job.Attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.NotificationJob{}, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return models.NotificationJob{}, err
+	}
+
+	err = json.Unmarshal(payload, &job.Event)
+	if err != nil {
+		return models.NotificationJob{}, err
+	}
+
+	return job, nil
+}
+
+// MarkNotificationSent records that the email of the job was sent
+func (r postgresRepository) MarkNotificationSent(ctx context.Context, jobID int64) error {
+	query := `UPDATE notifications_jobs SET sent_at = NOW(), next_attempt_at = NULL, attempts = attempts + 1, last_error = NULL
+			  WHERE id = $1`
+
+	return r.markNotification(ctx, query, jobID)
+}
+
+// MarkNotificationFailed records why the job failed and when to try again, nil gives up on it
+func (r postgresRepository) MarkNotificationFailed(ctx context.Context, jobID int64, reason string, retryAt *time.Time) error {
+	query := `UPDATE notifications_jobs SET next_attempt_at = $2, attempts = attempts + 1, last_error = $3
+			  WHERE id = $1`
+
+	return r.markNotification(ctx, query, jobID, retryAt, reason)
+}
+
+func (r postgresRepository) markNotification(ctx context.Context, query string, params ...interface{}) error {
+	result, err := r.pool.Exec(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+var (
+	// ErrNotFound not found
+	ErrNotFound = errors.New("not found")
+)
+
+// Repository defines the data-persistance related methods for notifications
+type Repository interface {
+	GetOptOuts(ctx context.Context, userID int64) ([]models.TicketEventType, error)
+	SetOptOuts(ctx context.Context, userID int64, eventTypes []models.TicketEventType) error
+	// QueueNotifications saves a job to email each user about the event. Users that already have one
+	// for the event are skipped
+	QueueNotifications(ctx context.Context, event models.TicketEvent, userIDs []int64) error
+	// ClaimDueNotification returns the oldest job due by now and moves it to claimedUntil, so nobody
+	// else takes it meanwhile. Returns ErrNotFound when none is due
+	ClaimDueNotification(ctx context.Context, now time.Time, claimedUntil time.Time) (models.NotificationJob, error)
+	MarkNotificationSent(ctx context.Context, jobID int64) error
+	// MarkNotificationFailed records why the job failed and when to try again, it is given up on when
+	// retryAt is nil
+	MarkNotificationFailed(ctx context.Context, jobID int64, reason string, retryAt *time.Time) error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/internal/models"
+	repository "github.com/syned13/ticket-support-back/internal/repositories/roles"
+)
+
+var (
+	// ErrMissingPool missing pool
+	ErrMissingPool = errors.New("missing pool")
+)
+
+type postgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// New returns a new postgress repository
+func New(pool *pgxpool.Pool) (repository.Repository, error) {
+	if pool == nil {
+		return nil, ErrMissingPool
+	}
+
+	return postgresRepository{
+		pool: pool,
+	}, nil
+}
+
+// ListRoles returns every role with the permissions it grants, sorted by name
+func (r postgresRepository) ListRoles(ctx context.Context) ([]models.RoleDefinition, error) {
+	query := `SELECT roles.name, roles.description, roles_permissions.permission
+			FROM roles
+			LEFT JOIN roles_permissions ON roles_permissions.role = roles.name
+			ORDER BY roles.name, roles_permissions.permission`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	roles := []models.RoleDefinition{}
+
+	for rows.Next() {
+		var role models.Role
+		var description string
+		var permission *models.Permission
+
+		if err := rows.Scan(&role, &description, &permission); err != nil {
+			return nil, err
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].Role != role {
+			roles = append(roles, models.RoleDefinition{Role: role, Description: description, Permissions: models.Permissions{}})
+		}
+
+		if permission != nil {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, *permission)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
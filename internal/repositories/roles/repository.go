@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// Repository defines the data-persistance related methods for roles and their permissions
+type Repository interface {
+	ListRoles(ctx context.Context) ([]models.RoleDefinition, error)
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/internal/models"
+	repository "github.com/syned13/ticket-support-back/internal/repositories/teams"
+)
+
+var (
+	// ErrMissingPool missing pool
+	ErrMissingPool = errors.New("missing pool")
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// teamQuery selects the teams along with their members and the ticket types routed to them
+const teamQuery = `SELECT t.id, t.name, t.created_at,
+			COALESCE((SELECT ARRAY_AGG(user_id ORDER BY user_id) FROM teams_members WHERE team_id = t.id), '{}'),
+			COALESCE((SELECT ARRAY_AGG(ticket_type ORDER BY ticket_type) FROM teams_routes WHERE team_id = t.id), '{}')
+		FROM teams t`
+
+type postgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// New returns a new postgress repository
+func New(pool *pgxpool.Pool) (repository.Repository, error) {
+	if pool == nil {
+		return nil, ErrMissingPool
+	}
+
+	return postgresRepository{
+		pool: pool,
+	}, nil
+}
+
+// CreateTeam saves a team and routes its ticket types to it
+func (r postgresRepository) CreateTeam(ctx context.Context, team models.Team) (models.Team, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO teams (name, created_at) VALUES ($1, NOW()) RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, query, team.Name).Scan(&team.TeamID, &team.CreatedAt)
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == uniqueViolation {
+		return models.Team{}, repository.ErrDuplicateName
+	}
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	err = setTicketTypes(ctx, tx, team.TeamID, team.TicketTypes)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	team.MemberIDs = []int64{}
+
+	return team, nil
+}
+
+// GetTeam returns a team with its members and ticket types
+func (r postgresRepository) GetTeam(ctx context.Context, teamID int64) (models.Team, error) {
+	return scanTeam(r.pool.QueryRow(ctx, teamQuery+` WHERE t.id = $1`, teamID))
+}
+
+// ListTeams returns every team, sorted by name
+func (r postgresRepository) ListTeams(ctx context.Context) ([]models.Team, error) {
+	rows, err := r.pool.Query(ctx, teamQuery+` ORDER BY t.name`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	teams := []models.Team{}
+
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		teams = append(teams, team)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// DeleteTeam deletes a team. Its tickets are left without team
+func (r postgresRepository) DeleteTeam(ctx context.Context, teamID int64) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM teams WHERE id = $1`, teamID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// AddMember adds the user to the team, doing nothing when it already is a member
+func (r postgresRepository) AddMember(ctx context.Context, teamID int64, userID int64) error {
+	query := `INSERT INTO teams_members (team_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	_, err := r.pool.Exec(ctx, query, teamID, userID)
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == foreignKeyViolation {
+		return repository.ErrNotFound
+	}
+
+	return err
+}
+
+// RemoveMember removes the user from the team. Returns ErrNotFound when it was not a member
+func (r postgresRepository) RemoveMember(ctx context.Context, teamID int64, userID int64) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM teams_members WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetTicketTypes replaces the ticket types routed to the team
+func (r postgresRepository) SetTicketTypes(ctx context.Context, teamID int64, ticketTypes []models.TicketType) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM teams_routes WHERE team_id = $1`, teamID)
+	if err != nil {
+		return err
+	}
+
+	err = setTicketTypes(ctx, tx, teamID, ticketTypes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetTeamForTicketType returns the id of the team the ticket type is routed to, nil when it is not
+func (r postgresRepository) GetTeamForTicketType(ctx context.Context, ticketType models.TicketType) (*int64, error) {
+	var teamID int64
+
+	err := r.pool.QueryRow(ctx, `SELECT team_id FROM teams_routes WHERE ticket_type = $1`, ticketType).Scan(&teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &teamID, nil
+}
+
+// ListUserTeamIDs returns the ids of the teams the user is a member of
+func (r postgresRepository) ListUserTeamIDs(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `SELECT team_id FROM teams_members WHERE user_id = $1 ORDER BY team_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	teamIDs := []int64{}
+
+	for rows.Next() {
+		var teamID int64
+
+		if err := rows.Scan(&teamID); err != nil {
+			return nil, err
+		}
+
+		teamIDs = append(teamIDs, teamID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return teamIDs, nil
+}
+
+// setTicketTypes routes the ticket types to the team, taking them from the teams they were routed to
+func setTicketTypes(ctx context.Context, tx pgx.Tx, teamID int64, ticketTypes []models.TicketType) error {
+	query := `INSERT INTO teams_routes (ticket_type, team_id) VALUES ($1, $2)
+			ON CONFLICT (ticket_type) DO UPDATE SET team_id = EXCLUDED.team_id`
+
+	for _, ticketType := range ticketTypes {
+		_, err := tx.Exec(ctx, query, ticketType, teamID)
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == foreignKeyViolation {
+			return repository.ErrNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanTeam(row pgx.Row) (models.Team, error) {
+	team := models.Team{}
+	var ticketTypes []string
+
+	err := row.Scan(&team.TeamID, &team.Name, &team.CreatedAt, &team.MemberIDs, &ticketTypes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Team{}, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	team.TicketTypes = make([]models.TicketType, len(ticketTypes))
+	for i, ticketType := range ticketTypes {
+		team.TicketTypes[i] = models.TicketType(ticketType)
+	}
+
+	return team, nil
+}
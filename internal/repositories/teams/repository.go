@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+var (
+	// ErrNotFound not found
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicateName duplicate name
+	ErrDuplicateName = errors.New("duplicate name")
+)
+
+// Repository defines the data-persistance related methods for teams
+type Repository interface {
+	CreateTeam(ctx context.Context, team models.Team) (models.Team, error)
+	GetTeam(ctx context.Context, teamID int64) (models.Team, error)
+	ListTeams(ctx context.Context) ([]models.Team, error)
+	DeleteTeam(ctx context.Context, teamID int64) error
+	AddMember(ctx context.Context, teamID int64, userID int64) error
+	RemoveMember(ctx context.Context, teamID int64, userID int64) error
+	// SetTicketTypes routes the ticket types to the team, taking them from the teams they were routed to
+	SetTicketTypes(ctx context.Context, teamID int64, ticketTypes []models.TicketType) error
+	// GetTeamForTicketType returns the id of the team the ticket type is routed to, nil when it is not
+	GetTeamForTicketType(ctx context.Context, ticketType models.TicketType) (*int64, error)
+	ListUserTeamIDs(ctx context.Context, userID int64) ([]int64, error)
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// TicketSortField a field tickets can be sorted by
+type TicketSortField string
+
+const (
+	SortByID        TicketSortField = "id"
+	SortByStatus    TicketSortField = "status"
+	SortByType      TicketSortField = "type"
+	SortBySeverity  TicketSortField = "severity"
+	SortByPriority  TicketSortField = "priority"
+	SortByOwner     TicketSortField = "ownerID"
+	SortByCreator   TicketSortField = "creatorID"
+	SortByCreatedAt TicketSortField = "createdAt"
+	SortByUpdatedAt TicketSortField = "updatedAt"
+)
+
+// Cursor points to the last ticket of a page: its value for the sort field and its id, which breaks ties.
+// It records the sort of the page, as it only points to the same place in that order
+type Cursor struct {
+	Value  string          `json:"v"`
+	ID     int64           `json:"id"`
+	SortBy TicketSortField `json:"s"`
+	Desc   bool            `json:"d,omitempty"`
+}
+
+// TicketFilter defines which tickets to list and in which order. Empty fields do not filter
+type TicketFilter struct {
+	Statuses   []models.TicketStatus
+	Types      []models.TicketType
+	Severities []models.TicketSeverity
+	Priorities []models.TicketPriority
+	OwnerID    *int64
+	CreatorID  *int64
+	// TeamIDs only keeps the tickets routed to any of these teams
+	TeamIDs       []int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	SortBy   TicketSortField
+	SortDesc bool
+	After    *Cursor
+	Limit    int
+}
+
+// ActivityCursor points to the last entry of an activity page. The type and the id break ties
+// between entries made at the same time
+type ActivityCursor struct {
+	At   time.Time                 `json:"at"`
+	Type models.TicketActivityType `json:"t"`
+	ID   int64                     `json:"id"`
+}
+
+// ActivityFilter defines which activity to list, newest first. Empty fields do not filter
+type ActivityFilter struct {
+	TicketID *int64
+	ActorID  *int64
+	// CreatorID only keeps the activity of the tickets made by that person
+	CreatorID *int64
+	Types     []models.TicketActivityType
+	After     *time.Time
+	Before    *time.Time
+	// IncludeInternal whether internal notes are listed
+	IncludeInternal bool
+
+	Cursor *ActivityCursor
+	Limit  int
+}
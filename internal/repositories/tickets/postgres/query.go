@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	repository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+)
+
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+// sortColumn describes how to sort by a field: the SQL expression, its type for casting cursor
+// values, and how to read the value of a ticket for building the next cursor
+type sortColumn struct {
+	expression string
+	sqlType    string
+	value      func(ticket models.Ticket) string
+}
+
+var sortColumns = map[repository.TicketSortField]sortColumn{
+	repository.SortByID: {
+		expression: "id",
+		sqlType:    "INT",
+		value:      func(t models.Ticket) string { return strconv.FormatInt(t.TicketID, 10) },
+	},
+	repository.SortByStatus: {
+		expression: "ticket_status",
+		sqlType:    "TEXT",
+		value:      func(t models.Ticket) string { return string(t.Status) },
+	},
+	repository.SortByType: {
+		expression: "ticket_type",
+		sqlType:    "TEXT",
+		value:      func(t models.Ticket) string { return string(t.Type) },
+	},
+	repository.SortBySeverity: {
+		expression: "severity",
+		sqlType:    "INT",
+		value:      func(t models.Ticket) string { return strconv.Itoa(int(t.Severity)) },
+	},
+	repository.SortByPriority: {
+		expression: "ticket_priority",
+		sqlType:    "INT",
+		value:      func(t models.Ticket) string { return strconv.Itoa(int(t.Priority)) },
+	},
+	repository.SortByOwner: {
+		// unassigned tickets sort first
+		expression: "COALESCE(owner_id, 0)",
+		sqlType:    "INT",
+		value: func(t models.Ticket) string {
+			if t.OwnerID == nil {
+				return "0"
+			}
+
+			return strconv.FormatInt(*t.OwnerID, 10)
+		},
+	},
+	repository.SortByCreator: {
+		expression: "creator_id",
+		sqlType:    "INT",
+		value:      func(t models.Ticket) string { return strconv.FormatInt(t.CreatorID, 10) },
+	},
+	repository.SortByCreatedAt: {
+		expression: "created_at",
+		sqlType:    "TIMESTAMP",
+		value:      func(t models.Ticket) string { return formatTimestamp(t.CreatedAt) },
+	},
+	repository.SortByUpdatedAt: {
+		expression: "updated_at",
+		sqlType:    "TIMESTAMP",
+		value:      func(t models.Ticket) string { return formatTimestamp(t.UpdatedAt) },
+	},
+}
+
+// queryBuilder builds the WHERE clause of a query, numbering its parameters
+type queryBuilder struct {
+	conditions []string
+	params     []interface{}
+}
+
+// param adds a parameter and returns its placeholder
+func (b *queryBuilder) param(value interface{}) string {
+	b.params = append(b.params, value)
+	return fmt.Sprintf("$%d", len(b.params))
+}
+
+// where adds a condition. Each %s of the format is replaced with the placeholder of the matching value
+func (b *queryBuilder) where(format string, values ...interface{}) {
+	placeholders := make([]interface{}, len(values))
+	for i, value := range values {
+		placeholders[i] = b.param(value)
+	}
+
+	b.conditions = append(b.conditions, fmt.Sprintf(format, placeholders...))
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// buildListTicketsQuery returns the query listing the tickets matching the filter. One more ticket than
+// the limit is requested to know whether there is a next page
+func buildListTicketsQuery(filter repository.TicketFilter) (string, []interface{}, sortColumn, error) {
+	column, ok := sortColumns[filter.SortBy]
+	if !ok {
+		return "", nil, sortColumn{}, repository.ErrInvalidSortField
+	}
+
+	b := &queryBuilder{}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+
+		b.where("ticket_status = ANY(%s)", statuses)
+	}
+
+	if len(filter.Types) > 0 {
+		types := make([]string, len(filter.Types))
+		for i, ticketType := range filter.Types {
+			types[i] = string(ticketType)
+		}
+
+		b.where("ticket_type = ANY(%s)", types)
+	}
+
+	if len(filter.Severities) > 0 {
+		severities := make([]int32, len(filter.Severities))
+		for i, severity := range filter.Severities {
+			severities[i] = int32(severity)
+		}
+
+		b.where("severity = ANY(%s)", severities)
+	}
+
+	if len(filter.Priorities) > 0 {
+		priorities := make([]int32, len(filter.Priorities))
+		for i, priority := range filter.Priorities {
+			priorities[i] = int32(priority)
+		}
+
+		b.where("ticket_priority = ANY(%s)", priorities)
+	}
+
+	if filter.OwnerID != nil {
+		b.where("owner_id = %s", *filter.OwnerID)
+	}
+
+	if filter.CreatorID != nil {
+		b.where("creator_id = %s", *filter.CreatorID)
+	}
+
+	if len(filter.TeamIDs) > 0 {
+		b.where("team_id = ANY(%s)", filter.TeamIDs)
+	}
+
+	if filter.CreatedAfter != nil {
+		b.where("created_at >= %s", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		b.where("created_at < %s", *filter.CreatedBefore)
+	}
+
+	if filter.UpdatedAfter != nil {
+		b.where("updated_at >= %s", *filter.UpdatedAfter)
+	}
+
+	if filter.UpdatedBefore != nil {
+		b.where("updated_at < %s", *filter.UpdatedBefore)
+	}
+
+	direction := "ASC"
+	comparison := ">"
+
+	if filter.SortDesc {
+		direction = "DESC"
+		comparison = "<"
+	}
+
+	if filter.After != nil {
+		if !isValidCursor(*filter.After, filter, column) {
+			return "", nil, sortColumn{}, repository.ErrInvalidCursor
+		}
+
+		// comparing (value, id) tuples keeps the pagination stable when many tickets share a value
+		b.where(fmt.Sprintf("(%s, id) %s (%%s::TEXT::%s, %%s)", column.expression, comparison, column.sqlType), filter.After.Value, filter.After.ID)
+	}
+
+	query := fmt.Sprintf("SELECT * FROM tickets%s ORDER BY %s %s, id %s LIMIT %s",
+		b.whereClause(),
+		column.expression, direction,
+		direction,
+		b.param(filter.Limit+1),
+	)
+
+	return query, b.params, column, nil
+}
+
+// activityQuery merges the changes and the comments of the tickets into a single list of entries
+const activityQuery = `SELECT * FROM (
+		SELECT 'change' AS activity_type, id, ticket_id, creator_id, changed_by AS actor_id, changed_at AS at,
+			field, from_value, to_value, NULL AS body, FALSE AS internal
+		FROM tickets_changes
+		UNION ALL
+		SELECT 'comment', c.id, c.ticket_id, t.creator_id, c.author_id, c.created_at,
+			NULL, NULL, NULL, c.body, c.internal
+		FROM tickets_comments c JOIN tickets t ON t.id = c.ticket_id
+	) activity`
+
+// isValidCursor returns whether the cursor was made for the sort of the filter and its value can be
+// cast to the type of the column, so a cursor from another sort fails before reaching the database
+func isValidCursor(cursor repository.Cursor, filter repository.TicketFilter, column sortColumn) bool {
+	if cursor.SortBy != filter.SortBy || cursor.Desc != filter.SortDesc {
+		return false
+	}
+
+	var err error
+
+	switch column.sqlType {
+	case "INT":
+		_, err = strconv.ParseInt(cursor.Value, 10, 64)
+	case "TIMESTAMP":
+		_, err = time.Parse(timestampLayout, cursor.Value)
+	}
+
+	return err == nil
+}
+
+// buildListActivityQuery returns the query listing the activity matching the filter, newest first. One
+// more entry than the limit is requested to know whether there is a next page
+func buildListActivityQuery(filter repository.ActivityFilter) (string, []interface{}) {
+	b := &queryBuilder{}
+
+	if filter.TicketID != nil {
+		b.where("ticket_id = %s", *filter.TicketID)
+	}
+
+	if filter.ActorID != nil {
+		b.where("actor_id = %s", *filter.ActorID)
+	}
+
+	if filter.CreatorID != nil {
+		b.where("creator_id = %s", *filter.CreatorID)
+	}
+
+	if len(filter.Types) > 0 {
+		types := make([]string, len(filter.Types))
+		for i, activityType := range filter.Types {
+			types[i] = string(activityType)
+		}
+
+		b.where("activity_type = ANY(%s)", types)
+	}
+
+	if filter.After != nil {
+		b.where("at >= %s", *filter.After)
+	}
+
+	if filter.Before != nil {
+		b.where("at < %s", *filter.Before)
+	}
+
+	if !filter.IncludeInternal {
+		b.where("NOT internal")
+	}
+
+	if filter.Cursor != nil {
+		b.where("(at, activity_type, id) < (%s, %s, %s)", filter.Cursor.At, string(filter.Cursor.Type), filter.Cursor.ID)
+	}
+
+	query := fmt.Sprintf("%s%s ORDER BY at DESC, activity_type DESC, id DESC LIMIT %s",
+		activityQuery,
+		b.whereClause(),
+		b.param(filter.Limit+1),
+	)
+
+	return query, b.params
+}
+
+func formatTimestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(timestampLayout)
+}
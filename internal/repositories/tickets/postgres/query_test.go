@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	repository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+)
+
+func TestBuildListTicketsQueryDefault(t *testing.T) {
+	c := require.New(t)
+
+	query, params, _, err := buildListTicketsQuery(repository.TicketFilter{SortBy: repository.SortByID, Limit: 10})
+	c.Nil(err)
+	c.Equal("SELECT * FROM tickets ORDER BY id ASC, id ASC LIMIT $1", query)
+	c.Equal([]interface{}{11}, params)
+}
+
+func TestBuildListTicketsQueryFilters(t *testing.T) {
+	c := require.New(t)
+
+	creatorID := int64(4)
+	createdAfter := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	query, params, _, err := buildListTicketsQuery(repository.TicketFilter{
+		Statuses:     []models.TicketStatus{models.TicketTypePending, models.TicketTypeInProgress},
+		Severities:   []models.TicketSeverity{models.TicketSeverityHigh},
+		CreatorID:    &creatorID,
+		CreatedAfter: &createdAfter,
+		SortBy:       repository.SortByCreatedAt,
+		SortDesc:     true,
+		After:        &repository.Cursor{Value: "2021-04-02 10:00:00", ID: 20, SortBy: repository.SortByCreatedAt, Desc: true},
+		Limit:        5,
+	})
+	c.Nil(err)
+	c.Equal("SELECT * FROM tickets WHERE ticket_status = ANY($1) AND severity = ANY($2) AND creator_id = $3 AND created_at >= $4 "+
+		"AND (created_at, id) < ($5::TEXT::TIMESTAMP, $6) ORDER BY created_at DESC, id DESC LIMIT $7", query)
+	c.Equal([]interface{}{
+		[]string{"pending", "in_progress"},
+		[]int32{3},
+		int64(4),
+		createdAfter,
+		"2021-04-02 10:00:00",
+		int64(20),
+		6,
+	}, params)
+}
+
+func TestBuildListTicketsQueryInvalidSort(t *testing.T) {
+	c := require.New(t)
+
+	_, _, _, err := buildListTicketsQuery(repository.TicketFilter{SortBy: "password", Limit: 10})
+	c.Equal(repository.ErrInvalidSortField, err)
+}
+
+func TestBuildListTicketsQueryInvalidCursor(t *testing.T) {
+	c := require.New(t)
+
+	_, _, _, err := buildListTicketsQuery(repository.TicketFilter{
+		SortBy: repository.SortBySeverity,
+		After:  &repository.Cursor{Value: "2021-04-02 10:00:00", ID: 20, SortBy: repository.SortBySeverity},
+		Limit:  10,
+	})
+	c.Equal(repository.ErrInvalidCursor, err)
+
+	_, _, _, err = buildListTicketsQuery(repository.TicketFilter{
+		SortBy: repository.SortBySeverity,
+		After:  &repository.Cursor{Value: "3", ID: 20, SortBy: repository.SortByCreatedAt},
+		Limit:  10,
+	})
+	c.Equal(repository.ErrInvalidCursor, err)
+}
+
+func TestSortColumnValue(t *testing.T) {
+	c := require.New(t)
+
+	createdAt := time.Date(2021, 4, 2, 10, 0, 0, 500000000, time.UTC)
+	ticket := models.Ticket{TicketID: 3, CreatedAt: &createdAt}
+
+	c.Equal("2021-04-02 10:00:00.5", sortColumns[repository.SortByCreatedAt].value(ticket))
+	c.Equal("0", sortColumns[repository.SortByOwner].value(ticket))
+	c.Equal("3", sortColumns[repository.SortByID].value(ticket))
+}
+
+func TestBuildListActivityQuery(t *testing.T) {
+	c := require.New(t)
+
+	ticketID := int64(10)
+	at := time.Date(2021, 4, 2, 10, 0, 0, 0, time.UTC)
+
+	query, params := buildListActivityQuery(repository.ActivityFilter{
+		TicketID: &ticketID,
+		Types:    []models.TicketActivityType{models.TicketActivityComment},
+		Cursor:   &repository.ActivityCursor{At: at, Type: models.TicketActivityComment, ID: 3},
+		Limit:    20,
+	})
+	c.Equal(activityQuery+" WHERE ticket_id = $1 AND activity_type = ANY($2) AND NOT internal "+
+		"AND (at, activity_type, id) < ($3, $4, $5) ORDER BY at DESC, activity_type DESC, id DESC LIMIT $6", query)
+	c.Equal([]interface{}{int64(10), []string{"comment"}, at, "comment", int64(3), 21}, params)
+
+	query, _ = buildListActivityQuery(repository.ActivityFilter{IncludeInternal: true, Limit: 20})
+	c.Equal(activityQuery+" ORDER BY at DESC, activity_type DESC, id DESC LIMIT $1", query)
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
@@ -25,8 +27,16 @@ var (
 	errorCodes = map[string]error{}
 )
 
+// querier runs the queries of the repository, either on the pool or on a transaction
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 type postgresRepository struct {
-	pool *pgxpool.Pool
+	db querier
 }
 
 // New returns a new postgress repository
@@ -36,28 +46,50 @@ func New(pool *pgxpool.Pool) (repository.Repository, error) {
 	}
 
 	return postgresRepository{
-		pool: pool,
+		db: pool,
 	}, nil
 }
 
+// WithTx runs fn inside a transaction. When the repository is already in one a savepoint is used
+func (r postgresRepository) WithTx(ctx context.Context, fn func(repo repository.Repository) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	err = fn(postgresRepository{db: tx})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 // SaveTicket saves a ticket in the database
 func (r postgresRepository) SaveTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
 	query := `INSERT INTO tickets 
-				(title, ticket_description, ticket_type, severity, ticket_priority, ticket_status, creator_id, created_at, updated_at)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+				(title, ticket_description, ticket_type, severity, ticket_priority, ticket_status, creator_id, owner_id,
+				first_response_due_at, due_at, team_id, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
 				RETURNING id, created_at, updated_at`
 
 	var ticketID sql.NullInt64
 	var createdAt, updatedAt sql.NullTime
 
-	err := r.pool.QueryRow(ctx, query,
+	err := r.db.QueryRow(ctx, query,
 		ticket.Title,
 		ticket.Description,
 		ticket.Type,
 		ticket.Severity,
 		ticket.Priority,
 		ticket.Status,
-		ticket.CreatorID).Scan(&ticketID, &createdAt, &updatedAt)
+		ticket.CreatorID,
+		ticket.OwnerID,
+		ticket.FirstResponseDueAt,
+		ticket.DueAt,
+		ticket.TeamID).Scan(&ticketID, &createdAt, &updatedAt)
 
 	if pgErr, ok := err.(*pgconn.PgError); ok {
 		if _, ok := errorCodes[pgErr.Code]; ok {
@@ -87,11 +119,19 @@ func (r postgresRepository) SaveTicket(ctx context.Context, ticket models.Ticket
 
 // GetTicket returns a ticket from the database based on the tickeID
 func (r postgresRepository) GetTicket(ctx context.Context, ticketID int64) (models.Ticket, error) {
-	query := `SELECT * FROM tickets WHERE id = $1`
+	return r.getTicket(ctx, `SELECT * FROM tickets WHERE id = $1`, ticketID)
+}
+
+// GetTicketForUpdate returns the ticket and locks it until the transaction ends, so writes made from
+// what it returns do not overwrite the ones made meanwhile. It is meant to run in WithTx
+func (r postgresRepository) GetTicketForUpdate(ctx context.Context, ticketID int64) (models.Ticket, error) {
+	return r.getTicket(ctx, `SELECT * FROM tickets WHERE id = $1 FOR UPDATE`, ticketID)
+}
 
+func (r postgresRepository) getTicket(ctx context.Context, query string, ticketID int64) (models.Ticket, error) {
 	ticket := models.Ticket{}
 
-	err := r.pool.QueryRow(ctx, query, ticketID).Scan(
+	err := r.db.QueryRow(ctx, query, ticketID).Scan(
 		&ticket.TicketID,
 		&ticket.Title,
 		&ticket.Description,
@@ -104,6 +144,11 @@ func (r postgresRepository) GetTicket(ctx context.Context, ticketID int64) (mode
 		&ticket.CreatedAt,
 		&ticket.UpdatedAt,
 		&ticket.ResolvedAt,
+		&ticket.FirstResponseDueAt,
+		&ticket.FirstRespondedAt,
+		&ticket.DueAt,
+		&ticket.Breached,
+		&ticket.TeamID,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -117,97 +162,283 @@ func (r postgresRepository) GetTicket(ctx context.Context, ticketID int64) (mode
 	return ticket, nil
 }
 
-// GetTickets returns all the tickets
-func (r postgresRepository) GetTickets(ctx context.Context, lastID int64) ([]models.Ticket, int64, error) {
-	query := `SELECT * FROM tickets WHERE id > $1 ORDER BY id LIMIT 1000`
+// ListTickets returns a page of the tickets matching the filter and the cursor of the next page, which
+// is nil when there are no more tickets
+func (r postgresRepository) ListTickets(ctx context.Context, filter repository.TicketFilter) ([]models.Ticket, *repository.Cursor, error) {
+	query, params, column, err := buildListTicketsQuery(filter)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	rows, err := r.pool.Query(ctx, query, lastID)
+	rows, err := r.db.Query(ctx, query, params...)
 	if err != nil {
-		fmt.Println(err)
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	tickets := []models.Ticket{}
 
 	if err := pgxscan.NewScanner(rows).Scan(&tickets); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, 0, repository.ErrNotFound
+			return []models.Ticket{}, nil, nil
 		}
 
-		return nil, 0, err
+		return nil, nil, err
+	}
+
+	if len(tickets) <= filter.Limit {
+		return tickets, nil, nil
 	}
 
-	return tickets, tickets[len(tickets)-1].TicketID, nil
+	tickets = tickets[:filter.Limit]
+	last := tickets[len(tickets)-1]
+
+	return tickets, &repository.Cursor{Value: column.value(last), ID: last.TicketID, SortBy: filter.SortBy, Desc: filter.SortDesc}, nil
 }
 
-// GetTicketsByCreator returns all the tickets made by a single person
-func (r postgresRepository) GetTicketsByCreator(ctx context.Context, creatorID int64, lastID int64) ([]models.Ticket, int64, error) {
-	query := `SELECT * FROM tickets WHERE creator_id = $1 AND id > $2 ORDER BY id LIMIT 1000`
+// escapeHTML returns the SQL expression escaping the text of the column for HTML, so the <mark> tags
+// added by ts_headline are the only markup of the highlights
+func escapeHTML(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`, column)
+}
 
-	rows, err := r.pool.Query(ctx, query, creatorID, lastID)
+// SearchTickets returns the tickets whose title or description match the query, best matches first.
+// When creatorID is given only the tickets made by that person are searched
+func (r postgresRepository) SearchTickets(ctx context.Context, query string, creatorID *int64, limit int, offset int) ([]models.TicketSearchResult, error) {
+	searchQuery := `SELECT
+						t.id, t.title, t.ticket_description, t.ticket_type, t.severity, t.ticket_priority,
+						t.ticket_status, t.creator_id, t.owner_id, t.created_at, t.updated_at, t.resolved_at,
+						t.first_response_due_at, t.first_responded_at, t.due_at, t.breached, t.team_id,
+						ts_rank(document, query) AS rank,
+						ts_headline('english', ` + escapeHTML("t.title") + `, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=TRUE'),
+						ts_headline('english', ` + escapeHTML("t.ticket_description") + `, query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')
+					FROM tickets t,
+						plainto_tsquery('english', $1) query,
+						to_tsvector('english', t.title || ' ' || t.ticket_description) document
+					WHERE document @@ query AND ($2::INT IS NULL OR t.creator_id = $2)
+					ORDER BY rank DESC, t.id DESC
+					LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.Query(ctx, searchQuery, query, creatorID, limit, offset)
 	if err != nil {
-		fmt.Println(err)
-		return nil, 0, err
+		return nil, err
 	}
 
-	tickets := []models.Ticket{}
-
-	if err := pgxscan.NewScanner(rows).Scan(&tickets); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, 0, repository.ErrNotFound
+	defer rows.Close()
+
+	results := []models.TicketSearchResult{}
+
+	for rows.Next() {
+		result := models.TicketSearchResult{}
+		ticket := &result.Ticket
+
+		err = rows.Scan(
+			&ticket.TicketID,
+			&ticket.Title,
+			&ticket.Description,
+			&ticket.Type,
+			&ticket.Severity,
+			&ticket.Priority,
+			&ticket.Status,
+			&ticket.CreatorID,
+			&ticket.OwnerID,
+			&ticket.CreatedAt,
+			&ticket.UpdatedAt,
+			&ticket.ResolvedAt,
+			&ticket.FirstResponseDueAt,
+			&ticket.FirstRespondedAt,
+			&ticket.DueAt,
+			&ticket.Breached,
+			&ticket.TeamID,
+			&result.Rank,
+			&result.TitleHighlight,
+			&result.DescriptionHighlight,
+		)
+		if err != nil {
+			return nil, err
 		}
 
-		return nil, 0, err
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return tickets, tickets[len(tickets)-1].TicketID, nil
+	return results, nil
 }
 
-// UpdateTicket updates a ticket
+// UpdateTicket updates the mutable fields of a ticket
 func (r postgresRepository) UpdateTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
-	params := []interface{}{}
-	setStatements := []string{}
-	valuesCount := 0
+	query := `UPDATE tickets SET
+				title = $1,
+				ticket_description = $2,
+				ticket_type = $3,
+				severity = $4,
+				ticket_priority = $5,
+				ticket_status = $6,
+				owner_id = $7,
+				resolved_at = $8,
+				first_response_due_at = $9,
+				first_responded_at = $10,
+				due_at = $11,
+				breached = $12,
+				team_id = $13,
+				updated_at = NOW()
+			  WHERE id = $14
+			  RETURNING *`
+
+	params := []interface{}{
+		ticket.Title,
+		ticket.Description,
+		ticket.Type,
+		ticket.Severity,
+		ticket.Priority,
+		ticket.Status,
+		ticket.OwnerID,
+		ticket.ResolvedAt,
+		ticket.FirstResponseDueAt,
+		ticket.FirstRespondedAt,
+		ticket.DueAt,
+		ticket.Breached,
+		ticket.TeamID,
+		ticket.TicketID,
+	}
+
+	updatedTicket := models.Ticket{}
 
-	if ticket.Status != "" {
-		valuesCount++
-		params = append(params, ticket.Status)
-		// setStatements = append(setStatements, fmt.Sprintf("ticket_status = $%d", valuesCount))
-		setStatements = append(setStatements, fmt.Sprintf("ticket_status = '%s'", ticket.Status))
+	rows, err := r.db.Query(ctx, query, params...)
+	if err != nil {
+		return models.Ticket{}, err
 	}
 
-	if ticket.OwnerID != nil {
-		valuesCount++
-		params = append(params, ticket.Status)
-		// setStatements = append(setStatements, fmt.Sprintf("owner_id = $%d", valuesCount))
-		setStatements = append(setStatements, fmt.Sprintf("owner_id = '%d'", *ticket.OwnerID))
+	err = pgxscan.NewScanner(rows).Scan(&updatedTicket)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Ticket{}, repository.ErrNotFound
 	}
 
-	if valuesCount == 0 {
-		return models.Ticket{}, repository.ErrNothingToUpdate
+	if err != nil {
+		return models.Ticket{}, err
 	}
 
-	setStatements = append(setStatements, "updated_at = NOW()")
-	params = append(params, ticket.TicketID)
+	return updatedTicket, nil
+}
 
-	query := fmt.Sprintf("UPDATE tickets SET %s WHERE id = %d RETURNING *", strings.Join(setStatements, ", "), ticket.TicketID)
+// CountOpenTicketsByOwner returns how many pending or in progress tickets each owner has. Owners
+// without open tickets are not included
+func (r postgresRepository) CountOpenTicketsByOwner(ctx context.Context) (map[int64]int, error) {
+	query := `SELECT owner_id, COUNT(*) FROM tickets
+			  WHERE owner_id IS NOT NULL AND ticket_status = ANY($1)
+			  GROUP BY owner_id`
 
-	updatedTicket := models.Ticket{}
+	openStatuses := []string{string(models.TicketTypePending), string(models.TicketTypeInProgress)}
 
-	_, err := r.pool.Exec(ctx, query)
+	rows, err := r.db.Query(ctx, query, openStatuses)
 	if err != nil {
-		return models.Ticket{}, err
+		return nil, err
 	}
 
-	return updatedTicket, nil
+	defer rows.Close()
+
+	counts := map[int64]int{}
+
+	for rows.Next() {
+		var ownerID int64
+		var count int
+
+		err = rows.Scan(&ownerID, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		counts[ownerID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
 }
 
-func (r postgresRepository) SaveTicketChange(ctx context.Context, ticketChange models.TicketChange) error {
-	query := `INSERT INTO tickets_changes 
-			  (ticket_id, creator_id, to_status, changed_at) 
-			  VALUES ($1, $2, $3, NOW())`
+// FlagBreachedTickets marks as breached the open tickets that missed any of their SLA deadlines
+// by now and returns their ids
+func (r postgresRepository) FlagBreachedTickets(ctx context.Context, now time.Time) ([]int64, error) {
+	query := `UPDATE tickets SET breached = TRUE
+			  WHERE NOT breached AND ticket_status = ANY($1) AND (
+				due_at < $2 OR (first_responded_at IS NULL AND first_response_due_at < $2)
+			  )
+			  RETURNING id`
+
+	openStatuses := []string{string(models.TicketTypePending), string(models.TicketTypeInProgress)}
+
+	rows, err := r.db.Query(ctx, query, openStatuses, now)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
 
-	_, err := r.pool.Exec(ctx, query, ticketChange.TicketID, ticketChange.CreatorID, ticketChange.To)
+	ticketIDs := []int64{}
+
+	for rows.Next() {
+		var ticketID int64
+
+		if err := rows.Scan(&ticketID); err != nil {
+			return nil, err
+		}
+
+		ticketIDs = append(ticketIDs, ticketID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ticketIDs, nil
+}
+
+// ListSLATickets returns the open tickets that are breached or have a pending SLA deadline
+// before dueBefore, closest deadline first
+func (r postgresRepository) ListSLATickets(ctx context.Context, dueBefore time.Time) ([]models.Ticket, error) {
+	query := `SELECT * FROM tickets
+			  WHERE ticket_status = ANY($1) AND (
+				breached OR due_at < $2 OR (first_responded_at IS NULL AND first_response_due_at < $2)
+			  )
+			  ORDER BY LEAST(due_at, CASE WHEN first_responded_at IS NULL THEN first_response_due_at END), id`
+
+	openStatuses := []string{string(models.TicketTypePending), string(models.TicketTypeInProgress)}
+
+	rows, err := r.db.Query(ctx, query, openStatuses, dueBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := []models.Ticket{}
+
+	if err := pgxscan.NewScanner(rows).Scan(&tickets); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []models.Ticket{}, nil
+		}
+
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+// SaveTicketChange records the change of a field of a ticket
+func (r postgresRepository) SaveTicketChange(ctx context.Context, ticketChange models.TicketChange) error {
+	query := `INSERT INTO tickets_changes
+			  (ticket_id, creator_id, changed_by, field, from_value, to_value, changed_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, NOW())`
+
+	_, err := r.db.Exec(ctx, query,
+		ticketChange.TicketID,
+		ticketChange.CreatorID,
+		ticketChange.ChangedBy,
+		ticketChange.Field,
+		ticketChange.From,
+		ticketChange.To)
 	if err != nil {
 		return err
 	}
@@ -215,19 +446,42 @@ func (r postgresRepository) SaveTicketChange(ctx context.Context, ticketChange m
 	return nil
 }
 
+// GetTicketHistory returns the changes made to a ticket, oldest first
+func (r postgresRepository) GetTicketHistory(ctx context.Context, ticketID int64) ([]models.TicketChange, error) {
+	query := `SELECT * FROM tickets_changes WHERE ticket_id = $1 ORDER BY id`
+
+	changes := []models.TicketChange{}
+
+	rows, err := r.db.Query(ctx, query, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pgxscan.NewScanner(rows).Scan(&changes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []models.TicketChange{}, nil
+		}
+
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetTicketChanges returns the changes made to the tickets created by creatorID, oldest first
 func (r postgresRepository) GetTicketChanges(ctx context.Context, creatorID int64) ([]models.TicketChange, error) {
-	query := `SELECT * FROM tickets_changes where creator_id = $1`
+	query := `SELECT * FROM tickets_changes WHERE creator_id = $1 ORDER BY id`
 
 	changes := []models.TicketChange{}
 
-	rows, err := r.pool.Query(ctx, query, creatorID)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return changes, nil
+	rows, err := r.db.Query(ctx, query, creatorID)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := pgxscan.NewScanner(rows).Scan(&changes); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, repository.ErrNotFound
+			return []models.TicketChange{}, nil
 		}
 
 		return nil, err
@@ -235,3 +489,342 @@ func (r postgresRepository) GetTicketChanges(ctx context.Context, creatorID int6
 
 	return changes, nil
 }
+
+// ListActivity returns a page of the changes and comments matching the filter, newest first, and the
+// cursor of the next page, which is nil when there is no more activity
+func (r postgresRepository) ListActivity(ctx context.Context, filter repository.ActivityFilter) ([]models.TicketActivity, *repository.ActivityCursor, error) {
+	query, params := buildListActivityQuery(filter)
+
+	rows, err := r.db.Query(ctx, query, params...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	activity := []models.TicketActivity{}
+
+	for rows.Next() {
+		entry := models.TicketActivity{}
+		var entryID, creatorID int64
+		var field, from, to, body *string
+		var internal bool
+
+		err = rows.Scan(&entry.Type, &entryID, &entry.TicketID, &creatorID, &entry.ActorID, &entry.At, &field, &from, &to, &body, &internal)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch entry.Type {
+		case models.TicketActivityChange:
+			entry.Change = &models.TicketChange{
+				ChangeID:  entryID,
+				TicketID:  entry.TicketID,
+				CreatorID: creatorID,
+				ChangedBy: entry.ActorID,
+				From:      from,
+				To:        to,
+				ChangedAt: entry.At,
+			}
+
+			if field != nil {
+				entry.Change.Field = models.TicketField(*field)
+			}
+		case models.TicketActivityComment:
+			at := entry.At
+			entry.Comment = &models.TicketComment{
+				CommentID: entryID,
+				TicketID:  entry.TicketID,
+				AuthorID:  entry.ActorID,
+				Internal:  internal,
+				CreatedAt: &at,
+			}
+
+			if body != nil {
+				entry.Comment.Body = *body
+			}
+		}
+
+		activity = append(activity, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(activity) <= filter.Limit {
+		return activity, nil, nil
+	}
+
+	activity = activity[:filter.Limit]
+	last := activity[len(activity)-1]
+
+	cursor := &repository.ActivityCursor{At: last.At, Type: last.Type}
+	if last.Change != nil {
+		cursor.ID = last.Change.ChangeID
+	} else {
+		cursor.ID = last.Comment.CommentID
+	}
+
+	return activity, cursor, nil
+}
+
+// SaveTicketComment saves a comment made on a ticket
+func (r postgresRepository) SaveTicketComment(ctx context.Context, comment models.TicketComment) (models.TicketComment, error) {
+	query := `INSERT INTO tickets_comments
+			  (ticket_id, author_id, body, internal, created_at)
+			  VALUES ($1, $2, $3, $4, NOW())
+			  RETURNING id, created_at`
+
+	var commentID sql.NullInt64
+	var createdAt sql.NullTime
+
+	err := r.db.QueryRow(ctx, query,
+		comment.TicketID,
+		comment.AuthorID,
+		comment.Body,
+		comment.Internal).Scan(&commentID, &createdAt)
+
+	if pgErr, ok := err.(*pgconn.PgError); ok {
+		if _, ok := errorCodes[pgErr.Code]; ok {
+			return models.TicketComment{}, errorCodes[pgErr.Code]
+		}
+	}
+
+	if err != nil {
+		return models.TicketComment{}, err
+	}
+
+	if commentID.Valid {
+		comment.CommentID = commentID.Int64
+	}
+
+	if createdAt.Valid {
+		comment.CreatedAt = &createdAt.Time
+	}
+
+	return comment, nil
+}
+
+// GetTicketComments returns the comments of a ticket, oldest first. Internal notes are only
+// included when includeInternal is true
+func (r postgresRepository) GetTicketComments(ctx context.Context, ticketID int64, includeInternal bool) ([]models.TicketComment, error) {
+	query := `SELECT * FROM tickets_comments WHERE ticket_id = $1 AND (internal = FALSE OR $2) ORDER BY id`
+
+	comments := []models.TicketComment{}
+
+	rows, err := r.db.Query(ctx, query, ticketID, includeInternal)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pgxscan.NewScanner(rows).Scan(&comments); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []models.TicketComment{}, nil
+		}
+
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// SaveTicketAttachment saves the metadata of a file uploaded to a ticket
+func (r postgresRepository) SaveTicketAttachment(ctx context.Context, attachment models.TicketAttachment) (models.TicketAttachment, error) {
+	query := `INSERT INTO tickets_attachments
+			  (ticket_id, uploader_id, file_name, content_type, size_bytes, storage_key, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, NOW())
+			  RETURNING id, created_at`
+
+	var attachmentID sql.NullInt64
+	var createdAt sql.NullTime
+
+	err := r.db.QueryRow(ctx, query,
+		attachment.TicketID,
+		attachment.UploaderID,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.StorageKey).Scan(&attachmentID, &createdAt)
+
+	if pgErr, ok := err.(*pgconn.PgError); ok {
+		if _, ok := errorCodes[pgErr.Code]; ok {
+			return models.TicketAttachment{}, errorCodes[pgErr.Code]
+		}
+	}
+
+	if err != nil {
+		return models.TicketAttachment{}, err
+	}
+
+	if attachmentID.Valid {
+		attachment.AttachmentID = attachmentID.Int64
+	}
+
+	if createdAt.Valid {
+		attachment.CreatedAt = &createdAt.Time
+	}
+
+	return attachment, nil
+}
+
+// GetTicketAttachment returns an attachment of a ticket
+func (r postgresRepository) GetTicketAttachment(ctx context.Context, ticketID int64, attachmentID int64) (models.TicketAttachment, error) {
+	query := `SELECT * FROM tickets_attachments WHERE ticket_id = $1 AND id = $2`
+
+	attachment := models.TicketAttachment{}
+
+	rows, err := r.db.Query(ctx, query, ticketID, attachmentID)
+	if err != nil {
+		return models.TicketAttachment{}, err
+	}
+
+	err = pgxscan.NewScanner(rows).Scan(&attachment)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.TicketAttachment{}, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return models.TicketAttachment{}, err
+	}
+
+	return attachment, nil
+}
+
+// GetTicketAttachments returns the attachments of a ticket, oldest first
+func (r postgresRepository) GetTicketAttachments(ctx context.Context, ticketID int64) ([]models.TicketAttachment, error) {
+	query := `SELECT * FROM tickets_attachments WHERE ticket_id = $1 ORDER BY id`
+
+	attachments := []models.TicketAttachment{}
+
+	rows, err := r.db.Query(ctx, query, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pgxscan.NewScanner(rows).Scan(&attachments); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []models.TicketAttachment{}, nil
+		}
+
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// SaveOutboxEvent adds an event to the outbox, it is relayed once the transaction it is part of commits
+func (r postgresRepository) SaveOutboxEvent(ctx context.Context, event models.TicketEvent) error {
+	query := `INSERT INTO tickets_outbox
+			  (ticket_id, event_type, payload, created_at, next_attempt_at)
+			  VALUES ($1, $2, $3, NOW(), NOW())`
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, query, event.Ticket.TicketID, event.Type, payload)
+
+	return err
+}
+
+// ClaimOutboxEvents returns the oldest outbox events due by now and moves them to claimedUntil, so
+// the other dispatchers skip them while they are relayed and they are taken again if they are never
+// marked. The events whose payload cannot be read are returned with Err set
+func (r postgresRepository) ClaimOutboxEvents(ctx context.Context, now time.Time, claimedUntil time.Time, limit int) ([]models.OutboxEvent, error) {
+	query := `UPDATE tickets_outbox SET next_attempt_at = $2
+			  WHERE id IN (
+				  SELECT id FROM tickets_outbox
+				  WHERE dispatched_at IS NULL AND next_attempt_at <= $1
+				  ORDER BY id
+				  LIMIT $3
+				  FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING id, payload, attempts, delivered_to`
+
+	rows, err := r.db.Query(ctx, query, now, claimedUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := []models.OutboxEvent{}
+
+	for rows.Next() {
+		var eventID int64
+		var payload []byte
+		event := models.OutboxEvent{}
+
+		err = rows.Scan(&eventID, &payload, &event.Attempts, &event.DeliveredTo)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Err = json.Unmarshal(payload, &event.Event)
+		event.Event.EventID = eventID
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Event.EventID < events[j].Event.EventID
+	})
+
+	return events, nil
+}
+
+// MarkOutboxEventDispatched records that the event reached every consumer
+func (r postgresRepository) MarkOutboxEventDispatched(ctx context.Context, eventID int64) error {
+	query := `UPDATE tickets_outbox SET dispatched_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, eventID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkOutboxEventFailed records the consumers that took the event so far, why the rest did not and
+// when to try them again
+func (r postgresRepository) MarkOutboxEventFailed(ctx context.Context, eventID int64, deliveredTo []string, reason string, retryAt time.Time) error {
+	query := `UPDATE tickets_outbox SET attempts = attempts + 1, delivered_to = $2, last_error = $3, next_attempt_at = $4
+			  WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, eventID, deliveredTo, reason, retryAt)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkOutboxEventDead gives up on the event, it is kept with the reason but never claimed again
+func (r postgresRepository) MarkOutboxEventDead(ctx context.Context, eventID int64, reason string) error {
+	query := `UPDATE tickets_outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = NULL WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, eventID, reason)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
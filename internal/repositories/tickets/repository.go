@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/syned13/ticket-support-back/internal/models"
 )
@@ -12,14 +13,42 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrNothingToUpdate nothing to update
 	ErrNothingToUpdate = errors.New("nothing to update")
+	// ErrInvalidSortField invalid sort field
+	ErrInvalidSortField = errors.New("invalid sort field")
+	// ErrInvalidCursor the cursor does not match the sort or its value is not one of the sort field
+	ErrInvalidCursor = errors.New("invalid cursor")
 )
 
 type Repository interface {
+	// WithTx runs fn with a repository whose writes are committed together once fn succeeds and
+	// rolled back when it fails
+	WithTx(ctx context.Context, fn func(repo Repository) error) error
 	SaveTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error)
 	GetTicket(ctx context.Context, ticketID int64) (models.Ticket, error)
-	GetTickets(ctx context.Context, lastID int64) ([]models.Ticket, int64, error)
-	GetTicketsByCreator(ctx context.Context, creatorID int64, lastID int64) ([]models.Ticket, int64, error)
+	// GetTicketForUpdate returns the ticket locked until the transaction ends, it is meant to run in WithTx
+	GetTicketForUpdate(ctx context.Context, ticketID int64) (models.Ticket, error)
+	ListTickets(ctx context.Context, filter TicketFilter) ([]models.Ticket, *Cursor, error)
+	SearchTickets(ctx context.Context, query string, creatorID *int64, limit int, offset int) ([]models.TicketSearchResult, error)
 	UpdateTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error)
+	CountOpenTicketsByOwner(ctx context.Context) (map[int64]int, error)
+	FlagBreachedTickets(ctx context.Context, now time.Time) ([]int64, error)
+	ListSLATickets(ctx context.Context, dueBefore time.Time) ([]models.Ticket, error)
 	SaveTicketChange(ctx context.Context, ticketChange models.TicketChange) error
+	ListActivity(ctx context.Context, filter ActivityFilter) ([]models.TicketActivity, *ActivityCursor, error)
+	GetTicketHistory(ctx context.Context, ticketID int64) ([]models.TicketChange, error)
 	GetTicketChanges(ctx context.Context, creatorID int64) ([]models.TicketChange, error)
+	SaveTicketComment(ctx context.Context, comment models.TicketComment) (models.TicketComment, error)
+	GetTicketComments(ctx context.Context, ticketID int64, includeInternal bool) ([]models.TicketComment, error)
+	SaveTicketAttachment(ctx context.Context, attachment models.TicketAttachment) (models.TicketAttachment, error)
+	GetTicketAttachment(ctx context.Context, ticketID int64, attachmentID int64) (models.TicketAttachment, error)
+	GetTicketAttachments(ctx context.Context, ticketID int64) ([]models.TicketAttachment, error)
+	SaveOutboxEvent(ctx context.Context, event models.TicketEvent) error
+	// ClaimOutboxEvents returns the oldest outbox events due by now and moves them to claimedUntil, so
+	// nobody else claims them while they are relayed and they are taken again if they are never marked
+	ClaimOutboxEvents(ctx context.Context, now time.Time, claimedUntil time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkOutboxEventDispatched(ctx context.Context, eventID int64) error
+	// MarkOutboxEventFailed records the consumers that took the event so far and when to try the rest again
+	MarkOutboxEventFailed(ctx context.Context, eventID int64, deliveredTo []string, reason string, retryAt time.Time) error
+	// MarkOutboxEventDead gives up on the event, it is kept with the reason but never relayed
+	MarkOutboxEventDead(ctx context.Context, eventID int64, reason string) error
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
@@ -24,6 +25,8 @@ var (
 	}
 )
 
+const userColumns = `id, name, email, password, role, active, created_at, tokens_invalid_before`
+
 type PgxIface interface {
 	Begin(context.Context) (pgx.Tx, error)
 	Close(context.Context) error
@@ -47,14 +50,15 @@ func New(pool *pgxpool.Pool) (repository.Repository, error) {
 // CreateUser saves a user in the database
 func (r postgresRepository) CreateUser(ctx context.Context, user models.User) (models.User, error) {
 	query := `INSERT INTO users
-			(name, email, password, user_type, created_at)
+			(name, email, password, role, created_at)
 			VALUES ($1, $2, $3, $4, NOW() )
-			RETURNING id, created_at `
+			RETURNING id, active, created_at `
 
 	var userID sql.NullInt64
+	var active sql.NullBool
 	var createdAt sql.NullTime
 
-	err := r.pool.QueryRow(ctx, query, user.Name, user.Email, user.Password, user.Type).Scan(&userID, &createdAt)
+	err := r.pool.QueryRow(ctx, query, user.Name, user.Email, user.Password, user.Role).Scan(&userID, &active, &createdAt)
 	if pgErr, ok := err.(*pgconn.PgError); ok {
 		if _, ok := errorCodes[pgErr.Code]; ok {
 			return models.User{}, errorCodes[pgErr.Code]
@@ -70,6 +74,10 @@ func (r postgresRepository) CreateUser(ctx context.Context, user models.User) (m
 		user.UserID = userID.Int64
 	}
 
+	if active.Valid {
+		user.Active = active.Bool
+	}
+
 	if createdAt.Valid {
 		user.CreateAt = createdAt.Time
 	}
@@ -79,32 +87,89 @@ func (r postgresRepository) CreateUser(ctx context.Context, user models.User) (m
 
 // GetUser gets a user from the database based on the userID
 func (r postgresRepository) GetUser(ctx context.Context, userID int) (models.User, error) {
-	query := `SELECT * FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	return scanUser(r.pool.QueryRow(ctx, query, userID))
+}
+
+// GetUserByEmail returns a user from the dabase based on the email
+func (r postgresRepository) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+
+	return scanUser(r.pool.QueryRow(ctx, query, email))
+}
+
+// ListUsers returns up to limit users with an id greater than lastID, sorted by id
+func (r postgresRepository) ListUsers(ctx context.Context, lastID int64, limit int) ([]models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id > $1 ORDER BY id LIMIT $2`
+
+	return r.queryUsers(ctx, query, lastID, limit)
+}
 
-	rows, err := r.pool.Query(ctx, query, userID)
+func (r postgresRepository) queryUsers(ctx context.Context, query string, params ...interface{}) ([]models.User, error) {
+	rows, err := r.pool.Query(ctx, query, params...)
 	if err != nil {
-		return models.User{}, err
+		return nil, err
 	}
 
-	user := models.User{}
+	defer rows.Close()
 
-	err = rows.Scan(&user.UserID, &user.Email, &user.Name, &user.Password, &user.Type, &user.CreateAt)
-	if err != nil {
-		return models.User{}, err
+	users := []models.User{}
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
 	}
 
-	return user, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
 }
 
-// GetUserByEmail returns a user from the dabase based on the email
-func (r postgresRepository) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
-	query := `SELECT * FROM users WHERE email = $1`
+// ListActiveUsersWithPermission returns every active user whose role grants the permission, sorted by id
+func (r postgresRepository) ListActiveUsersWithPermission(ctx context.Context, permission models.Permission) ([]models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users
+			WHERE active AND role IN (SELECT role FROM roles_permissions WHERE permission = $1)
+			ORDER BY id`
+
+	return r.queryUsers(ctx, query, permission)
+}
+
+// UpdateUser updates the name, email, role and active state of a user
+func (r postgresRepository) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	query := `UPDATE users SET name = $1, email = $2, role = $3, active = $4
+			WHERE id = $5
+			RETURNING ` + userColumns
+
+	return scanUser(r.pool.QueryRow(ctx, query, user.Name, user.Email, user.Role, user.Active, user.UserID))
+}
 
-	rows := r.pool.QueryRow(ctx, query, email)
+// UpdatePassword replaces the password hash of a user. Returns ErrNotFound when the user does not exist
+func (r postgresRepository) UpdatePassword(ctx context.Context, userID int64, password string) error {
+	query := `UPDATE users SET password = $1 WHERE id = $2`
+
+	tag, err := r.pool.Exec(ctx, query, password, userID)
+	if err != nil {
+		return err
+	}
 
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row pgx.Row) (models.User, error) {
 	user := models.User{}
 
-	err := rows.Scan(&user.UserID, &user.Name, &user.Email, &user.Password, &user.Type, &user.CreateAt)
+	err := row.Scan(&user.UserID, &user.Name, &user.Email, &user.Password, &user.Role, &user.Active, &user.CreateAt, &user.TokensInvalidBefore)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return models.User{}, repository.ErrNotFound
 	}
@@ -121,3 +186,137 @@ func (r postgresRepository) GetUserByEmail(ctx context.Context, email string) (m
 
 	return user, nil
 }
+
+// SaveRefreshToken saves a refresh token
+func (r postgresRepository) SaveRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error) {
+	query := `INSERT INTO refresh_tokens
+			(user_id, token_hash, expires_at, created_at)
+			VALUES ($1, $2, $3, NOW())
+			RETURNING id, created_at`
+
+	var tokenID sql.NullInt64
+	var createdAt sql.NullTime
+
+	err := r.pool.QueryRow(ctx, query, token.UserID, token.TokenHash, token.ExpiresAt).Scan(&tokenID, &createdAt)
+	if pgErr, ok := err.(*pgconn.PgError); ok {
+		if _, ok := errorCodes[pgErr.Code]; ok {
+			return models.RefreshToken{}, errorCodes[pgErr.Code]
+		}
+	}
+
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	if tokenID.Valid {
+		token.TokenID = tokenID.Int64
+	}
+
+	if createdAt.Valid {
+		token.CreatedAt = createdAt.Time
+	}
+
+	return token, nil
+}
+
+// GetRefreshToken returns a refresh token based on its hash
+func (r postgresRepository) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	query := `SELECT id, user_id, token_hash, expires_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1`
+
+	token := models.RefreshToken{}
+
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&token.TokenID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.RefreshToken{}, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+
+	return token, nil
+}
+
+// RevokeRefreshToken revokes a refresh token. Returns ErrNotFound if the token does not exist or
+// was already revoked, so only one caller can ever revoke a given token
+func (r postgresRepository) RevokeRefreshToken(ctx context.Context, tokenID int64) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+
+	tag, err := r.pool.Exec(ctx, query, tokenID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token of a user
+func (r postgresRepository) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := r.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserAccessTokens makes the access tokens of a user issued before issuedBefore invalid
+func (r postgresRepository) RevokeUserAccessTokens(ctx context.Context, userID int64, issuedBefore time.Time) error {
+	query := `UPDATE users SET tokens_invalid_before = $1 WHERE id = $2`
+
+	tag, err := r.pool.Exec(ctx, query, issuedBefore.UTC(), userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeToken adds an access token id to the revocation list. Entries are kept until the token expires
+func (r postgresRepository) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+
+	_, err := r.pool.Exec(ctx, query, tokenID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsTokenRevoked returns whether an access token id is in the revocation list
+func (r postgresRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	var revoked bool
+
+	err := r.pool.QueryRow(ctx, query, tokenID).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
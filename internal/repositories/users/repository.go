@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/syned13/ticket-support-back/internal/models"
 )
@@ -19,4 +20,15 @@ type Repository interface {
 	CreateUser(ctx context.Context, user models.User) (models.User, error)
 	GetUser(ctx context.Context, userID int) (models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	ListUsers(ctx context.Context, lastID int64, limit int) ([]models.User, error)
+	ListActiveUsersWithPermission(ctx context.Context, permission models.Permission) ([]models.User, error)
+	UpdateUser(ctx context.Context, user models.User) (models.User, error)
+	UpdatePassword(ctx context.Context, userID int64, password string) error
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, tokenID int64) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) error
+	RevokeUserAccessTokens(ctx context.Context, userID int64, issuedBefore time.Time) error
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
 }
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/syned13/ticket-support-back/internal/models"
+	repository "github.com/syned13/ticket-support-back/internal/repositories/webhooks"
+)
+
+var (
+	// ErrMissingPool missing pool
+	ErrMissingPool = errors.New("missing pool")
+)
+
+const (
+	webhookColumns  = `id, url, event_types, secret, active, creator_id, created_at`
+	deliveryColumns = `id, webhook_id, event_id, event_type, payload, attempt, replay_of, status_code, error, success, created_at, next_attempt_at`
+)
+
+type postgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// New returns a new postgress repository
+func New(pool *pgxpool.Pool) (repository.Repository, error) {
+	if pool == nil {
+		return nil, ErrMissingPool
+	}
+
+	return postgresRepository{
+		pool: pool,
+	}, nil
+}
+
+// scanWebhook scans a row made of webhookColumns
+func scanWebhook(row pgx.Row) (models.Webhook, error) {
+	webhook := models.Webhook{}
+	eventTypes := []string{}
+
+	err := row.Scan(
+		&webhook.WebhookID,
+		&webhook.URL,
+		&eventTypes,
+		&webhook.Secret,
+		&webhook.Active,
+		&webhook.CreatorID,
+		&webhook.CreatedAt,
+	)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	for _, eventType := range eventTypes {
+		webhook.EventTypes = append(webhook.EventTypes, models.TicketEventType(eventType))
+	}
+
+	return webhook, nil
+}
+
+// scanDelivery scans a row made of deliveryColumns
+func scanDelivery(row pgx.Row) (models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{}
+	var payload []byte
+
+	err := row.Scan(
+		&delivery.DeliveryID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Attempt,
+		&delivery.ReplayOf,
+		&delivery.StatusCode,
+		&delivery.Error,
+		&delivery.Success,
+		&delivery.CreatedAt,
+		&delivery.NextAttemptAt,
+	)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery.Payload = payload
+
+	return delivery, nil
+}
+
+func eventTypesToStrings(eventTypes []models.TicketEventType) []string {
+	values := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		values = append(values, string(eventType))
+	}
+
+	return values
+}
+
+// CreateWebhook saves a webhook
+func (r postgresRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	query := `INSERT INTO webhooks
+			  (url, event_types, secret, active, creator_id, created_at)
+			  VALUES ($1, $2, $3, $4, $5, NOW())
+			  RETURNING ` + webhookColumns
+
+	row := r.pool.QueryRow(ctx, query,
+		webhook.URL,
+		eventTypesToStrings(webhook.EventTypes),
+		webhook.Secret,
+		webhook.Active,
+		webhook.CreatorID)
+
+	return scanWebhook(row)
+}
+
+// GetWebhook returns a webhook by its id
+func (r postgresRepository) GetWebhook(ctx context.Context, webhookID int64) (models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	webhook, err := scanWebhook(r.pool.QueryRow(ctx, query, webhookID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Webhook{}, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks returns every webhook
+func (r postgresRepository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`
+
+	return r.listWebhooks(ctx, query)
+}
+
+// ListActiveWebhooks returns the active webhooks subscribed to the event type
+func (r postgresRepository) ListActiveWebhooks(ctx context.Context, eventType models.TicketEventType) ([]models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE active AND $1 = ANY(event_types) ORDER BY id`
+
+	return r.listWebhooks(ctx, query, string(eventType))
+}
+
+func (r postgresRepository) listWebhooks(ctx context.Context, query string, params ...interface{}) ([]models.Webhook, error) {
+	rows, err := r.pool.Query(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries
+func (r postgresRepository) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, webhookID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// SaveDelivery saves an attempt to deliver an event
+func (r postgresRepository) SaveDelivery(ctx context.Context, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	query := `INSERT INTO webhooks_deliveries
+			  (webhook_id, event_id, event_type, payload, attempt, replay_of, status_code, error, success, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+			  RETURNING ` + deliveryColumns
+
+	row := r.pool.QueryRow(ctx, query,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType,
+		[]byte(delivery.Payload),
+		delivery.Attempt,
+		delivery.ReplayOf,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.Success)
+
+	return scanDelivery(row)
+}
+
+// QueueDelivery saves the first attempt of an event to be made at its NextAttemptAt. It does
+// nothing when the event was already queued for the webhook
+func (r postgresRepository) QueueDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	query := `INSERT INTO webhooks_deliveries
+			  (webhook_id, event_id, event_type, payload, attempt, success, next_attempt_at, created_at)
+			  VALUES ($1, $2, $3, $4, 1, FALSE, $5, NOW())
+			  ON CONFLICT (webhook_id, event_id) WHERE attempt = 1 AND replay_of IS NULL DO NOTHING`
+
+	_, err := r.pool.Exec(ctx, query,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType,
+		[]byte(delivery.Payload),
+		delivery.NextAttemptAt)
+
+	return err
+}
+
+// ClaimDueDelivery returns the oldest attempt due by now and moves it to claimedUntil, so the
+// other workers skip it while it is made and it is taken again if it is never completed
+func (r postgresRepository) ClaimDueDelivery(ctx context.Context, now time.Time, claimedUntil time.Time) (models.WebhookDelivery, error) {
+	query := `UPDATE webhooks_deliveries SET next_attempt_at = $2
+			  WHERE id = (
+				  SELECT id FROM webhooks_deliveries
+				  WHERE next_attempt_at <= $1
+				  ORDER BY next_attempt_at, id
+				  LIMIT 1
+				  FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING ` + deliveryColumns
+
+	delivery, err := scanDelivery(r.pool.QueryRow(ctx, query, now, claimedUntil))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.WebhookDelivery{}, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+// CompleteDelivery records the outcome of an attempt and queues the next one, if any, in the same
+// transaction
+func (r postgresRepository) CompleteDelivery(ctx context.Context, delivery models.WebhookDelivery, next *models.WebhookDelivery) error {
+	return r.pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		query := `UPDATE webhooks_deliveries SET status_code = $2, error = $3, success = $4, next_attempt_at = NULL
+				  WHERE id = $1`
+
+		result, err := tx.Exec(ctx, query, delivery.DeliveryID, delivery.StatusCode, delivery.Error, delivery.Success)
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		query = `INSERT INTO webhooks_deliveries
+				 (webhook_id, event_id, event_type, payload, attempt, success, next_attempt_at, created_at)
+				 VALUES ($1, $2, $3, $4, $5, FALSE, $6, NOW())`
+
+		_, err = tx.Exec(ctx, query,
+			next.WebhookID,
+			next.EventID,
+			next.EventType,
+			[]byte(next.Payload),
+			next.Attempt,
+			next.NextAttemptAt)
+
+		return err
+	})
+}
+
+// GetDelivery returns a delivery of a webhook
+func (r postgresRepository) GetDelivery(ctx context.Context, webhookID int64, deliveryID int64) (models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhooks_deliveries WHERE webhook_id = $1 AND id = $2`
+
+	delivery, err := scanDelivery(r.pool.QueryRow(ctx, query, webhookID, deliveryID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.WebhookDelivery{}, repository.ErrNotFound
+	}
+
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+// ListDeliveries returns the deliveries of a webhook, newest first. When lastID is given only the
+// deliveries older than it are returned
+func (r postgresRepository) ListDeliveries(ctx context.Context, webhookID int64, lastID int64, limit int) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhooks_deliveries
+			  WHERE webhook_id = $1 AND ($2 = 0 OR id < $2)
+			  ORDER BY id DESC
+			  LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, webhookID, lastID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+var (
+	// ErrNotFound not found
+	ErrNotFound = errors.New("not found")
+)
+
+// Repository defines the data-persistance related methods for webhooks
+type Repository interface {
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetWebhook(ctx context.Context, webhookID int64) (models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	ListActiveWebhooks(ctx context.Context, eventType models.TicketEventType) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID int64) error
+	SaveDelivery(ctx context.Context, delivery models.WebhookDelivery) (models.WebhookDelivery, error)
+	// QueueDelivery saves the first attempt of an event to be made later. It does nothing when the
+	// event was already queued for the webhook
+	QueueDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	// ClaimDueDelivery returns the oldest attempt due by now and moves it to claimedUntil, so nobody
+	// else takes it meanwhile. Returns ErrNotFound when none is due
+	ClaimDueDelivery(ctx context.Context, now time.Time, claimedUntil time.Time) (models.WebhookDelivery, error)
+	// CompleteDelivery records the outcome of an attempt along with the next one, if any
+	CompleteDelivery(ctx context.Context, delivery models.WebhookDelivery, next *models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, webhookID int64, deliveryID int64) (models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, webhookID int64, lastID int64, limit int) ([]models.WebhookDelivery, error)
+}
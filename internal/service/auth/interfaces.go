@@ -10,4 +10,14 @@ import (
 type Service interface {
 	CreateUser(ctx context.Context, user models.User) (models.User, error)
 	Login(ctx context.Context, email, password string) (LoginResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string) (LoginResponse, error)
+	Logout(ctx context.Context, accessToken string, refreshToken string) error
+	ValidateToken(ctx context.Context, accessToken string) (TokenClaims, error)
+	GetUsers(ctx context.Context, lastID int64, requester models.Requester) (GetUsersResponse, error)
+	GetUser(ctx context.Context, userID int64, requester models.Requester) (models.User, error)
+	UpdateUser(ctx context.Context, userID int64, request UpdateUserRequest, requester models.Requester) (models.User, error)
+	SetUserActive(ctx context.Context, userID int64, active bool, requester models.Requester) (models.User, error)
+	ChangeUserRole(ctx context.Context, userID int64, role models.Role, requester models.Requester) (models.User, error)
+	// ResetPassword is meant for operators, it does not check who asks for it
+	ResetPassword(ctx context.Context, email string, password string) (models.User, error)
 }
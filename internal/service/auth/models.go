@@ -1,9 +1,34 @@
 package service
 
-import "github.com/syned13/ticket-support-back/internal/models"
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/syned13/ticket-support-back/internal/models"
+)
 
 // LoginResponse login response
 type LoginResponse struct {
-	User  models.User `json:"user"`
-	Token string      `json:"token"`
+	User         models.User `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refreshToken"`
+}
+
+// TokenClaims claims of an access token. The standard jti claim identifies the token so it can be revoked.
+// The permissions are not part of the token, they are looked up for its role when it is validated
+type TokenClaims struct {
+	Role        models.Role        `json:"role"`
+	Permissions models.Permissions `json:"-"`
+	jwt.StandardClaims
+}
+
+// GetUsersResponse a page of users
+type GetUsersResponse struct {
+	Users []models.User `json:"users"`
+	Last  int64         `json:"last"`
+	Total int           `json:"total"`
+}
+
+// UpdateUserRequest fields of a user that can be changed. Nil fields are left untouched
+type UpdateUserRequest struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
 }
@@ -4,19 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/syned13/ticket-support-back/internal/models"
 	usersRepo "github.com/syned13/ticket-support-back/internal/repositories/users"
+	rbacService "github.com/syned13/ticket-support-back/internal/service/rbac"
 	"github.com/syned13/ticket-support-back/pkg/httputils"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	tokenSub      = "ticket-support-back"
-	tokenDuration = time.Hour * 24
+	tokenSub             = "ticket-support-back"
+	tokenDuration        = time.Minute * 15
+	refreshTokenDuration = time.Hour * 24 * 30
 )
 
 var (
@@ -28,16 +31,18 @@ var (
 	ErrMissingEmail = httputils.NewBadRequestError("missing email")
 	// ErrDuplicateFields duplicate fields
 	ErrDuplicateFields = httputils.NewBadRequestError("duplicate fields")
-	// ErrMissingType missing type
-	ErrMissingType = errors.New("missing type")
-	// ErrInvalidType invalid type
-	ErrInvalidType = errors.New("invalid type")
+	// ErrMissingRole missing role
+	ErrMissingRole = errors.New("missing role")
+	// ErrInvalidRole invalid role
+	ErrInvalidRole = errors.New("invalid role")
 	// ErrPasswordHashingFailed hashing password failed
 	ErrPasswordHashingFailed = errors.New("hashing password failed")
 	// ErrInvalidCredentials invalid credentials
 	ErrInvalidCredentials = httputils.NewBadRequestError("invalid credentials")
 	// ErrGeneratingIDFailed generating id failed
 	ErrGeneratingIDFailed = errors.New("generating id failed")
+	// ErrUserDeactivated user deactivated
+	ErrUserDeactivated = httputils.ErrorResponse{Code: http.StatusForbidden, Message: "user is deactivated"}
 )
 
 var (
@@ -46,15 +51,17 @@ var (
 
 type service struct {
 	repo usersRepo.Repository
+	rbac rbacService.Service
 }
 
 func init() {
 	generatePasswordHashFunction = bcrypt.GenerateFromPassword
 }
 
-func New(repo usersRepo.Repository) Service {
+func New(repo usersRepo.Repository, rbac rbacService.Service) Service {
 	return service{
 		repo: repo,
+		rbac: rbac,
 	}
 }
 
@@ -64,13 +71,11 @@ func (s service) CreateUser(ctx context.Context, user models.User) (models.User,
 		return models.User{}, err
 	}
 
-	hashedPassword, err := generatePasswordHashFunction([]byte(user.Password), bcrypt.DefaultCost)
+	user.Password, err = hashPassword(user.Password)
 	if err != nil {
-		return models.User{}, ErrPasswordHashingFailed
+		return models.User{}, err
 	}
 
-	user.Password = string(hashedPassword)
-
 	createdUser, err := s.repo.CreateUser(ctx, user)
 	if errors.Is(err, usersRepo.ErrDuplicateField) {
 		return models.User{}, ErrDuplicateFields
@@ -85,6 +90,16 @@ func (s service) CreateUser(ctx context.Context, user models.User) (models.User,
 	return createdUser, nil
 }
 
+// hashPassword returns the hash of the password as it is stored
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := generatePasswordHashFunction([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", ErrPasswordHashingFailed
+	}
+
+	return string(hashedPassword), nil
+}
+
 func validateCreateUserParams(user models.User) error {
 	if user.Email == "" {
 		return ErrMissingEmail
@@ -98,12 +113,12 @@ func validateCreateUserParams(user models.User) error {
 		return ErrMissingPassword
 	}
 
-	if user.Type == "" {
-		return ErrMissingType
+	if user.Role == "" {
+		return ErrMissingRole
 	}
 
-	if !user.HasValidType() {
-		return ErrInvalidType
+	if !user.HasValidRole() {
+		return ErrInvalidRole
 	}
 
 	return nil
@@ -128,14 +143,11 @@ func (s service) Login(ctx context.Context, email, password string) (LoginRespon
 		return LoginResponse{}, ErrInvalidCredentials
 	}
 
-	token, err := generateToken(user)
-	if err != nil {
-		return LoginResponse{}, err
+	if !user.Active {
+		return LoginResponse{}, ErrUserDeactivated
 	}
 
-	user.Password = ""
-
-	return LoginResponse{User: user, Token: token}, nil
+	return s.issueTokens(ctx, user)
 }
 
 func validateLoginParams(email, password string) error {
@@ -151,12 +163,22 @@ func validateLoginParams(email, password string) error {
 }
 
 func generateToken(user models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":      fmt.Sprint(user.UserID),
-		"iss":      tokenSub,
-		"userType": user.Type,
-		"iat":      time.Now(),
-		"exp":      time.Now().Add(tokenDuration), // TODO: make the adding a const
+	tokenID, err := generateRandomString(16)
+	if err != nil {
+		return "", ErrGeneratingIDFailed
+	}
+
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, TokenClaims{
+		Role: user.Role,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
+			Subject:   fmt.Sprint(user.UserID),
+			Issuer:    tokenSub,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(tokenDuration).Unix(),
+		},
 	})
 
 	signedToken, err := token.SignedString([]byte(os.Getenv("TOKEN_SECRET")))
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/syned13/ticket-support-back/internal/models"
+	usersRepo "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrMissingRefreshToken missing refresh token
+	ErrMissingRefreshToken = httputils.NewBadRequestError("missing refresh token")
+	// ErrInvalidRefreshToken invalid refresh token
+	ErrInvalidRefreshToken = httputils.UnauthorizedError
+	// ErrInvalidToken invalid token
+	ErrInvalidToken = httputils.ForbiddenError
+	// ErrInvalidTokenSigningMethod invalid token signing method
+	ErrInvalidTokenSigningMethod = errors.New("invalid token signing method")
+)
+
+// issueTokens returns a new access token and a new refresh token for the user
+func (s service) issueTokens(ctx context.Context, user models.User) (LoginResponse, error) {
+	token, err := generateToken(user)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	refreshToken, err := generateRandomString(32)
+	if err != nil {
+		return LoginResponse{}, ErrGeneratingIDFailed
+	}
+
+	_, err = s.repo.SaveRefreshToken(ctx, models.RefreshToken{
+		UserID:    user.UserID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenDuration).UTC(),
+	})
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	user.Password = ""
+
+	return LoginResponse{User: user, Token: token, RefreshToken: refreshToken}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new pair of tokens. Refresh tokens are rotated: the
+// given one stops working, and presenting an already used one revokes every session of its user
+func (s service) RefreshToken(ctx context.Context, refreshToken string) (LoginResponse, error) {
+	if refreshToken == "" {
+		return LoginResponse{}, ErrMissingRefreshToken
+	}
+
+	storedToken, err := s.repo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return LoginResponse{}, ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	if storedToken.RevokedAt != nil {
+		return LoginResponse{}, s.revokeReusedToken(ctx, storedToken)
+	}
+
+	if time.Now().After(storedToken.ExpiresAt) {
+		return LoginResponse{}, ErrInvalidRefreshToken
+	}
+
+	err = s.repo.RevokeRefreshToken(ctx, storedToken.TokenID)
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return LoginResponse{}, s.revokeReusedToken(ctx, storedToken)
+	}
+
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	user, err := s.repo.GetUser(ctx, int(storedToken.UserID))
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return LoginResponse{}, ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	if !user.Active {
+		return LoginResponse{}, ErrUserDeactivated
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// revokeReusedToken handles a refresh token being used twice, which means it was stolen
+func (s service) revokeReusedToken(ctx context.Context, token models.RefreshToken) error {
+	err := s.revokeUserSessions(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+
+	return ErrInvalidRefreshToken
+}
+
+// revokeUserSessions ends every session of the user: its refresh tokens are revoked and the access
+// tokens issued until now are rejected by ValidateToken
+func (s service) revokeUserSessions(ctx context.Context, userID int64) error {
+	err := s.repo.RevokeUserRefreshTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RevokeUserAccessTokens(ctx, userID, time.Now())
+}
+
+// Logout revokes the access token and, if given, the refresh token of the session
+func (s service) Logout(ctx context.Context, accessToken string, refreshToken string) error {
+	claims, err := s.ValidateToken(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0).UTC())
+	if err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	storedToken, err := s.repo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if strconv.FormatInt(storedToken.UserID, 10) != claims.Subject {
+		return httputils.ForbiddenError
+	}
+
+	err = s.repo.RevokeRefreshToken(ctx, storedToken.TokenID)
+	if err != nil && !errors.Is(err, usersRepo.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// ValidateToken parses the access token, verifying its signature, expiration and that it was not revoked,
+// and that its user is still active. Returns its claims with the current role of the user and the
+// permissions granted to it. Iat only has seconds, so a token issued in the same second its user's
+// sessions were revoked is rejected too and has to be refreshed
+func (s service) ValidateToken(ctx context.Context, accessToken string) (TokenClaims, error) {
+	claims := TokenClaims{}
+
+	token, err := jwt.ParseWithClaims(accessToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidTokenSigningMethod
+		}
+
+		return []byte(os.Getenv("TOKEN_SECRET")), nil
+	})
+
+	if err != nil || !token.Valid {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	if claims.Id == "" {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	revoked, err := s.repo.IsTokenRevoked(ctx, claims.Id)
+	if err != nil {
+		return TokenClaims{}, fmt.Errorf("checking token revocation failed: %w", err)
+	}
+
+	if revoked {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	user, err := s.repo.GetUser(ctx, int(userID))
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	if err != nil {
+		return TokenClaims{}, fmt.Errorf("getting token user failed: %w", err)
+	}
+
+	if !user.Active {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	if user.TokensInvalidBefore != nil && time.Unix(claims.IssuedAt, 0).Before(*user.TokensInvalidBefore) {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	claims.Role = user.Role
+
+	claims.Permissions, err = s.rbac.GetPermissions(ctx, claims.Role)
+	if err != nil {
+		return TokenClaims{}, fmt.Errorf("getting permissions failed: %w", err)
+	}
+
+	return claims, nil
+}
+
+func generateRandomString(size int) (string, error) {
+	bytes := make([]byte, size)
+
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashToken returns the hash under which a refresh token is stored
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
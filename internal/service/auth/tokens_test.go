@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	usersRepo "github.com/syned13/ticket-support-back/internal/repositories/users"
+	rbacService "github.com/syned13/ticket-support-back/internal/service/rbac"
+)
+
+// rbacMock grants the roles their default permissions
+type rbacMock struct {
+	rbacService.Service
+}
+
+func (m rbacMock) GetPermissions(ctx context.Context, role models.Role) (models.Permissions, error) {
+	return models.DefaultRolePermissions[role], nil
+}
+
+// usersRepoMock is an in-memory users repository. Methods not overridden panic when called
+type usersRepoMock struct {
+	usersRepo.Repository
+	users         map[int64]models.User
+	refreshTokens map[string]models.RefreshToken
+	revokedTokens map[string]time.Time
+}
+
+func newUsersRepoMock(users ...models.User) *usersRepoMock {
+	repo := &usersRepoMock{
+		users:         map[int64]models.User{},
+		refreshTokens: map[string]models.RefreshToken{},
+		revokedTokens: map[string]time.Time{},
+	}
+
+	for _, user := range users {
+		repo.users[user.UserID] = user
+	}
+
+	return repo
+}
+
+func (m *usersRepoMock) GetUser(ctx context.Context, userID int) (models.User, error) {
+	user, ok := m.users[int64(userID)]
+	if !ok {
+		return models.User{}, usersRepo.ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (m *usersRepoMock) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return models.User{}, usersRepo.ErrNotFound
+}
+
+func (m *usersRepoMock) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	if _, ok := m.users[user.UserID]; !ok {
+		return models.User{}, usersRepo.ErrNotFound
+	}
+
+	m.users[user.UserID] = user
+
+	return user, nil
+}
+
+func (m *usersRepoMock) UpdatePassword(ctx context.Context, userID int64, password string) error {
+	user, ok := m.users[userID]
+	if !ok {
+		return usersRepo.ErrNotFound
+	}
+
+	user.Password = password
+	m.users[userID] = user
+
+	return nil
+}
+
+func (m *usersRepoMock) SaveRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error) {
+	token.TokenID = int64(len(m.refreshTokens) + 1)
+	m.refreshTokens[token.TokenHash] = token
+
+	return token, nil
+}
+
+func (m *usersRepoMock) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	token, ok := m.refreshTokens[tokenHash]
+	if !ok {
+		return models.RefreshToken{}, usersRepo.ErrNotFound
+	}
+
+	return token, nil
+}
+
+func (m *usersRepoMock) RevokeRefreshToken(ctx context.Context, tokenID int64) error {
+	for hash, token := range m.refreshTokens {
+		if token.TokenID == tokenID && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt = &now
+			m.refreshTokens[hash] = token
+
+			return nil
+		}
+	}
+
+	return usersRepo.ErrNotFound
+}
+
+func (m *usersRepoMock) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	for hash, token := range m.refreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			now := time.Now()
+			token.RevokedAt = &now
+			m.refreshTokens[hash] = token
+		}
+	}
+
+	return nil
+}
+
+func (m *usersRepoMock) RevokeUserAccessTokens(ctx context.Context, userID int64, issuedBefore time.Time) error {
+	user, ok := m.users[userID]
+	if !ok {
+		return usersRepo.ErrNotFound
+	}
+
+	user.TokensInvalidBefore = &issuedBefore
+	m.users[userID] = user
+
+	return nil
+}
+
+func (m *usersRepoMock) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	m.revokedTokens[tokenID] = expiresAt
+	return nil
+}
+
+func (m *usersRepoMock) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	_, ok := m.revokedTokens[tokenID]
+	return ok, nil
+}
+
+var testUser = models.User{UserID: 7, Name: "Erica", Email: "erica@erica.com", Role: models.RoleAdmin, Active: true}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newUsersRepoMock(testUser), rbacMock{})
+
+	first, err := s.(service).issueTokens(context.Background(), testUser)
+	c.Nil(err)
+
+	second, err := s.RefreshToken(context.Background(), first.RefreshToken)
+	c.Nil(err)
+	c.NotEqual(first.RefreshToken, second.RefreshToken)
+	c.Equal(testUser.UserID, second.User.UserID)
+
+	claims, err := s.ValidateToken(context.Background(), second.Token)
+	c.Nil(err)
+	c.Equal("7", claims.Subject)
+	c.Equal(models.RoleAdmin, claims.Role)
+	c.True(claims.Permissions.Has(models.PermissionUserManage))
+}
+
+func TestRefreshTokenReuseRevokesSessions(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newUsersRepoMock(testUser), rbacMock{})
+
+	first, err := s.(service).issueTokens(context.Background(), testUser)
+	c.Nil(err)
+
+	second, err := s.RefreshToken(context.Background(), first.RefreshToken)
+	c.Nil(err)
+
+	_, err = s.RefreshToken(context.Background(), first.RefreshToken)
+	c.Equal(ErrInvalidRefreshToken, err)
+
+	_, err = s.RefreshToken(context.Background(), second.RefreshToken)
+	c.Equal(ErrInvalidRefreshToken, err)
+
+	_, err = s.ValidateToken(context.Background(), second.Token)
+	c.Equal(ErrInvalidToken, err)
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newUsersRepoMock(testUser), rbacMock{})
+
+	session, err := s.(service).issueTokens(context.Background(), testUser)
+	c.Nil(err)
+
+	err = s.Logout(context.Background(), session.Token, session.RefreshToken)
+	c.Nil(err)
+
+	_, err = s.ValidateToken(context.Background(), session.Token)
+	c.Equal(ErrInvalidToken, err)
+
+	_, err = s.RefreshToken(context.Background(), session.RefreshToken)
+	c.Equal(ErrInvalidRefreshToken, err)
+}
+
+func TestValidateTokenRejectsGarbage(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newUsersRepoMock(), rbacMock{})
+
+	_, err := s.ValidateToken(context.Background(), "not.a.token")
+	c.Equal(ErrInvalidToken, err)
+}
+
+func TestRefreshTokenDeactivatedUser(t *testing.T) {
+	c := require.New(t)
+
+	repo := newUsersRepoMock(testUser)
+	s := New(repo, rbacMock{})
+
+	session, err := s.(service).issueTokens(context.Background(), testUser)
+	c.Nil(err)
+
+	deactivated := testUser
+	deactivated.Active = false
+	repo.users[testUser.UserID] = deactivated
+
+	_, err = s.RefreshToken(context.Background(), session.RefreshToken)
+	c.Equal(ErrUserDeactivated, err)
+
+	_, err = s.ValidateToken(context.Background(), session.Token)
+	c.Equal(ErrInvalidToken, err)
+}
+
+func TestValidateTokenUsesCurrentRole(t *testing.T) {
+	c := require.New(t)
+
+	repo := newUsersRepoMock(testUser)
+	s := New(repo, rbacMock{})
+
+	session, err := s.(service).issueTokens(context.Background(), testUser)
+	c.Nil(err)
+
+	demoted := testUser
+	demoted.Role = models.RoleAgent
+	repo.users[testUser.UserID] = demoted
+
+	claims, err := s.ValidateToken(context.Background(), session.Token)
+	c.Nil(err)
+	c.Equal(models.RoleAgent, claims.Role)
+	c.False(claims.Permissions.Has(models.PermissionUserManage))
+}
+
+func TestValidateTokenIssuedBeforeRevocation(t *testing.T) {
+	c := require.New(t)
+
+	repo := newUsersRepoMock(testUser)
+	s := New(repo, rbacMock{})
+
+	session, err := s.(service).issueTokens(context.Background(), testUser)
+	c.Nil(err)
+
+	issuedBefore := time.Now().Add(-time.Minute)
+	c.Nil(repo.RevokeUserAccessTokens(context.Background(), testUser.UserID, issuedBefore))
+
+	_, err = s.ValidateToken(context.Background(), session.Token)
+	c.Nil(err)
+
+	c.Nil(repo.RevokeUserAccessTokens(context.Background(), testUser.UserID, time.Now().Add(time.Second)))
+
+	_, err = s.ValidateToken(context.Background(), session.Token)
+	c.Equal(ErrInvalidToken, err)
+}
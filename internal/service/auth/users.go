@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	usersRepo "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	usersPageSize = 100
+)
+
+var (
+	// ErrCannotChangeOwnUser cannot change own user
+	ErrCannotChangeOwnUser = httputils.NewBadRequestError("users can not deactivate themselves or change their own role")
+	// ErrInvalidUserRole invalid user role
+	ErrInvalidUserRole = httputils.NewBadRequestError("invalid user role")
+	// ErrNothingToUpdate nothing to update
+	ErrNothingToUpdate = httputils.NewBadRequestError("nothing to update")
+)
+
+// GetUsers returns a page of the users, sorted by id. Only the ones allowed to read users can list them
+func (s service) GetUsers(ctx context.Context, lastID int64, requester models.Requester) (GetUsersResponse, error) {
+	if !requester.Can(models.PermissionUserRead) {
+		return GetUsersResponse{}, httputils.ForbiddenError
+	}
+
+	users, err := s.repo.ListUsers(ctx, lastID, usersPageSize)
+	if err != nil {
+		return GetUsersResponse{}, err
+	}
+
+	var last int64
+
+	for i := range users {
+		users[i].Password = ""
+		last = users[i].UserID
+	}
+
+	return GetUsersResponse{Users: users, Last: last, Total: len(users)}, nil
+}
+
+// GetUser returns a user. Users can get themselves and the ones allowed to read users can get anybody
+func (s service) GetUser(ctx context.Context, userID int64, requester models.Requester) (models.User, error) {
+	if !requester.Can(models.PermissionUserRead) && requester.UserID != userID {
+		return models.User{}, httputils.ForbiddenError
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Password = ""
+
+	return user, nil
+}
+
+// UpdateUser changes the name or email of a user. Only the ones allowed to manage users can update them
+func (s service) UpdateUser(ctx context.Context, userID int64, request UpdateUserRequest, requester models.Requester) (models.User, error) {
+	if !requester.Can(models.PermissionUserManage) {
+		return models.User{}, httputils.ForbiddenError
+	}
+
+	if request.Name == nil && request.Email == nil {
+		return models.User{}, ErrNothingToUpdate
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if request.Name != nil {
+		if strings.TrimSpace(*request.Name) == "" {
+			return models.User{}, ErrMissingName
+		}
+
+		user.Name = *request.Name
+	}
+
+	if request.Email != nil {
+		if strings.TrimSpace(*request.Email) == "" {
+			return models.User{}, ErrMissingEmail
+		}
+
+		user.Email = *request.Email
+	}
+
+	return s.saveUser(ctx, user)
+}
+
+// SetUserActive activates or deactivates a user. Deactivated users can not log in and their sessions
+// end
+func (s service) SetUserActive(ctx context.Context, userID int64, active bool, requester models.Requester) (models.User, error) {
+	if !requester.Can(models.PermissionUserManage) {
+		return models.User{}, httputils.ForbiddenError
+	}
+
+	if !active && requester.UserID == userID {
+		return models.User{}, ErrCannotChangeOwnUser
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Active = active
+
+	updatedUser, err := s.saveUser(ctx, user)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if !active {
+		err = s.revokeUserSessions(ctx, userID)
+		if err != nil {
+			return models.User{}, err
+		}
+	}
+
+	return updatedUser, nil
+}
+
+// ChangeUserRole gives a user another role. Users can not change their own role, so there is always
+// somebody left who can manage users
+func (s service) ChangeUserRole(ctx context.Context, userID int64, role models.Role, requester models.Requester) (models.User, error) {
+	if !requester.Can(models.PermissionUserManage) {
+		return models.User{}, httputils.ForbiddenError
+	}
+
+	if !role.IsValid() {
+		return models.User{}, ErrInvalidUserRole
+	}
+
+	if requester.UserID == userID && role != requester.Role {
+		return models.User{}, ErrCannotChangeOwnUser
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Role = role
+
+	return s.saveUser(ctx, user)
+}
+
+// ResetPassword replaces the password of the user with the email and ends its sessions, so the ones
+// opened with the previous password can not be used anymore
+func (s service) ResetPassword(ctx context.Context, email string, password string) (models.User, error) {
+	if password == "" {
+		return models.User{}, ErrMissingPassword
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return models.User{}, httputils.NewNotFoundError("user")
+	}
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	err = s.repo.UpdatePassword(ctx, user.UserID, hashedPassword)
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return models.User{}, httputils.NewNotFoundError("user")
+	}
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	err = s.revokeUserSessions(ctx, user.UserID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Password = ""
+
+	return user, nil
+}
+
+func (s service) getUser(ctx context.Context, userID int64) (models.User, error) {
+	user, err := s.repo.GetUser(ctx, int(userID))
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return models.User{}, httputils.NewNotFoundError("user")
+	}
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s service) saveUser(ctx context.Context, user models.User) (models.User, error) {
+	updatedUser, err := s.repo.UpdateUser(ctx, user)
+	if errors.Is(err, usersRepo.ErrDuplicateField) {
+		return models.User{}, ErrDuplicateFields
+	}
+
+	if errors.Is(err, usersRepo.ErrNotFound) {
+		return models.User{}, httputils.NewNotFoundError("user")
+	}
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	updatedUser.Password = ""
+
+	return updatedUser, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	adminRequester = models.Requester{UserID: testUser.UserID, Role: models.RoleAdmin, Permissions: models.DefaultRolePermissions[models.RoleAdmin]}
+	userRequester  = models.Requester{UserID: 8, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]}
+	regularUser    = models.User{UserID: 8, Name: "Denys", Email: "denys@denys.com", Role: models.RoleRequester, Active: true}
+)
+
+func TestLoginDeactivatedUser(t *testing.T) {
+	c := require.New(t)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	c.Nil(err)
+
+	user := regularUser
+	user.Password = string(hashedPassword)
+	user.Active = false
+
+	s := New(newUsersRepoMock(user), rbacMock{})
+
+	_, err = s.Login(context.Background(), user.Email, "secret")
+	c.Equal(ErrUserDeactivated, err)
+}
+
+func TestSetUserActive(t *testing.T) {
+	c := require.New(t)
+
+	repo := newUsersRepoMock(testUser, regularUser)
+	s := New(repo, rbacMock{})
+
+	_, err := s.SetUserActive(context.Background(), testUser.UserID, false, userRequester)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.SetUserActive(context.Background(), testUser.UserID, false, adminRequester)
+	c.Equal(ErrCannotChangeOwnUser, err)
+
+	session, err := s.(service).issueTokens(context.Background(), regularUser)
+	c.Nil(err)
+
+	user, err := s.SetUserActive(context.Background(), regularUser.UserID, false, adminRequester)
+	c.Nil(err)
+	c.False(user.Active)
+
+	_, err = s.RefreshToken(context.Background(), session.RefreshToken)
+	c.Equal(ErrInvalidRefreshToken, err)
+
+	_, err = s.ValidateToken(context.Background(), session.Token)
+	c.Equal(ErrInvalidToken, err)
+
+	user, err = s.SetUserActive(context.Background(), regularUser.UserID, true, adminRequester)
+	c.Nil(err)
+	c.True(user.Active)
+
+	// reactivating the user does not bring back the sessions it had
+	_, err = s.ValidateToken(context.Background(), session.Token)
+	c.Equal(ErrInvalidToken, err)
+}
+
+func TestChangeUserRole(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newUsersRepoMock(testUser, regularUser), rbacMock{})
+
+	_, err := s.ChangeUserRole(context.Background(), regularUser.UserID, models.RoleAdmin, userRequester)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.ChangeUserRole(context.Background(), regularUser.UserID, "root", adminRequester)
+	c.Equal(ErrInvalidUserRole, err)
+
+	_, err = s.ChangeUserRole(context.Background(), testUser.UserID, models.RoleSupervisor, adminRequester)
+	c.Equal(ErrCannotChangeOwnUser, err)
+
+	user, err := s.ChangeUserRole(context.Background(), regularUser.UserID, models.RoleAgent, adminRequester)
+	c.Nil(err)
+	c.Equal(models.RoleAgent, user.Role)
+	c.Empty(user.Password)
+}
+
+func TestGetUserAuthorization(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newUsersRepoMock(testUser, regularUser), rbacMock{})
+
+	_, err := s.GetUser(context.Background(), testUser.UserID, userRequester)
+	c.Equal(httputils.ForbiddenError, err)
+
+	user, err := s.GetUser(context.Background(), regularUser.UserID, userRequester)
+	c.Nil(err)
+	c.Equal(regularUser.Email, user.Email)
+
+	_, err = s.GetUser(context.Background(), 99, adminRequester)
+	c.Equal(httputils.NewNotFoundError("user"), err)
+}
+
+func TestResetPassword(t *testing.T) {
+	c := require.New(t)
+
+	repo := newUsersRepoMock(regularUser)
+	s := New(repo, rbacMock{})
+
+	session, err := s.(service).issueTokens(context.Background(), regularUser)
+	c.Nil(err)
+
+	_, err = s.ResetPassword(context.Background(), regularUser.Email, "")
+	c.Equal(ErrMissingPassword, err)
+
+	_, err = s.ResetPassword(context.Background(), "nobody@nobody.com", "new secret")
+	c.Equal(httputils.NewNotFoundError("user"), err)
+
+	user, err := s.ResetPassword(context.Background(), regularUser.Email, "new secret")
+	c.Nil(err)
+	c.Empty(user.Password)
+
+	_, err = s.RefreshToken(context.Background(), session.RefreshToken)
+	c.Equal(ErrInvalidRefreshToken, err)
+
+	_, err = s.ValidateToken(context.Background(), session.Token)
+	c.Equal(ErrInvalidToken, err)
+
+	_, err = s.Login(context.Background(), regularUser.Email, "new secret")
+	c.Nil(err)
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// Service keeps the people viewing the same ticket in sync: who is there, who is typing and what
+// changes on the ticket
+type Service interface {
+	// Publish relays the updates and comments of a ticket to its viewers, it never blocks
+	Publish(ctx context.Context, event models.TicketEvent) error
+	// Join starts viewing a ticket. The session must be closed once the viewer leaves
+	Join(ctx context.Context, ticketID int64, requester models.Requester) (*Session, error)
+}
@@ -0,0 +1,46 @@
+package service
+
+import "github.com/syned13/ticket-support-back/internal/models"
+
+// MessageType what a message is about
+type MessageType string
+
+const (
+	// MessageSnapshot the first message of a session: the ticket and who is viewing it
+	MessageSnapshot MessageType = "snapshot"
+	// MessagePresence somebody started or stopped viewing the ticket
+	MessagePresence MessageType = "presence"
+	// MessageTyping somebody started or stopped typing a comment
+	MessageTyping MessageType = "typing"
+	// MessageTicketUpdated a field of the ticket changed
+	MessageTicketUpdated MessageType = "ticket.updated"
+	// MessageTicketCommented somebody commented on the ticket
+	MessageTicketCommented MessageType = "ticket.commented"
+)
+
+// Viewer somebody viewing a ticket
+type Viewer struct {
+	UserID int64  `json:"userID"`
+	Name   string `json:"name"`
+}
+
+// Typing tells whether a viewer is typing
+type Typing struct {
+	Viewer
+	Typing bool `json:"typing"`
+}
+
+// Message is sent to the viewers of a ticket
+type Message struct {
+	Type    MessageType         `json:"type"`
+	Ticket  *models.Ticket      `json:"ticket,omitempty"`
+	Viewers []Viewer            `json:"viewers,omitempty"`
+	Typing  *Typing             `json:"typing,omitempty"`
+	Event   *models.TicketEvent `json:"event,omitempty"`
+}
+
+// ClientMessage is sent by a viewer. Only typing indicators are expected
+type ClientMessage struct {
+	Type   MessageType `json:"type"`
+	Typing bool        `json:"typing"`
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	defaultPresenceGrace = 5 * time.Second
+	sessionBufferSize    = 32
+)
+
+var (
+	// ErrUnknownMessage unknown message type
+	ErrUnknownMessage = httputils.NewBadRequestError("unknown message type")
+)
+
+// relayedEvents the ticket events sent to the viewers and the message they are sent as
+var relayedEvents = map[models.TicketEventType]MessageType{
+	models.TicketEventUpdated:   MessageTicketUpdated,
+	models.TicketEventCommented: MessageTicketCommented,
+}
+
+type service struct {
+	tickets       ticketsService.Service
+	usersRepo     usersRepository.Repository
+	presenceGrace time.Duration
+
+	mu    *sync.Mutex
+	rooms map[int64]*room
+}
+
+// room the sessions viewing the same ticket
+type room struct {
+	sessions map[*Session]bool
+	viewers  map[int64]*viewer
+}
+
+// viewer a person in a room, who may be connected more than once
+type viewer struct {
+	Viewer
+	sessions int
+	typing   bool
+	// leaving removes the viewer once the grace period after its last session is over
+	leaving *time.Timer
+}
+
+// New returns a new collaboration service. Tickets are read, and their visibility checked, through
+// the tickets service. Viewers stay present for presenceGrace after their last session closes so
+// reconnecting does not make them leave and join again
+func New(tickets ticketsService.Service, usersRepo usersRepository.Repository, presenceGrace time.Duration) Service {
+	if presenceGrace <= 0 {
+		presenceGrace = defaultPresenceGrace
+	}
+
+	return service{
+		tickets:       tickets,
+		usersRepo:     usersRepo,
+		presenceGrace: presenceGrace,
+		mu:            &sync.Mutex{},
+		rooms:         map[int64]*room{},
+	}
+}
+
+// Session is a connection of a viewer to the room of a ticket
+type Session struct {
+	service   service
+	ticketID  int64
+	requester models.Requester
+	viewer    Viewer
+	messages  chan Message
+}
+
+// Messages returns the channel the messages for the viewer are received from. It is closed when
+// the session is closed, either by Close or because it fell behind
+func (s *Session) Messages() <-chan Message {
+	return s.messages
+}
+
+// Handle processes a message sent by the viewer
+func (s *Session) Handle(message ClientMessage) error {
+	if message.Type != MessageTyping {
+		return ErrUnknownMessage
+	}
+
+	s.service.mu.Lock()
+	defer s.service.mu.Unlock()
+
+	r, ok := s.service.rooms[s.ticketID]
+	if !ok || !r.sessions[s] {
+		return nil
+	}
+
+	v := r.viewers[s.requester.UserID]
+	if v.typing == message.Typing {
+		return nil
+	}
+
+	v.typing = message.Typing
+	r.broadcast(Message{Type: MessageTyping, Typing: &Typing{Viewer: s.viewer, Typing: message.Typing}}, s.requester.UserID)
+
+	return nil
+}
+
+// Close leaves the room, it is safe to call more than once
+func (s *Session) Close() {
+	s.service.mu.Lock()
+	defer s.service.mu.Unlock()
+
+	s.service.leave(s)
+}
+
+func (s service) Join(ctx context.Context, ticketID int64, requester models.Requester) (*Session, error) {
+	ticket, err := s.tickets.GetTicket(ctx, ticketID, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.usersRepo.GetUser(ctx, int(requester.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		service:   s,
+		ticketID:  ticketID,
+		requester: requester,
+		viewer:    Viewer{UserID: user.UserID, Name: user.Name},
+		messages:  make(chan Message, sessionBufferSize),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rooms[ticketID]
+	if !ok {
+		r = &room{sessions: map[*Session]bool{}, viewers: map[int64]*viewer{}}
+		s.rooms[ticketID] = r
+	}
+
+	r.sessions[session] = true
+
+	v, present := r.viewers[requester.UserID]
+	if !present {
+		v = &viewer{Viewer: session.viewer}
+		r.viewers[requester.UserID] = v
+	}
+
+	v.sessions++
+
+	// a reconnection within the grace period keeps the viewer present
+	if v.leaving != nil {
+		v.leaving.Stop()
+		v.leaving = nil
+	}
+
+	session.messages <- Message{Type: MessageSnapshot, Ticket: &ticket, Viewers: r.viewerList()}
+
+	if !present {
+		r.broadcast(Message{Type: MessagePresence, Viewers: r.viewerList()}, requester.UserID)
+	}
+
+	return session, nil
+}
+
+func (s service) Publish(ctx context.Context, event models.TicketEvent) error {
+	messageType, ok := relayedEvents[event.Type]
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rooms[event.Ticket.TicketID]
+	if !ok {
+		return nil
+	}
+
+	internal := event.Comment != nil && event.Comment.Internal
+	message := Message{Type: messageType, Ticket: &event.Ticket, Event: &event}
+
+	for session := range r.sessions {
+		if internal && !session.requester.Can(models.PermissionTicketInternal) {
+			continue
+		}
+
+		r.send(session, message)
+	}
+
+	return nil
+}
+
+// leave removes the session from its room. The viewer is removed after the grace period when it
+// was its last session. The lock must be held
+func (s service) leave(session *Session) {
+	r, ok := s.rooms[session.ticketID]
+	if !ok || !r.sessions[session] {
+		return
+	}
+
+	delete(r.sessions, session)
+	close(session.messages)
+
+	v := r.viewers[session.requester.UserID]
+	v.sessions--
+
+	if v.sessions > 0 {
+		return
+	}
+
+	if v.typing {
+		v.typing = false
+		r.broadcast(Message{Type: MessageTyping, Typing: &Typing{Viewer: v.Viewer, Typing: false}}, v.UserID)
+	}
+
+	v.leaving = time.AfterFunc(s.presenceGrace, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// the viewer came back meanwhile
+		if r.viewers[v.UserID] != v || v.sessions > 0 {
+			return
+		}
+
+		delete(r.viewers, v.UserID)
+		r.broadcast(Message{Type: MessagePresence, Viewers: r.viewerList()}, v.UserID)
+
+		if len(r.viewers) == 0 && s.rooms[session.ticketID] == r {
+			delete(s.rooms, session.ticketID)
+		}
+	})
+}
+
+// broadcast sends the message to every session of the room but the ones of the given user. The lock
+// must be held
+func (r *room) broadcast(message Message, exceptUserID int64) {
+	for session := range r.sessions {
+		if session.requester.UserID != exceptUserID {
+			r.send(session, message)
+		}
+	}
+}
+
+// send queues the message for the session, closing the session when it fell behind. The lock must
+// be held
+func (r *room) send(session *Session, message Message) {
+	select {
+	case session.messages <- message:
+	default:
+		session.service.leave(session)
+	}
+}
+
+// viewerList returns the viewers of the room sorted by name
+func (r *room) viewerList() []Viewer {
+	viewers := make([]Viewer, 0, len(r.viewers))
+	for _, v := range r.viewers {
+		viewers = append(viewers, v.Viewer)
+	}
+
+	sort.Slice(viewers, func(i, j int) bool {
+		if viewers[i].Name == viewers[j].Name {
+			return viewers[i].UserID < viewers[j].UserID
+		}
+
+		return viewers[i].Name < viewers[j].Name
+	})
+
+	return viewers
+}
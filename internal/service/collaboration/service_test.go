@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	ticketsService "github.com/syned13/ticket-support-back/internal/service/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	creator = models.Requester{UserID: 1, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]}
+	admin   = models.Requester{UserID: 2, Role: models.RoleAdmin, Permissions: models.DefaultRolePermissions[models.RoleAdmin]}
+)
+
+type ticketsServiceMock struct {
+	ticketsService.Service
+	ticket models.Ticket
+}
+
+func (m ticketsServiceMock) GetTicket(ctx context.Context, ticketID int64, requester models.Requester) (models.Ticket, error) {
+	if ticketID != m.ticket.TicketID {
+		return models.Ticket{}, httputils.NewNotFoundError("ticket")
+	}
+
+	if !requester.Can(models.PermissionTicketReadAll) && requester.UserID != m.ticket.CreatorID {
+		return models.Ticket{}, httputils.ForbiddenError
+	}
+
+	return m.ticket, nil
+}
+
+type usersRepositoryMock struct {
+	usersRepository.Repository
+}
+
+func (m usersRepositoryMock) GetUser(ctx context.Context, userID int) (models.User, error) {
+	return models.User{UserID: int64(userID), Name: map[int]string{1: "Ana", 2: "Bruno"}[userID]}, nil
+}
+
+func newTestService(grace time.Duration) Service {
+	tickets := ticketsServiceMock{ticket: models.Ticket{TicketID: 10, CreatorID: creator.UserID}}
+
+	return New(tickets, usersRepositoryMock{}, grace)
+}
+
+func TestJoinSharesPresenceAndTyping(t *testing.T) {
+	c := require.New(t)
+
+	s := newTestService(time.Minute)
+
+	_, err := s.Join(context.Background(), 10, models.Requester{UserID: 3, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]})
+	c.Equal(httputils.ForbiddenError, err)
+
+	creatorSession, err := s.Join(context.Background(), 10, creator)
+	c.Nil(err)
+	defer creatorSession.Close()
+
+	snapshot := <-creatorSession.Messages()
+	c.Equal(MessageSnapshot, snapshot.Type)
+	c.Equal(int64(10), snapshot.Ticket.TicketID)
+	c.Equal([]Viewer{{UserID: 1, Name: "Ana"}}, snapshot.Viewers)
+
+	adminSession, err := s.Join(context.Background(), 10, admin)
+	c.Nil(err)
+	defer adminSession.Close()
+
+	snapshot = <-adminSession.Messages()
+	c.Len(snapshot.Viewers, 2)
+
+	presence := <-creatorSession.Messages()
+	c.Equal(MessagePresence, presence.Type)
+	c.Equal([]Viewer{{UserID: 1, Name: "Ana"}, {UserID: 2, Name: "Bruno"}}, presence.Viewers)
+
+	c.Nil(adminSession.Handle(ClientMessage{Type: MessageTyping, Typing: true}))
+	c.Equal(ErrUnknownMessage, adminSession.Handle(ClientMessage{Type: MessageSnapshot}))
+
+	typing := <-creatorSession.Messages()
+	c.Equal(MessageTyping, typing.Type)
+	c.Equal(Typing{Viewer: Viewer{UserID: 2, Name: "Bruno"}, Typing: true}, *typing.Typing)
+	c.Len(adminSession.Messages(), 0)
+}
+
+func TestPublishHidesInternalComments(t *testing.T) {
+	c := require.New(t)
+
+	s := newTestService(time.Minute)
+
+	creatorSession, err := s.Join(context.Background(), 10, creator)
+	c.Nil(err)
+	defer creatorSession.Close()
+
+	adminSession, err := s.Join(context.Background(), 10, admin)
+	c.Nil(err)
+	defer adminSession.Close()
+
+	<-creatorSession.Messages()
+	<-creatorSession.Messages()
+	<-adminSession.Messages()
+
+	ticket := models.Ticket{TicketID: 10, CreatorID: creator.UserID}
+
+	note := models.NewTicketEvent(models.TicketEventCommented, ticket, admin.UserID)
+	note.Comment = &models.TicketComment{Body: "replace toner", Internal: true}
+
+	c.Nil(s.Publish(context.Background(), note))
+	c.Nil(s.Publish(context.Background(), models.NewTicketEvent(models.TicketEventUpdated, ticket, admin.UserID)))
+	c.Nil(s.Publish(context.Background(), models.NewTicketEvent(models.TicketEventAssigned, ticket, admin.UserID)))
+
+	c.Len(creatorSession.Messages(), 1)
+	c.Equal(MessageTicketUpdated, (<-creatorSession.Messages()).Type)
+	c.Len(adminSession.Messages(), 2)
+	c.Equal(MessageTicketCommented, (<-adminSession.Messages()).Type)
+}
+
+func TestReconnectWithinGraceKeepsPresence(t *testing.T) {
+	c := require.New(t)
+
+	s := newTestService(50 * time.Millisecond)
+
+	adminSession, err := s.Join(context.Background(), 10, admin)
+	c.Nil(err)
+	defer adminSession.Close()
+
+	<-adminSession.Messages()
+
+	creatorSession, err := s.Join(context.Background(), 10, creator)
+	c.Nil(err)
+
+	<-creatorSession.Messages()
+	<-adminSession.Messages()
+
+	creatorSession.Close()
+	creatorSession.Close()
+
+	_, ok := <-creatorSession.Messages()
+	c.False(ok)
+
+	creatorSession, err = s.Join(context.Background(), 10, creator)
+	c.Nil(err)
+
+	time.Sleep(100 * time.Millisecond)
+	c.Len(adminSession.Messages(), 0)
+
+	creatorSession.Close()
+
+	select {
+	case presence := <-adminSession.Messages():
+		c.Equal(MessagePresence, presence.Type)
+		c.Equal([]Viewer{{UserID: 2, Name: "Bruno"}}, presence.Viewers)
+	case <-time.After(time.Second):
+		c.Fail("the viewer did not leave")
+	}
+}
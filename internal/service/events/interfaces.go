@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// Service fans the ticket events out in process to the people allowed to see them
+type Service interface {
+	// Publish hands the event to every subscription that can see it, it never blocks
+	Publish(ctx context.Context, event models.TicketEvent) error
+	// Subscribe returns a subscription to the events visible to the requester. It must be closed
+	// once it is no longer read
+	Subscribe(requester models.Requester) *Subscription
+}
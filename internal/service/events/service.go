@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+const (
+	defaultBufferSize = 16
+)
+
+// streamedEventTypes the events pushed to the subscriptions
+var streamedEventTypes = map[models.TicketEventType]bool{
+	models.TicketEventCreated:   true,
+	models.TicketEventUpdated:   true,
+	models.TicketEventCommented: true,
+}
+
+type hub struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]bool
+	bufferSize    int
+}
+
+// New returns an in-process pub/sub hub. Every subscription buffers up to bufferSize events, the ones
+// not read fast enough are closed instead of silently missing events
+func New(bufferSize int) Service {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	return &hub{
+		subscriptions: map[*Subscription]bool{},
+		bufferSize:    bufferSize,
+	}
+}
+
+// Subscription receives the events visible to a requester
+type Subscription struct {
+	requester models.Requester
+	events    chan models.TicketEvent
+	hub       *hub
+}
+
+// Events returns the channel the events are received from. It is closed when the subscription is
+// closed, either by Close or because it fell behind
+func (s *Subscription) Events() <-chan models.TicketEvent {
+	return s.events
+}
+
+// Close stops the subscription, it is safe to call more than once
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
+
+func (h *hub) Subscribe(requester models.Requester) *Subscription {
+	subscription := &Subscription{
+		requester: requester,
+		events:    make(chan models.TicketEvent, h.bufferSize),
+		hub:       h,
+	}
+
+	h.mu.Lock()
+	h.subscriptions[subscription] = true
+	h.mu.Unlock()
+
+	return subscription
+}
+
+func (h *hub) Publish(ctx context.Context, event models.TicketEvent) error {
+	if !streamedEventTypes[event.Type] {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscriptions {
+		if !canSee(subscription.requester, event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			h.remove(subscription)
+		}
+	}
+
+	return nil
+}
+
+// remove closes the subscription unless it is already closed. The lock must be held
+func (h *hub) remove(subscription *Subscription) {
+	if !h.subscriptions[subscription] {
+		return
+	}
+
+	delete(h.subscriptions, subscription)
+	close(subscription.events)
+}
+
+// canSee returns whether the requester is allowed to know about the event: it has to be allowed to see
+// the ticket and, for internal notes, to read them
+func canSee(requester models.Requester, event models.TicketEvent) bool {
+	if !requester.Can(models.PermissionTicketReadAll) && event.Ticket.CreatorID != requester.UserID {
+		return false
+	}
+
+	return event.Comment == nil || !event.Comment.Internal || requester.Can(models.PermissionTicketInternal)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+var (
+	creator  = models.Requester{UserID: 1, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]}
+	stranger = models.Requester{UserID: 2, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]}
+	admin    = models.Requester{UserID: 3, Role: models.RoleAdmin, Permissions: models.DefaultRolePermissions[models.RoleAdmin]}
+)
+
+func TestPublishFollowsVisibility(t *testing.T) {
+	c := require.New(t)
+
+	s := New(0)
+
+	creatorSubscription := s.Subscribe(creator)
+	defer creatorSubscription.Close()
+
+	strangerSubscription := s.Subscribe(stranger)
+	defer strangerSubscription.Close()
+
+	adminSubscription := s.Subscribe(admin)
+	defer adminSubscription.Close()
+
+	ticket := models.Ticket{TicketID: 10, CreatorID: creator.UserID}
+
+	note := models.NewTicketEvent(models.TicketEventCommented, ticket, admin.UserID)
+	note.Comment = &models.TicketComment{Body: "replace toner", Internal: true}
+
+	c.Nil(s.Publish(context.Background(), models.NewTicketEvent(models.TicketEventCreated, ticket, creator.UserID)))
+	c.Nil(s.Publish(context.Background(), models.NewTicketEvent(models.TicketEventAssigned, ticket, admin.UserID)))
+	c.Nil(s.Publish(context.Background(), note))
+
+	c.Len(creatorSubscription.Events(), 1)
+	c.Equal(models.TicketEventCreated, (<-creatorSubscription.Events()).Type)
+	c.Len(strangerSubscription.Events(), 0)
+	c.Len(adminSubscription.Events(), 2)
+}
+
+func TestSlowSubscriptionsAreClosed(t *testing.T) {
+	c := require.New(t)
+
+	s := New(1)
+
+	subscription := s.Subscribe(admin)
+	event := models.NewTicketEvent(models.TicketEventUpdated, models.Ticket{TicketID: 10}, admin.UserID)
+
+	c.Nil(s.Publish(context.Background(), event))
+	c.Nil(s.Publish(context.Background(), event))
+
+	_, ok := <-subscription.Events()
+	c.True(ok)
+
+	_, ok = <-subscription.Events()
+	c.False(ok)
+
+	subscription.Close()
+}
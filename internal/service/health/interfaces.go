@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+)
+
+// Service tells whether the API is able to serve requests and which build is running
+type Service interface {
+	// Ready runs the readiness checks, it is ready when every one of them passed
+	Ready(ctx context.Context) Readiness
+	// Version returns the build info of the running binary
+	Version() BuildInfo
+}
+
+// Pinger checks the database is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SchemaVersioner knows the version the schema of the database is at and the one it should be at
+type SchemaVersioner interface {
+	Version(ctx context.Context) (int64, error)
+	Latest() int64
+}
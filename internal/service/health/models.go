@@ -0,0 +1,21 @@
+package service
+
+// Check the result of a readiness check
+type Check struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Readiness whether the API is ready along with the checks telling so
+type Readiness struct {
+	Ready  bool    `json:"ready"`
+	Checks []Check `json:"checks"`
+}
+
+// BuildInfo identifies the running build
+type BuildInfo struct {
+	GitSHA      string `json:"gitSHA"`
+	BuildTime   string `json:"buildTime"`
+	Environment string `json:"environment"`
+}
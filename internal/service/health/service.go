@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	checkTimeout = 2 * time.Second
+
+	checkDatabase   = "database"
+	checkMigrations = "migrations"
+)
+
+type service struct {
+	database  Pinger
+	schema    SchemaVersioner
+	buildInfo BuildInfo
+	logger    *slog.Logger
+}
+
+// New returns a new health service. The default logger is used when logger is nil
+func New(database Pinger, schema SchemaVersioner, buildInfo BuildInfo, logger *slog.Logger) Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return service{
+		database:  database,
+		schema:    schema,
+		buildInfo: buildInfo,
+		logger:    logger,
+	}
+}
+
+// Ready checks the database answers and has every migration of this build applied. A schema newer
+// than the build is fine, as it is what older instances see while a new version is rolled out. Errors
+// are logged but not reported, so the checks do not leak details of the database
+func (s service) Ready(ctx context.Context) Readiness {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	readiness := Readiness{Ready: true}
+
+	err := s.database.Ping(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "database_check_failed", "error", err)
+		readiness.add(Check{Name: checkDatabase, Error: "unreachable"})
+		readiness.add(Check{Name: checkMigrations, Error: "skipped"})
+
+		return readiness
+	}
+
+	readiness.add(Check{Name: checkDatabase, OK: true})
+
+	version, err := s.schema.Version(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "migrations_check_failed", "error", err)
+		readiness.add(Check{Name: checkMigrations, Error: "unknown schema version"})
+
+		return readiness
+	}
+
+	if version < s.schema.Latest() {
+		readiness.add(Check{Name: checkMigrations, Error: fmt.Sprintf("schema at version %d, expected %d", version, s.schema.Latest())})
+		return readiness
+	}
+
+	readiness.add(Check{Name: checkMigrations, OK: true})
+
+	return readiness
+}
+
+// Version returns the build info of the running binary
+func (s service) Version() BuildInfo {
+	return s.buildInfo
+}
+
+func (r *Readiness) add(check Check) {
+	r.Checks = append(r.Checks, check)
+	r.Ready = r.Ready && check.OK
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pingerMock struct {
+	err error
+}
+
+func (m pingerMock) Ping(ctx context.Context) error {
+	return m.err
+}
+
+type schemaMock struct {
+	version int64
+	latest  int64
+	err     error
+}
+
+func (m schemaMock) Version(ctx context.Context) (int64, error) {
+	return m.version, m.err
+}
+
+func (m schemaMock) Latest() int64 {
+	return m.latest
+}
+
+func TestReady(t *testing.T) {
+	c := require.New(t)
+
+	s := New(pingerMock{}, schemaMock{version: 2, latest: 2}, BuildInfo{}, nil)
+
+	readiness := s.Ready(context.Background())
+	c.True(readiness.Ready)
+	c.Equal([]Check{{Name: checkDatabase, OK: true}, {Name: checkMigrations, OK: true}}, readiness.Checks)
+
+	s = New(pingerMock{}, schemaMock{version: 3, latest: 2}, BuildInfo{}, nil)
+	c.True(s.Ready(context.Background()).Ready)
+}
+
+func TestReadyNotReady(t *testing.T) {
+	c := require.New(t)
+
+	s := New(pingerMock{err: errors.New("connection refused")}, schemaMock{latest: 2}, BuildInfo{}, nil)
+
+	readiness := s.Ready(context.Background())
+	c.False(readiness.Ready)
+	c.Equal(Check{Name: checkDatabase, Error: "unreachable"}, readiness.Checks[0])
+
+	s = New(pingerMock{}, schemaMock{version: 1, latest: 2}, BuildInfo{}, nil)
+
+	readiness = s.Ready(context.Background())
+	c.False(readiness.Ready)
+	c.Equal(Check{Name: checkMigrations, Error: "schema at version 1, expected 2"}, readiness.Checks[1])
+
+	s = New(pingerMock{}, schemaMock{latest: 2, err: errors.New("timeout")}, BuildInfo{}, nil)
+	c.False(s.Ready(context.Background()).Ready)
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// Service defines the notifications related methods
+type Service interface {
+	// Publish saves the emails to send the people involved in the event, Run sends them. It fails
+	// when they could not be saved
+	Publish(ctx context.Context, event models.TicketEvent) error
+	// Run sends the saved emails, retrying the failed ones, until the context is done
+	Run(ctx context.Context)
+	GetPreferences(ctx context.Context, requester models.Requester) (Preferences, error)
+	UpdatePreferences(ctx context.Context, preferences Preferences, requester models.Requester) (Preferences, error)
+}
@@ -0,0 +1,6 @@
+package service
+
+import "github.com/syned13/ticket-support-back/internal/models"
+
+// Preferences tells, for every event type, whether the user gets an email about it
+type Preferences map[models.TicketEventType]bool
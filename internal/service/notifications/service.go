@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	notificationsRepository "github.com/syned13/ticket-support-back/internal/repositories/notifications"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/email"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	pollInterval = time.Second
+	maxAttempts  = 5
+	// claimDuration how long a claimed job is skipped, it is taken again after it when it was never
+	// marked as sent or failed
+	claimDuration  = time.Minute
+	initialBackoff = 30 * time.Second
+	maxBackoff     = 30 * time.Minute
+)
+
+var (
+	// ErrInvalidEventType invalid event type
+	ErrInvalidEventType = httputils.NewBadRequestError("invalid event type")
+
+	errMissingTemplate = errors.New("no email template for the event")
+)
+
+type service struct {
+	repo         notificationsRepository.Repository
+	usersRepo    usersRepository.Repository
+	sender       email.Sender
+	pollInterval time.Duration
+	backoff      func(attempt int) time.Duration
+	logger       *slog.Logger
+}
+
+// New returns a new notifications service. Without a sender events are ignored, which is how
+// notifications are disabled. The default logger is used when logger is nil
+func New(repo notificationsRepository.Repository, usersRepo usersRepository.Repository, sender email.Sender, logger *slog.Logger) Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return service{
+		repo:         repo,
+		usersRepo:    usersRepo,
+		sender:       sender,
+		pollInterval: pollInterval,
+		backoff:      exponentialBackoff,
+		logger:       logger,
+	}
+}
+
+// exponentialBackoff doubles the wait after every failed attempt
+func exponentialBackoff(attempt int) time.Duration {
+	backoff := initialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
+
+// Publish saves a job to email everybody involved in the event but the one who caused it. Publishing
+// an event again saves no job for the people it was already saved for
+func (s service) Publish(ctx context.Context, event models.TicketEvent) error {
+	if s.sender == nil {
+		return nil
+	}
+
+	if _, ok := emailTemplates[event.Type]; !ok {
+		return nil
+	}
+
+	userIDs := recipients(event)
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	return s.repo.QueueNotifications(ctx, event, userIDs)
+}
+
+func (s service) Run(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if s.sendNext(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// sendNext sends the email of the oldest due job, if any, and returns whether there was one
+func (s service) sendNext(ctx context.Context) bool {
+	now := time.Now().UTC()
+
+	job, err := s.repo.ClaimDueNotification(ctx, now, now.Add(claimDuration))
+	if errors.Is(err, notificationsRepository.ErrNotFound) {
+		return false
+	}
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "claiming_notification_failed", "error", err)
+		return false
+	}
+
+	// a job in flight is finished and marked even when the context is done
+	ctx = context.WithoutCancel(ctx)
+
+	tmpl, ok := emailTemplates[job.Event.Type]
+	if !ok {
+		err = errMissingTemplate
+	} else {
+		err = s.notifyUser(ctx, job.UserID, job.Event, tmpl)
+	}
+
+	if err == nil {
+		err = s.repo.MarkNotificationSent(ctx, job.JobID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "marking_notification_failed", "job_id", job.JobID, "error", err)
+		}
+
+		return true
+	}
+
+	s.logger.ErrorContext(ctx, "sending_notification_failed", "event_type", job.Event.Type, "ticket_id", job.Event.Ticket.TicketID, "user_id", job.UserID, "error", err)
+
+	var retryAt *time.Time
+	if job.Attempts+1 < maxAttempts && !errors.Is(err, errMissingTemplate) {
+		next := time.Now().UTC().Add(s.backoff(job.Attempts + 1))
+		retryAt = &next
+	}
+
+	err = s.repo.MarkNotificationFailed(ctx, job.JobID, err.Error(), retryAt)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "marking_notification_failed", "job_id", job.JobID, "error", err)
+	}
+
+	return true
+}
+
+func (s service) notifyUser(ctx context.Context, userID int64, event models.TicketEvent, tmpl emailTemplate) error {
+	optOuts, err := s.repo.GetOptOuts(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, optOut := range optOuts {
+		if optOut == event.Type {
+			return nil
+		}
+	}
+
+	user, err := s.usersRepo.GetUser(ctx, int(userID))
+	if errors.Is(err, usersRepository.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !user.Active {
+		return nil
+	}
+
+	subject, body, err := tmpl.render(templateData{Recipient: user, Event: event})
+	if err != nil {
+		return err
+	}
+
+	return s.sender.Send(ctx, email.Message{To: []string{user.Email}, Subject: subject, Body: body})
+}
+
+// recipients returns who should hear about the event: the creator when something happens to
+// their ticket and the owner when the ticket is theirs to handle
+func recipients(event models.TicketEvent) []int64 {
+	candidates := []int64{}
+	ticket := event.Ticket
+
+	switch event.Type {
+	case models.TicketEventCreated:
+		candidates = append(candidates, ticket.CreatorID)
+		if ticket.OwnerID != nil {
+			candidates = append(candidates, *ticket.OwnerID)
+		}
+	case models.TicketEventAssigned:
+		if ticket.OwnerID != nil {
+			candidates = append(candidates, *ticket.OwnerID)
+		}
+	case models.TicketEventStatusChanged:
+		candidates = append(candidates, ticket.CreatorID)
+	case models.TicketEventCommented:
+		// internal notes are not meant for the creator
+		if event.Comment == nil || !event.Comment.Internal {
+			candidates = append(candidates, ticket.CreatorID)
+		}
+
+		if ticket.OwnerID != nil {
+			candidates = append(candidates, *ticket.OwnerID)
+		}
+	}
+
+	seen := map[int64]bool{event.ActorID: true}
+	userIDs := []int64{}
+
+	for _, userID := range candidates {
+		if seen[userID] {
+			continue
+		}
+
+		seen[userID] = true
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs
+}
+
+// GetPreferences returns which events the requester gets emails about
+func (s service) GetPreferences(ctx context.Context, requester models.Requester) (Preferences, error) {
+	optOuts, err := s.repo.GetOptOuts(ctx, requester.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := Preferences{}
+	for _, eventType := range notifiedEventTypes {
+		preferences[eventType] = true
+	}
+
+	for _, eventType := range optOuts {
+		if _, ok := preferences[eventType]; ok {
+			preferences[eventType] = false
+		}
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences changes which events the requester gets emails about. Event types not
+// included keep their current preference
+func (s service) UpdatePreferences(ctx context.Context, preferences Preferences, requester models.Requester) (Preferences, error) {
+	for eventType := range preferences {
+		if _, ok := emailTemplates[eventType]; !ok {
+			return nil, ErrInvalidEventType
+		}
+	}
+
+	current, err := s.GetPreferences(ctx, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	optOuts := []models.TicketEventType{}
+
+	for _, eventType := range notifiedEventTypes {
+		enabled, ok := preferences[eventType]
+		if !ok {
+			enabled = current[eventType]
+		}
+
+		current[eventType] = enabled
+
+		if !enabled {
+			optOuts = append(optOuts, eventType)
+		}
+	}
+
+	err = s.repo.SetOptOuts(ctx, requester.UserID, optOuts)
+	if err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	notificationsRepository "github.com/syned13/ticket-support-back/internal/repositories/notifications"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/email"
+)
+
+const (
+	creatorID int64 = 1
+	ownerID   int64 = 2
+)
+
+// notificationsRepoMock is an in-memory notifications repository
+type notificationsRepoMock struct {
+	optOuts map[int64][]models.TicketEventType
+	jobs    []jobRow
+}
+
+type jobRow struct {
+	job     models.NotificationJob
+	retryAt *time.Time
+	sent    bool
+}
+
+func newNotificationsRepoMock() *notificationsRepoMock {
+	return &notificationsRepoMock{optOuts: map[int64][]models.TicketEventType{}}
+}
+
+func (m *notificationsRepoMock) GetOptOuts(ctx context.Context, userID int64) ([]models.TicketEventType, error) {
+	return m.optOuts[userID], nil
+}
+
+func (m *notificationsRepoMock) SetOptOuts(ctx context.Context, userID int64, eventTypes []models.TicketEventType) error {
+	m.optOuts[userID] = eventTypes
+	return nil
+}
+
+func (m *notificationsRepoMock) QueueNotifications(ctx context.Context, event models.TicketEvent, userIDs []int64) error {
+	now := time.Now()
+
+	for _, userID := range userIDs {
+		queued := false
+		for _, row := range m.jobs {
+			queued = queued || (row.job.Event.EventID == event.EventID && row.job.UserID == userID)
+		}
+
+		if !queued {
+			job := models.NotificationJob{JobID: int64(len(m.jobs) + 1), UserID: userID, Event: event}
+			m.jobs = append(m.jobs, jobRow{job: job, retryAt: &now})
+		}
+	}
+
+	return nil
+}
+
+func (m *notificationsRepoMock) ClaimDueNotification(ctx context.Context, now time.Time, claimedUntil time.Time) (models.NotificationJob, error) {
+	for i, row := range m.jobs {
+		if row.retryAt != nil && !row.retryAt.After(now) {
+			m.jobs[i].retryAt = &claimedUntil
+			return row.job, nil
+		}
+	}
+
+	return models.NotificationJob{}, notificationsRepository.ErrNotFound
+}
+
+func (m *notificationsRepoMock) MarkNotificationSent(ctx context.Context, jobID int64) error {
+	m.jobs[jobID-1].sent = true
+	m.jobs[jobID-1].retryAt = nil
+	m.jobs[jobID-1].job.Attempts++
+
+	return nil
+}
+
+func (m *notificationsRepoMock) MarkNotificationFailed(ctx context.Context, jobID int64, reason string, retryAt *time.Time) error {
+	m.jobs[jobID-1].retryAt = retryAt
+	m.jobs[jobID-1].job.Attempts++
+
+	return nil
+}
+
+var _ notificationsRepository.Repository = &notificationsRepoMock{}
+
+// usersRepoMock is an in-memory users repository. Methods not overridden panic when called
+type usersRepoMock struct {
+	usersRepository.Repository
+}
+
+func (m usersRepoMock) GetUser(ctx context.Context, userID int) (models.User, error) {
+	switch int64(userID) {
+	case creatorID:
+		return models.User{UserID: creatorID, Name: "Creator", Email: "creator@tickets.com", Active: true}, nil
+	case ownerID:
+		return models.User{UserID: ownerID, Name: "Owner", Email: "owner@tickets.com", Active: true}, nil
+	default:
+		return models.User{}, usersRepository.ErrNotFound
+	}
+}
+
+// senderMock keeps the sent emails instead of sending them, it fails while err is set
+type senderMock struct {
+	sent []email.Message
+	err  error
+}
+
+func (m *senderMock) Send(ctx context.Context, message email.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+// sendDue sends the emails of every due job
+func sendDue(s service) {
+	for s.sendNext(context.Background()) {
+	}
+}
+
+func TestNotifyStatusChange(t *testing.T) {
+	c := require.New(t)
+
+	sender := &senderMock{}
+	s := New(newNotificationsRepoMock(), usersRepoMock{}, sender, nil).(service)
+
+	owner := ownerID
+	event := models.NewTicketEvent(models.TicketEventStatusChanged, models.Ticket{
+		TicketID:  10,
+		Title:     "printer",
+		CreatorID: creatorID,
+		OwnerID:   &owner,
+		Status:    models.TicketStatusResolved,
+	}, ownerID)
+	event.PreviousStatus = models.TicketTypeInProgress
+
+	c.Nil(s.Publish(context.Background(), event))
+	sendDue(s)
+
+	c.Len(sender.sent, 1)
+	c.Equal([]string{"creator@tickets.com"}, sender.sent[0].To)
+	c.Equal("[Ticket #10] Now resolved: printer", sender.sent[0].Subject)
+	c.Contains(sender.sent[0].Body, `Ticket #10 "printer" went from in_progress to resolved.`)
+}
+
+func TestNotifySkipsOptedOutUsers(t *testing.T) {
+	c := require.New(t)
+
+	sender := &senderMock{}
+	s := New(newNotificationsRepoMock(), usersRepoMock{}, sender, nil).(service)
+
+	_, err := s.UpdatePreferences(context.Background(), Preferences{models.TicketEventCommented: false}, models.Requester{UserID: creatorID})
+	c.Nil(err)
+
+	owner := ownerID
+	event := models.NewTicketEvent(models.TicketEventCommented, models.Ticket{TicketID: 10, CreatorID: creatorID, OwnerID: &owner}, 3)
+	event.Comment = &models.TicketComment{Body: "any news?"}
+
+	c.Nil(s.Publish(context.Background(), event))
+	sendDue(s)
+
+	c.Len(sender.sent, 1)
+	c.Equal([]string{"owner@tickets.com"}, sender.sent[0].To)
+}
+
+func TestNotificationsAreSentOnceAndRetried(t *testing.T) {
+	c := require.New(t)
+
+	sender := &senderMock{err: errors.New("smtp is down")}
+	repo := newNotificationsRepoMock()
+	s := New(repo, usersRepoMock{}, sender, nil).(service)
+	s.backoff = func(attempt int) time.Duration { return 0 }
+
+	event := models.NewTicketEvent(models.TicketEventStatusChanged, models.Ticket{TicketID: 10, CreatorID: creatorID}, ownerID)
+	event.EventID = 42
+
+	// relaying the event again does not email anybody twice
+	c.Nil(s.Publish(context.Background(), event))
+	c.Nil(s.Publish(context.Background(), event))
+	c.Len(repo.jobs, 1)
+
+	c.True(s.sendNext(context.Background()))
+	c.Equal(1, repo.jobs[0].job.Attempts)
+	c.False(repo.jobs[0].sent)
+	c.NotNil(repo.jobs[0].retryAt)
+
+	sender.err = nil
+	sendDue(s)
+	c.True(repo.jobs[0].sent)
+	c.Len(sender.sent, 1)
+
+	// jobs failing every attempt are given up on
+	sender.err = errors.New("smtp is down")
+	event.EventID = 43
+	c.Nil(s.Publish(context.Background(), event))
+
+	sendDue(s)
+	c.Equal(maxAttempts, repo.jobs[1].job.Attempts)
+	c.Nil(repo.jobs[1].retryAt)
+	c.False(repo.jobs[1].sent)
+}
+
+func TestRecipients(t *testing.T) {
+	owner := ownerID
+
+	tests := []struct {
+		name       string
+		event      models.TicketEvent
+		recipients []int64
+	}{
+		{"owner hears about new tickets", models.NewTicketEvent(models.TicketEventCreated, models.Ticket{CreatorID: creatorID, OwnerID: &owner}, creatorID), []int64{ownerID}},
+		{"owner hears about assignments", models.NewTicketEvent(models.TicketEventAssigned, models.Ticket{CreatorID: creatorID, OwnerID: &owner}, 3), []int64{ownerID}},
+		{"actor is never notified", models.NewTicketEvent(models.TicketEventStatusChanged, models.Ticket{CreatorID: creatorID}, creatorID), []int64{}},
+		{"internal notes skip the creator", models.TicketEvent{Type: models.TicketEventCommented, Ticket: models.Ticket{CreatorID: creatorID, OwnerID: &owner}, ActorID: 3, Comment: &models.TicketComment{Internal: true}}, []int64{ownerID}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.recipients, recipients(test.event))
+		})
+	}
+}
+
+func TestUpdatePreferencesRejectsUnknownEvents(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newNotificationsRepoMock(), usersRepoMock{}, nil, nil)
+
+	_, err := s.UpdatePreferences(context.Background(), Preferences{"ticket.deleted": false}, models.Requester{UserID: creatorID})
+	c.Equal(ErrInvalidEventType, err)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// emailTemplate the subject and body of the email sent for an event type
+type emailTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// templateData what the templates are rendered with
+type templateData struct {
+	Recipient models.User
+	Event     models.TicketEvent
+}
+
+// notifiedEventTypes the events users can get emails about
+var notifiedEventTypes = []models.TicketEventType{
+	models.TicketEventCreated,
+	models.TicketEventAssigned,
+	models.TicketEventStatusChanged,
+	models.TicketEventCommented,
+}
+
+var emailTemplates = map[models.TicketEventType]emailTemplate{
+	models.TicketEventCreated: newEmailTemplate(
+		`[Ticket #{{.Event.Ticket.TicketID}}] {{.Event.Ticket.Title}}`,
+		`Hi {{.Recipient.Name}},
+
+Ticket #{{.Event.Ticket.TicketID}} "{{.Event.Ticket.Title}}" was created.
+
+{{.Event.Ticket.Description}}
+
+Type: {{.Event.Ticket.Type}}
+Severity: {{.Event.Ticket.Severity}}
+Priority: {{.Event.Ticket.Priority}}
+`),
+	models.TicketEventAssigned: newEmailTemplate(
+		`[Ticket #{{.Event.Ticket.TicketID}}] Assigned to you: {{.Event.Ticket.Title}}`,
+		`Hi {{.Recipient.Name}},
+
+Ticket #{{.Event.Ticket.TicketID}} "{{.Event.Ticket.Title}}" was assigned to you.
+{{if .Event.Ticket.DueAt}}
+It is due by {{.Event.Ticket.DueAt.Format "2006-01-02 15:04 MST"}}.
+{{end}}`),
+	models.TicketEventStatusChanged: newEmailTemplate(
+		`[Ticket #{{.Event.Ticket.TicketID}}] Now {{.Event.Ticket.Status}}: {{.Event.Ticket.Title}}`,
+		`Hi {{.Recipient.Name}},
+
+Ticket #{{.Event.Ticket.TicketID}} "{{.Event.Ticket.Title}}" went from {{.Event.PreviousStatus}} to {{.Event.Ticket.Status}}.
+`),
+	models.TicketEventCommented: newEmailTemplate(
+		`[Ticket #{{.Event.Ticket.TicketID}}] New comment: {{.Event.Ticket.Title}}`,
+		`Hi {{.Recipient.Name}},
+
+There is a new comment on ticket #{{.Event.Ticket.TicketID}} "{{.Event.Ticket.Title}}":
+
+{{.Event.Comment.Body}}
+`),
+}
+
+func newEmailTemplate(subject, body string) emailTemplate {
+	return emailTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+func (t emailTemplate) render(data templateData) (string, string, error) {
+	var subject, body bytes.Buffer
+
+	err := t.subject.Execute(&subject, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = t.body.Execute(&body, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	return subject.String(), body.String(), nil
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// Service defines the role-based access control related methods
+type Service interface {
+	GetPermissions(ctx context.Context, role models.Role) (models.Permissions, error)
+	GetRoles(ctx context.Context, requester models.Requester) ([]models.RoleDefinition, error)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	rolesRepository "github.com/syned13/ticket-support-back/internal/repositories/roles"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	defaultCacheTTL = time.Minute
+)
+
+type service struct {
+	repo     rolesRepository.Repository
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	roles    []models.RoleDefinition
+	loadedAt time.Time
+}
+
+// New returns a new rbac service. The roles are read from the repository at most once every
+// cacheTTL, so changes to the permissions of a role take up to cacheTTL to apply
+func New(repo rolesRepository.Repository, cacheTTL time.Duration) Service {
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+
+	return &service{
+		repo:     repo,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// GetPermissions returns the permissions granted by the role. Unknown roles grant nothing
+func (s *service) GetPermissions(ctx context.Context, role models.Role) (models.Permissions, error) {
+	roles, err := s.listRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, definition := range roles {
+		if definition.Role == role {
+			return definition.Permissions, nil
+		}
+	}
+
+	return models.Permissions{}, nil
+}
+
+// GetRoles returns every role with the permissions it grants. Only the ones allowed to see the users
+// can list the roles
+func (s *service) GetRoles(ctx context.Context, requester models.Requester) ([]models.RoleDefinition, error) {
+	if !requester.Can(models.PermissionUserRead) {
+		return nil, httputils.ForbiddenError
+	}
+
+	return s.listRoles(ctx)
+}
+
+func (s *service) listRoles(ctx context.Context) ([]models.RoleDefinition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.roles != nil && time.Since(s.loadedAt) < s.cacheTTL {
+		return s.roles, nil
+	}
+
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.roles = roles
+	s.loadedAt = time.Now()
+
+	return roles, nil
+}
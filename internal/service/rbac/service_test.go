@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	rolesRepository "github.com/syned13/ticket-support-back/internal/repositories/roles"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+type rolesRepoMock struct {
+	rolesRepository.Repository
+	roles []models.RoleDefinition
+	calls int
+}
+
+func (m *rolesRepoMock) ListRoles(ctx context.Context) ([]models.RoleDefinition, error) {
+	m.calls++
+	return m.roles, nil
+}
+
+func TestGetPermissionsIsCached(t *testing.T) {
+	c := require.New(t)
+
+	repo := &rolesRepoMock{roles: []models.RoleDefinition{
+		{Role: models.RoleAgent, Permissions: models.Permissions{models.PermissionTicketReadAll}},
+	}}
+
+	s := New(repo, time.Hour)
+
+	permissions, err := s.GetPermissions(context.Background(), models.RoleAgent)
+	c.Nil(err)
+	c.Equal(models.Permissions{models.PermissionTicketReadAll}, permissions)
+
+	permissions, err = s.GetPermissions(context.Background(), "root")
+	c.Nil(err)
+	c.Empty(permissions)
+
+	c.Equal(1, repo.calls)
+
+	_, err = s.GetRoles(context.Background(), models.Requester{UserID: 1, Role: models.RoleRequester})
+	c.Equal(httputils.ForbiddenError, err)
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// Service defines the teams related methods
+type Service interface {
+	CreateTeam(ctx context.Context, request CreateTeamRequest, requester models.Requester) (models.Team, error)
+	GetTeams(ctx context.Context, requester models.Requester) ([]models.Team, error)
+	GetTeam(ctx context.Context, teamID int64, requester models.Requester) (models.Team, error)
+	DeleteTeam(ctx context.Context, teamID int64, requester models.Requester) error
+	AddMember(ctx context.Context, teamID int64, userID int64, requester models.Requester) (models.Team, error)
+	RemoveMember(ctx context.Context, teamID int64, userID int64, requester models.Requester) (models.Team, error)
+	SetTicketTypes(ctx context.Context, teamID int64, ticketTypes []models.TicketType, requester models.Requester) (models.Team, error)
+}
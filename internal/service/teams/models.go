@@ -0,0 +1,10 @@
+package service
+
+import "github.com/syned13/ticket-support-back/internal/models"
+
+// CreateTeamRequest the fields of a new team. The ticket types are taken from the teams they were
+// routed to
+type CreateTeamRequest struct {
+	Name        string              `json:"name"`
+	TicketTypes []models.TicketType `json:"ticketTypes"`
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrMissingName missing name
+	ErrMissingName = httputils.NewBadRequestError("missing name")
+	// ErrDuplicateName duplicate name
+	ErrDuplicateName = httputils.NewBadRequestError("there is already a team with that name")
+	// ErrInvalidTicketType invalid ticket type
+	ErrInvalidTicketType = httputils.NewBadRequestError("invalid ticket type")
+	// ErrInvalidUserID invalid user id
+	ErrInvalidUserID = httputils.NewBadRequestError("invalid user id")
+	// ErrMemberNotAgent member not agent
+	ErrMemberNotAgent = httputils.NewBadRequestError("only users who work on tickets can be team members")
+)
+
+type service struct {
+	teamsRepo teamsRepository.Repository
+	usersRepo usersRepository.Repository
+}
+
+// New returns a new teams service
+func New(teamsRepo teamsRepository.Repository, usersRepo usersRepository.Repository) Service {
+	return service{
+		teamsRepo: teamsRepo,
+		usersRepo: usersRepo,
+	}
+}
+
+// CreateTeam creates a team. Only the ones allowed to manage teams can
+func (s service) CreateTeam(ctx context.Context, request CreateTeamRequest, requester models.Requester) (models.Team, error) {
+	if !requester.Can(models.PermissionTeamManage) {
+		return models.Team{}, httputils.ForbiddenError
+	}
+
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		return models.Team{}, ErrMissingName
+	}
+
+	err := validateTicketTypes(request.TicketTypes)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	team, err := s.teamsRepo.CreateTeam(ctx, models.Team{Name: request.Name, TicketTypes: request.TicketTypes})
+	if errors.Is(err, teamsRepository.ErrDuplicateName) {
+		return models.Team{}, ErrDuplicateName
+	}
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	return team, nil
+}
+
+// GetTeams returns every team. Only the ones allowed to read every ticket can list them
+func (s service) GetTeams(ctx context.Context, requester models.Requester) ([]models.Team, error) {
+	if !requester.Can(models.PermissionTicketReadAll) {
+		return nil, httputils.ForbiddenError
+	}
+
+	return s.teamsRepo.ListTeams(ctx)
+}
+
+// GetTeam returns a team. Only the ones allowed to read every ticket can get it
+func (s service) GetTeam(ctx context.Context, teamID int64, requester models.Requester) (models.Team, error) {
+	if !requester.Can(models.PermissionTicketReadAll) {
+		return models.Team{}, httputils.ForbiddenError
+	}
+
+	return s.getTeam(ctx, teamID)
+}
+
+// DeleteTeam deletes a team, its tickets are left without team
+func (s service) DeleteTeam(ctx context.Context, teamID int64, requester models.Requester) error {
+	if !requester.Can(models.PermissionTeamManage) {
+		return httputils.ForbiddenError
+	}
+
+	err := s.teamsRepo.DeleteTeam(ctx, teamID)
+	if errors.Is(err, teamsRepository.ErrNotFound) {
+		return httputils.NewNotFoundError("team")
+	}
+
+	return err
+}
+
+// AddMember adds a user whose role allows it to work on tickets to the team
+func (s service) AddMember(ctx context.Context, teamID int64, userID int64, requester models.Requester) (models.Team, error) {
+	if !requester.Can(models.PermissionTeamManage) {
+		return models.Team{}, httputils.ForbiddenError
+	}
+
+	_, err := s.getTeam(ctx, teamID)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	err = s.validateMember(ctx, userID)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	err = s.teamsRepo.AddMember(ctx, teamID, userID)
+	if errors.Is(err, teamsRepository.ErrNotFound) {
+		return models.Team{}, httputils.NewNotFoundError("team")
+	}
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	return s.getTeam(ctx, teamID)
+}
+
+// RemoveMember removes a user from the team
+func (s service) RemoveMember(ctx context.Context, teamID int64, userID int64, requester models.Requester) (models.Team, error) {
+	if !requester.Can(models.PermissionTeamManage) {
+		return models.Team{}, httputils.ForbiddenError
+	}
+
+	err := s.teamsRepo.RemoveMember(ctx, teamID, userID)
+	if errors.Is(err, teamsRepository.ErrNotFound) {
+		return models.Team{}, httputils.NewNotFoundError("team member")
+	}
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	return s.getTeam(ctx, teamID)
+}
+
+// SetTicketTypes replaces the ticket types routed to the team. The types are taken from the teams
+// they were routed to
+func (s service) SetTicketTypes(ctx context.Context, teamID int64, ticketTypes []models.TicketType, requester models.Requester) (models.Team, error) {
+	if !requester.Can(models.PermissionTeamManage) {
+		return models.Team{}, httputils.ForbiddenError
+	}
+
+	err := validateTicketTypes(ticketTypes)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	_, err = s.getTeam(ctx, teamID)
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	err = s.teamsRepo.SetTicketTypes(ctx, teamID, ticketTypes)
+	if errors.Is(err, teamsRepository.ErrNotFound) {
+		return models.Team{}, httputils.NewNotFoundError("team")
+	}
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	return s.getTeam(ctx, teamID)
+}
+
+func (s service) getTeam(ctx context.Context, teamID int64) (models.Team, error) {
+	team, err := s.teamsRepo.GetTeam(ctx, teamID)
+	if errors.Is(err, teamsRepository.ErrNotFound) {
+		return models.Team{}, httputils.NewNotFoundError("team")
+	}
+
+	if err != nil {
+		return models.Team{}, err
+	}
+
+	return team, nil
+}
+
+// validateMember checks the user exists and its role allows it to work on tickets
+func (s service) validateMember(ctx context.Context, userID int64) error {
+	user, err := s.usersRepo.GetUser(ctx, int(userID))
+	if errors.Is(err, usersRepository.ErrNotFound) {
+		return ErrInvalidUserID
+	}
+
+	if err != nil {
+		return err
+	}
+
+	agents, err := s.usersRepo.ListActiveUsersWithPermission(ctx, models.PermissionTicketWork)
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range agents {
+		if agent.UserID == user.UserID {
+			return nil
+		}
+	}
+
+	return ErrMemberNotAgent
+}
+
+func validateTicketTypes(ticketTypes []models.TicketType) error {
+	for _, ticketType := range ticketTypes {
+		if !models.IsValidTicketType(ticketType) {
+			return ErrInvalidTicketType
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	agent = models.Requester{UserID: 1, Role: models.RoleAgent, Permissions: models.DefaultRolePermissions[models.RoleAgent]}
+	admin = models.Requester{UserID: 2, Role: models.RoleAdmin, Permissions: models.DefaultRolePermissions[models.RoleAdmin]}
+)
+
+// teamsRepoMock is an in-memory teams repository. Methods not overridden panic when called
+type teamsRepoMock struct {
+	teamsRepository.Repository
+	teams []models.Team
+}
+
+func (m *teamsRepoMock) CreateTeam(ctx context.Context, team models.Team) (models.Team, error) {
+	for _, existing := range m.teams {
+		if existing.Name == team.Name {
+			return models.Team{}, teamsRepository.ErrDuplicateName
+		}
+	}
+
+	team.TeamID = int64(len(m.teams) + 1)
+	m.teams = append(m.teams, team)
+
+	return team, nil
+}
+
+func (m *teamsRepoMock) GetTeam(ctx context.Context, teamID int64) (models.Team, error) {
+	for _, team := range m.teams {
+		if team.TeamID == teamID {
+			return team, nil
+		}
+	}
+
+	return models.Team{}, teamsRepository.ErrNotFound
+}
+
+func (m *teamsRepoMock) AddMember(ctx context.Context, teamID int64, userID int64) error {
+	m.teams[teamID-1].MemberIDs = append(m.teams[teamID-1].MemberIDs, userID)
+	return nil
+}
+
+type usersRepoMock struct {
+	usersRepository.Repository
+}
+
+func (m usersRepoMock) GetUser(ctx context.Context, userID int) (models.User, error) {
+	switch userID {
+	case 1:
+		return models.User{UserID: 1, Role: models.RoleAgent, Active: true}, nil
+	case 3:
+		return models.User{UserID: 3, Role: models.RoleRequester, Active: true}, nil
+	default:
+		return models.User{}, usersRepository.ErrNotFound
+	}
+}
+
+func (m usersRepoMock) ListActiveUsersWithPermission(ctx context.Context, permission models.Permission) ([]models.User, error) {
+	return []models.User{{UserID: 1, Role: models.RoleAgent, Active: true}}, nil
+}
+
+func TestCreateTeam(t *testing.T) {
+	c := require.New(t)
+
+	s := New(&teamsRepoMock{}, usersRepoMock{})
+
+	request := CreateTeamRequest{Name: " billing ", TicketTypes: []models.TicketType{models.TicketTypeSupport}}
+
+	_, err := s.CreateTeam(context.Background(), request, agent)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.CreateTeam(context.Background(), CreateTeamRequest{TicketTypes: request.TicketTypes}, admin)
+	c.Equal(ErrMissingName, err)
+
+	_, err = s.CreateTeam(context.Background(), CreateTeamRequest{Name: "billing", TicketTypes: []models.TicketType{"unknown"}}, admin)
+	c.Equal(ErrInvalidTicketType, err)
+
+	team, err := s.CreateTeam(context.Background(), request, admin)
+	c.Nil(err)
+	c.Equal("billing", team.Name)
+
+	_, err = s.CreateTeam(context.Background(), request, admin)
+	c.Equal(ErrDuplicateName, err)
+}
+
+func TestAddMember(t *testing.T) {
+	c := require.New(t)
+
+	s := New(&teamsRepoMock{teams: []models.Team{{TeamID: 1, Name: "billing"}}}, usersRepoMock{})
+
+	_, err := s.AddMember(context.Background(), 2, 1, admin)
+	c.Equal(httputils.NewNotFoundError("team"), err)
+
+	_, err = s.AddMember(context.Background(), 1, 99, admin)
+	c.Equal(ErrInvalidUserID, err)
+
+	_, err = s.AddMember(context.Background(), 1, 3, admin)
+	c.Equal(ErrMemberNotAgent, err)
+
+	team, err := s.AddMember(context.Background(), 1, 1, admin)
+	c.Nil(err)
+	c.True(team.HasMember(1))
+}
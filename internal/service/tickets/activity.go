@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	defaultActivityPageSize = 50
+	maxActivityPageSize     = 200
+)
+
+var (
+	// ErrInvalidActivityType invalid activity type
+	ErrInvalidActivityType = httputils.NewBadRequestError("invalid activity type")
+)
+
+// GetActivity returns a page of the changes and comments of the tickets, newest first. Without a
+// ticket only the activity of the tickets made by the requester is listed, unless somebody allowed to
+// read every ticket asks for all of it. Internal notes are only listed for the ones allowed to read them
+func (s service) GetActivity(ctx context.Context, filter GetActivityFilter, requester models.Requester) (GetActivityResponse, error) {
+	repoFilter, err := filter.toRepositoryFilter(requester)
+	if err != nil {
+		return GetActivityResponse{}, err
+	}
+
+	if filter.TicketID != nil {
+		_, err = s.GetTicket(ctx, *filter.TicketID, requester)
+		if err != nil {
+			return GetActivityResponse{}, err
+		}
+	}
+
+	activity, next, err := s.ticketsRepo.ListActivity(ctx, repoFilter)
+	if err != nil {
+		return GetActivityResponse{}, err
+	}
+
+	response := GetActivityResponse{Activity: activity, Total: len(activity)}
+	if next != nil {
+		response.Next = encodeOpaque(next)
+	}
+
+	return response, nil
+}
+
+// toRepositoryFilter validates the filter and converts it to the one of the repository
+func (f GetActivityFilter) toRepositoryFilter(requester models.Requester) (ticketsRepository.ActivityFilter, error) {
+	for _, activityType := range f.Types {
+		if !activityType.IsValid() {
+			return ticketsRepository.ActivityFilter{}, ErrInvalidActivityType
+		}
+	}
+
+	if f.All && !requester.Can(models.PermissionTicketReadAll) {
+		return ticketsRepository.ActivityFilter{}, httputils.ForbiddenError
+	}
+
+	filter := ticketsRepository.ActivityFilter{
+		TicketID:        f.TicketID,
+		ActorID:         f.ActorID,
+		Types:           f.Types,
+		After:           utcTime(f.After),
+		Before:          utcTime(f.Before),
+		IncludeInternal: requester.Can(models.PermissionTicketInternal),
+		Limit:           defaultActivityPageSize,
+	}
+
+	// the visibility of a single ticket is checked against the ticket itself
+	if !f.All && f.TicketID == nil {
+		filter.CreatorID = &requester.UserID
+	}
+
+	if f.PageSize < 0 || f.PageSize > maxActivityPageSize {
+		return ticketsRepository.ActivityFilter{}, ErrInvalidPageSize
+	}
+
+	if f.PageSize > 0 {
+		filter.Limit = f.PageSize
+	}
+
+	if f.Cursor != "" {
+		cursor := ticketsRepository.ActivityCursor{}
+
+		err := decodeOpaque(f.Cursor, &cursor)
+		if err != nil || cursor.ID <= 0 || !cursor.Type.IsValid() {
+			return ticketsRepository.ActivityFilter{}, ErrInvalidCursor
+		}
+
+		filter.Cursor = &cursor
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	// AssignmentStrategyNone new tickets are left unassigned
+	AssignmentStrategyNone = ""
+	// AssignmentStrategyRoundRobin new tickets are assigned to each agent in turn
+	AssignmentStrategyRoundRobin = "round_robin"
+	// AssignmentStrategyLeastLoaded new tickets are assigned to the agent with the fewest open tickets
+	AssignmentStrategyLeastLoaded = "least_loaded"
+)
+
+var (
+	// ErrOwnerNotAgent owner not agent
+	ErrOwnerNotAgent = httputils.NewBadRequestError("tickets can only be assigned to users who work on tickets")
+	// ErrOwnerDeactivated owner deactivated
+	ErrOwnerDeactivated = httputils.NewBadRequestError("tickets can not be assigned to deactivated users")
+	// ErrUnknownAssignmentStrategy unknown assignment strategy
+	ErrUnknownAssignmentStrategy = errors.New("unknown assignment strategy")
+)
+
+// AssignmentStrategy picks the owner of a new ticket among the members of its team, or among every
+// agent when it has none. A nil owner leaves the ticket unassigned
+type AssignmentStrategy interface {
+	PickOwner(ctx context.Context, ticket models.Ticket) (*int64, error)
+}
+
+// NewAssignmentStrategy returns the strategy with the given name, or nil for AssignmentStrategyNone
+func NewAssignmentStrategy(name string, ticketsRepo ticketsRepository.Repository, usersRepo usersRepository.Repository, teamsRepo teamsRepository.Repository) (AssignmentStrategy, error) {
+	candidates := candidatesFinder{usersRepo: usersRepo, teamsRepo: teamsRepo}
+
+	switch name {
+	case AssignmentStrategyNone:
+		return nil, nil
+	case AssignmentStrategyRoundRobin:
+		return &roundRobinStrategy{candidates: candidates}, nil
+	case AssignmentStrategyLeastLoaded:
+		return leastLoadedStrategy{ticketsRepo: ticketsRepo, candidates: candidates}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAssignmentStrategy, name)
+	}
+}
+
+// candidatesFinder lists the users a new ticket can be assigned to
+type candidatesFinder struct {
+	usersRepo usersRepository.Repository
+	teamsRepo teamsRepository.Repository
+}
+
+// find returns the active agents, only the members of the team of the ticket when it has one
+func (f candidatesFinder) find(ctx context.Context, ticket models.Ticket) ([]models.User, error) {
+	agents, err := f.usersRepo.ListActiveUsersWithPermission(ctx, models.PermissionTicketWork)
+	if err != nil {
+		return nil, err
+	}
+
+	if ticket.TeamID == nil {
+		return agents, nil
+	}
+
+	team, err := f.teamsRepo.GetTeam(ctx, *ticket.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []models.User{}
+	for _, agent := range agents {
+		if team.HasMember(agent.UserID) {
+			members = append(members, agent)
+		}
+	}
+
+	return members, nil
+}
+
+type roundRobinStrategy struct {
+	candidates candidatesFinder
+
+	mutex sync.Mutex
+	next  int
+}
+
+// PickOwner returns the candidates in turn
+func (s *roundRobinStrategy) PickOwner(ctx context.Context, ticket models.Ticket) (*int64, error) {
+	agents, err := s.candidates.find(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(agents) == 0 {
+		return nil, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	owner := agents[s.next%len(agents)].UserID
+	s.next = (s.next + 1) % len(agents)
+
+	return &owner, nil
+}
+
+type leastLoadedStrategy struct {
+	ticketsRepo ticketsRepository.Repository
+	candidates  candidatesFinder
+}
+
+// PickOwner returns the candidate with the fewest open tickets, the one with the lowest id on ties
+func (s leastLoadedStrategy) PickOwner(ctx context.Context, ticket models.Ticket) (*int64, error) {
+	agents, err := s.candidates.find(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(agents) == 0 {
+		return nil, nil
+	}
+
+	openTickets, err := s.ticketsRepo.CountOpenTicketsByOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := agents[0].UserID
+	for _, agent := range agents[1:] {
+		if openTickets[agent.UserID] < openTickets[owner] {
+			owner = agent.UserID
+		}
+	}
+
+	return &owner, nil
+}
+
+// AssignTicket makes the given agent the owner of the ticket. Only the ones allowed to assign tickets can
+func (s service) AssignTicket(ctx context.Context, ticketID int64, ownerID int64, requester models.Requester) (models.Ticket, error) {
+	if !requester.Can(models.PermissionTicketAssign) {
+		return models.Ticket{}, httputils.ForbiddenError
+	}
+
+	err := s.validateOwner(ctx, ownerID)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return s.setTicketOwner(ctx, ticketID, &ownerID, requester)
+}
+
+// UnassignTicket leaves the ticket without owner. Only the ones allowed to assign tickets can
+func (s service) UnassignTicket(ctx context.Context, ticketID int64, requester models.Requester) (models.Ticket, error) {
+	if !requester.Can(models.PermissionTicketAssign) {
+		return models.Ticket{}, httputils.ForbiddenError
+	}
+
+	return s.setTicketOwner(ctx, ticketID, nil, requester)
+}
+
+func (s service) setTicketOwner(ctx context.Context, ticketID int64, ownerID *int64, requester models.Requester) (models.Ticket, error) {
+	var updatedTicket models.Ticket
+
+	err := s.ticketsRepo.WithTx(ctx, func(repo ticketsRepository.Repository) error {
+		ticket, err := repo.GetTicketForUpdate(ctx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		if ticket.Status.IsFinal() {
+			return ErrTicketInFinalStatus
+		}
+
+		previousTicket := ticket
+		previousOwnerID := ticket.OwnerID
+		ticket.OwnerID = ownerID
+
+		updatedTicket, err = repo.UpdateTicket(ctx, ticket)
+		if err != nil {
+			return err
+		}
+
+		err = saveChanges(ctx, repo, ticketChanges(previousTicket, updatedTicket, requester.UserID))
+		if err != nil {
+			return err
+		}
+
+		events := []models.TicketEvent{models.NewTicketEvent(models.TicketEventUpdated, updatedTicket, requester.UserID)}
+
+		if ownerID != nil && !sameID(ownerID, previousOwnerID) {
+			events = append(events, models.NewTicketEvent(models.TicketEventAssigned, updatedTicket, requester.UserID))
+		}
+
+		return saveEvents(ctx, repo, events...)
+	})
+	if errors.Is(err, ticketsRepository.ErrNotFound) {
+		return models.Ticket{}, httputils.NewNotFoundError("ticket")
+	}
+
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return updatedTicket, nil
+}
+
+// validateOwner checks the user exists, is active and its role allows it to work on tickets
+func (s service) validateOwner(ctx context.Context, ownerID int64) error {
+	owner, err := s.usersRepo.GetUser(ctx, int(ownerID))
+	if errors.Is(err, usersRepository.ErrNotFound) {
+		return ErrInvalidOwnerID
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !owner.Active {
+		return ErrOwnerDeactivated
+	}
+
+	agents, err := s.usersRepo.ListActiveUsersWithPermission(ctx, models.PermissionTicketWork)
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range agents {
+		if agent.UserID == owner.UserID {
+			return nil
+		}
+	}
+
+	return ErrOwnerNotAgent
+}
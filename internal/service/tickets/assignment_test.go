@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const secondAdminID int64 = 4
+
+func newAdminsRepoMock() *usersRepoMock {
+	repo := newUsersRepoMock()
+	repo.users = append(repo.users,
+		models.User{UserID: secondAdminID, Role: models.RoleAdmin, Active: true},
+		models.User{UserID: 5, Role: models.RoleAdmin, Active: false},
+	)
+
+	return repo
+}
+
+func TestAssignTicket(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newAdminsRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	_, err := s.AssignTicket(context.Background(), 10, adminID, creator)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.AssignTicket(context.Background(), 10, strangerID, admin)
+	c.Equal(ErrOwnerNotAgent, err)
+
+	_, err = s.AssignTicket(context.Background(), 10, 5, admin)
+	c.Equal(ErrOwnerDeactivated, err)
+
+	_, err = s.AssignTicket(context.Background(), 10, 99, admin)
+	c.Equal(ErrInvalidOwnerID, err)
+
+	ticket, err := s.AssignTicket(context.Background(), 10, secondAdminID, admin)
+	c.Nil(err)
+	c.Equal(secondAdminID, *ticket.OwnerID)
+
+	ticket, err = s.UnassignTicket(context.Background(), 10, admin)
+	c.Nil(err)
+	c.Nil(ticket.OwnerID)
+}
+
+func TestRoundRobinStrategy(t *testing.T) {
+	c := require.New(t)
+
+	strategy, err := NewAssignmentStrategy(AssignmentStrategyRoundRobin, nil, newAdminsRepoMock(), newTeamsRepoMock())
+	c.Nil(err)
+
+	owners := []int64{}
+	for i := 0; i < 3; i++ {
+		owner, err := strategy.PickOwner(context.Background(), models.Ticket{})
+		c.Nil(err)
+		owners = append(owners, *owner)
+	}
+
+	c.Equal([]int64{adminID, secondAdminID, adminID}, owners)
+}
+
+func TestLeastLoadedStrategy(t *testing.T) {
+	c := require.New(t)
+
+	busyTicket := newTestTicket()
+	busyTicket.OwnerID = new(int64)
+	*busyTicket.OwnerID = adminID
+
+	ticketsRepo := newTicketsRepoMock(busyTicket)
+
+	strategy, err := NewAssignmentStrategy(AssignmentStrategyLeastLoaded, ticketsRepo, newAdminsRepoMock(), newTeamsRepoMock())
+	c.Nil(err)
+
+	s := New(ticketsRepo, newAdminsRepoMock(), newTeamsRepoMock(), strategy, nil, nil)
+
+	ticket, err := s.CreateTicket(context.Background(), models.Ticket{
+		Title:       "scanner",
+		Description: "it does not scan",
+		Type:        models.TicketTypeSupport,
+		Severity:    models.TicketSeverityLow,
+		Priority:    models.TicketPriorityLow,
+		CreatorID:   creatorID,
+	})
+	c.Nil(err)
+	c.Equal(secondAdminID, *ticket.OwnerID)
+}
+
+func TestUnknownAssignmentStrategy(t *testing.T) {
+	c := require.New(t)
+
+	_, err := NewAssignmentStrategy("random", nil, nil, nil)
+	c.ErrorIs(err, ErrUnknownAssignmentStrategy)
+}
+
+func TestAssignTicketByRole(t *testing.T) {
+	c := require.New(t)
+
+	repo := newAdminsRepoMock()
+	repo.users = append(repo.users,
+		models.User{UserID: 6, Role: models.RoleAgent, Active: true},
+		models.User{UserID: 7, Role: models.RoleSupervisor, Active: true},
+	)
+
+	agent := models.Requester{UserID: 6, Role: models.RoleAgent, Permissions: models.DefaultRolePermissions[models.RoleAgent]}
+	supervisor := models.Requester{UserID: 7, Role: models.RoleSupervisor, Permissions: models.DefaultRolePermissions[models.RoleSupervisor]}
+
+	s := New(newTicketsRepoMock(newTestTicket()), repo, newTeamsRepoMock(), nil, nil, nil)
+
+	_, err := s.AssignTicket(context.Background(), 10, agent.UserID, agent)
+	c.Equal(httputils.ForbiddenError, err)
+
+	ticket, err := s.AssignTicket(context.Background(), 10, agent.UserID, supervisor)
+	c.Nil(err)
+	c.Equal(agent.UserID, *ticket.OwnerID)
+
+	ticket, err = s.GetTicket(context.Background(), 10, agent)
+	c.Nil(err)
+	c.Equal(creatorID, ticket.CreatorID)
+}
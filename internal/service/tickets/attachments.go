@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+	"github.com/syned13/ticket-support-back/pkg/storage"
+)
+
+const (
+	// MaxAttachmentSize the biggest file that can be attached to a ticket, in bytes
+	MaxAttachmentSize = 10 << 20
+	// sniffLen how many bytes are read to detect the type of a file
+	sniffLen = 512
+)
+
+var (
+	// ErrMissingFileName missing file name
+	ErrMissingFileName = httputils.NewBadRequestError("missing file name")
+	// ErrEmptyAttachment empty attachment
+	ErrEmptyAttachment = httputils.NewBadRequestError("empty attachment")
+	// ErrAttachmentTooLarge attachment too large
+	ErrAttachmentTooLarge = httputils.ErrorResponse{Code: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("attachments can not be bigger than %d bytes", MaxAttachmentSize)}
+	// ErrUnsupportedAttachmentType unsupported attachment type
+	ErrUnsupportedAttachmentType = httputils.ErrorResponse{Code: http.StatusUnsupportedMediaType, Message: "unsupported attachment type"}
+)
+
+// allowedAttachmentTypes the media types that can be attached to a ticket. The type is detected
+// from the content, what the client claims is ignored
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// CreateAttachment stores the uploaded file and attaches it to the ticket
+func (s service) CreateAttachment(ctx context.Context, upload AttachmentUpload, requester models.Requester) (models.TicketAttachment, error) {
+	fileName := filepath.Base(strings.ReplaceAll(upload.FileName, "\\", "/"))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		return models.TicketAttachment{}, ErrMissingFileName
+	}
+
+	if upload.Size <= 0 {
+		return models.TicketAttachment{}, ErrEmptyAttachment
+	}
+
+	if upload.Size > MaxAttachmentSize {
+		return models.TicketAttachment{}, ErrAttachmentTooLarge
+	}
+
+	_, err := s.GetTicket(ctx, upload.TicketID, requester)
+	if err != nil {
+		return models.TicketAttachment{}, err
+	}
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(upload.Content, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return models.TicketAttachment{}, err
+	}
+
+	header = header[:n]
+
+	contentType := http.DetectContentType(header)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !allowedAttachmentTypes[mediaType] {
+		return models.TicketAttachment{}, ErrUnsupportedAttachmentType
+	}
+
+	key, err := generateStorageKey(upload.TicketID)
+	if err != nil {
+		return models.TicketAttachment{}, err
+	}
+
+	content := io.MultiReader(bytes.NewReader(header), upload.Content)
+
+	err = s.storage.Put(ctx, key, content, upload.Size, contentType)
+	if err != nil {
+		return models.TicketAttachment{}, err
+	}
+
+	attachment, err := s.ticketsRepo.SaveTicketAttachment(ctx, models.TicketAttachment{
+		TicketID:    upload.TicketID,
+		UploaderID:  requester.UserID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        upload.Size,
+		StorageKey:  key,
+	})
+	if err != nil {
+		if deleteErr := s.storage.Delete(ctx, key); deleteErr != nil {
+			s.logger.ErrorContext(ctx, "deleting_orphan_attachment_failed", "key", key, "error", deleteErr)
+		}
+
+		return models.TicketAttachment{}, err
+	}
+
+	attachment.URL = attachmentURL(attachment)
+
+	return attachment, nil
+}
+
+// GetAttachments returns the attachments of a ticket visible to the requester
+func (s service) GetAttachments(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketAttachment, error) {
+	_, err := s.GetTicket(ctx, ticketID, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.ticketsRepo.GetTicketAttachments(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range attachments {
+		attachments[i].URL = attachmentURL(attachments[i])
+	}
+
+	return attachments, nil
+}
+
+// OpenAttachment returns an attachment and its content, which the caller must close
+func (s service) OpenAttachment(ctx context.Context, ticketID int64, attachmentID int64, requester models.Requester) (models.TicketAttachment, io.ReadCloser, error) {
+	_, err := s.GetTicket(ctx, ticketID, requester)
+	if err != nil {
+		return models.TicketAttachment{}, nil, err
+	}
+
+	attachment, err := s.ticketsRepo.GetTicketAttachment(ctx, ticketID, attachmentID)
+	if errors.Is(err, ticketsRepository.ErrNotFound) {
+		return models.TicketAttachment{}, nil, httputils.NewNotFoundError("attachment")
+	}
+
+	if err != nil {
+		return models.TicketAttachment{}, nil, err
+	}
+
+	content, err := s.storage.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return models.TicketAttachment{}, nil, httputils.NewNotFoundError("attachment")
+	}
+
+	if err != nil {
+		return models.TicketAttachment{}, nil, err
+	}
+
+	attachment.URL = attachmentURL(attachment)
+
+	return attachment, content, nil
+}
+
+// attachmentURL returns the path the attachment is downloaded from. It requires the same
+// authorization as viewing the ticket
+func attachmentURL(attachment models.TicketAttachment) string {
+	return fmt.Sprintf("/tickets/%d/attachments/%d", attachment.TicketID, attachment.AttachmentID)
+}
+
+// generateStorageKey returns a random key so file names chosen by users never reach the storage
+func generateStorageKey(ticketID int64) (string, error) {
+	randomBytes := make([]byte, 16)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("tickets/%d/%s", ticketID, hex.EncodeToString(randomBytes)), nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+	"github.com/syned13/ticket-support-back/pkg/storage"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func TestCreateAttachment(t *testing.T) {
+	c := require.New(t)
+
+	attachmentsStorage, err := storage.NewLocal(t.TempDir())
+	c.Nil(err)
+
+	ticketsRepo := newTicketsRepoMock(newTestTicket())
+	s := New(ticketsRepo, newUsersRepoMock(), newTeamsRepoMock(), nil, attachmentsStorage, nil)
+
+	content := append(pngHeader, []byte("image data")...)
+
+	attachment, err := s.CreateAttachment(context.Background(), AttachmentUpload{
+		TicketID: 10,
+		FileName: "../../screenshot.png",
+		Size:     int64(len(content)),
+		Content:  bytes.NewReader(content),
+	}, creator)
+	c.Nil(err)
+	c.Equal("screenshot.png", attachment.FileName)
+	c.Equal("image/png", attachment.ContentType)
+	c.Equal("/tickets/10/attachments/1", attachment.URL)
+
+	stored, err := attachmentsStorage.Get(context.Background(), attachment.StorageKey)
+	c.Nil(err)
+
+	storedContent, err := ioutil.ReadAll(stored)
+	c.Nil(err)
+	c.Nil(stored.Close())
+	c.Equal(content, storedContent)
+}
+
+func TestCreateAttachmentValidations(t *testing.T) {
+	attachmentsStorage, err := storage.NewLocal(t.TempDir())
+	require.Nil(t, err)
+
+	pngContent := append(pngHeader, []byte("image data")...)
+	htmlContent := []byte("<html><script>alert(1)</script></html>")
+
+	tests := []struct {
+		name      string
+		fileName  string
+		size      int64
+		content   []byte
+		requester models.Requester
+		err       error
+	}{
+		{"missing file name", "", int64(len(pngContent)), pngContent, creator, ErrMissingFileName},
+		{"empty file", "empty.png", 0, nil, creator, ErrEmptyAttachment},
+		{"too large", "huge.png", MaxAttachmentSize + 1, pngContent, creator, ErrAttachmentTooLarge},
+		{"html is not allowed", "page.png", int64(len(htmlContent)), htmlContent, creator, ErrUnsupportedAttachmentType},
+		{"stranger can not attach", "screenshot.png", int64(len(pngContent)), pngContent, stranger, httputils.ForbiddenError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := require.New(t)
+
+			s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, attachmentsStorage, nil)
+
+			_, err := s.CreateAttachment(context.Background(), AttachmentUpload{
+				TicketID: 10,
+				FileName: test.fileName,
+				Size:     test.size,
+				Content:  bytes.NewReader(test.content),
+			}, test.requester)
+			c.Equal(test.err, err)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+// canViewTicket returns whether the requester can see the ticket: its creator and the ones allowed to
+// read every ticket can
+func canViewTicket(requester models.Requester, ticket models.Ticket) bool {
+	return requester.Can(models.PermissionTicketReadAll) || ticket.CreatorID == requester.UserID
+}
+
+// canUpdateTicket returns whether the requester can change the ticket
+func canUpdateTicket(requester models.Requester, ticket models.Ticket) bool {
+	return requester.Can(models.PermissionTicketUpdateAll) || ticket.CreatorID == requester.UserID
+}
+
+// visibleCreatorID returns the creator the tickets listed to the requester are limited to, following
+// canViewTicket. It is nil for the ones allowed to read every ticket
+func visibleCreatorID(requester models.Requester) *int64 {
+	if requester.Can(models.PermissionTicketReadAll) {
+		return nil
+	}
+
+	return &requester.UserID
+}
+
+func authorizeTicketView(requester models.Requester, ticket models.Ticket) error {
+	if !canViewTicket(requester, ticket) {
+		return httputils.ForbiddenError
+	}
+
+	return nil
+}
+
+func authorizeTicketUpdate(requester models.Requester, ticket models.Ticket) error {
+	if !canUpdateTicket(requester, ticket) {
+		return httputils.ForbiddenError
+	}
+
+	return nil
+}
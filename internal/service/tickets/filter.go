@@ -0,0 +1,179 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+
+	sortOrderAsc  = "asc"
+	sortOrderDesc = "desc"
+)
+
+var (
+	// ErrInvalidSortField invalid sort field
+	ErrInvalidSortField = httputils.NewBadRequestError("invalid sort field")
+	// ErrInvalidSortOrder invalid sort order
+	ErrInvalidSortOrder = httputils.NewBadRequestError("invalid sort order")
+	// ErrInvalidPageSize invalid page size
+	ErrInvalidPageSize = httputils.NewBadRequestError("invalid page size")
+	// ErrInvalidCursor invalid cursor
+	ErrInvalidCursor = httputils.NewBadRequestError("invalid cursor")
+	// ErrAfterIDWithSort after_id with sort
+	ErrAfterIDWithSort = httputils.NewBadRequestError("after_id can only be used when sorting by id")
+)
+
+var validSortFields = map[ticketsRepository.TicketSortField]bool{
+	ticketsRepository.SortByID:        true,
+	ticketsRepository.SortByStatus:    true,
+	ticketsRepository.SortByType:      true,
+	ticketsRepository.SortBySeverity:  true,
+	ticketsRepository.SortByPriority:  true,
+	ticketsRepository.SortByOwner:     true,
+	ticketsRepository.SortByCreator:   true,
+	ticketsRepository.SortByCreatedAt: true,
+	ticketsRepository.SortByUpdatedAt: true,
+}
+
+// toRepositoryFilter validates the filter and converts it to the one of the repository. Requesters not
+// allowed to read every ticket only get to see their own ones
+func (f GetTicketsFilter) toRepositoryFilter(requester models.Requester) (ticketsRepository.TicketFilter, error) {
+	for _, status := range f.Statuses {
+		if !models.IsValidTicketStatus(status) {
+			return ticketsRepository.TicketFilter{}, ErrInvalidStatus
+		}
+	}
+
+	for _, ticketType := range f.Types {
+		if !models.IsValidTicketType(ticketType) {
+			return ticketsRepository.TicketFilter{}, ErrInvalidTicketType
+		}
+	}
+
+	for _, severity := range f.Severities {
+		if !severity.IsValid() {
+			return ticketsRepository.TicketFilter{}, ErrInvalidSeverity
+		}
+	}
+
+	for _, priority := range f.Priorities {
+		if !priority.IsValid() {
+			return ticketsRepository.TicketFilter{}, ErrInvalidPriority
+		}
+	}
+
+	filter := ticketsRepository.TicketFilter{
+		Statuses:      f.Statuses,
+		Types:         f.Types,
+		Severities:    f.Severities,
+		Priorities:    f.Priorities,
+		OwnerID:       f.OwnerID,
+		CreatorID:     f.CreatorID,
+		CreatedAfter:  utcTime(f.CreatedAfter),
+		CreatedBefore: utcTime(f.CreatedBefore),
+		UpdatedAfter:  utcTime(f.UpdatedAfter),
+		UpdatedBefore: utcTime(f.UpdatedBefore),
+		SortBy:        ticketsRepository.SortByID,
+		Limit:         defaultPageSize,
+	}
+
+	if f.TeamID != nil {
+		filter.TeamIDs = []int64{*f.TeamID}
+	}
+
+	if creatorID := visibleCreatorID(requester); creatorID != nil {
+		filter.CreatorID = creatorID
+	}
+
+	if f.SortBy != "" {
+		filter.SortBy = ticketsRepository.TicketSortField(f.SortBy)
+		if !validSortFields[filter.SortBy] {
+			return ticketsRepository.TicketFilter{}, ErrInvalidSortField
+		}
+	}
+
+	switch f.Order {
+	case "", sortOrderAsc:
+	case sortOrderDesc:
+		filter.SortDesc = true
+	default:
+		return ticketsRepository.TicketFilter{}, ErrInvalidSortOrder
+	}
+
+	if f.PageSize < 0 || f.PageSize > maxPageSize {
+		return ticketsRepository.TicketFilter{}, ErrInvalidPageSize
+	}
+
+	if f.PageSize > 0 {
+		filter.Limit = f.PageSize
+	}
+
+	if f.Cursor != "" {
+		cursor, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return ticketsRepository.TicketFilter{}, err
+		}
+
+		// a cursor of another sort points somewhere else, or to a value of another type
+		if cursor.SortBy != filter.SortBy || cursor.Desc != filter.SortDesc {
+			return ticketsRepository.TicketFilter{}, ErrInvalidCursor
+		}
+
+		filter.After = &cursor
+	} else if f.AfterID > 0 {
+		if filter.SortBy != ticketsRepository.SortByID {
+			return ticketsRepository.TicketFilter{}, ErrAfterIDWithSort
+		}
+
+		filter.After = &ticketsRepository.Cursor{Value: strconv.FormatInt(f.AfterID, 10), ID: f.AfterID, SortBy: filter.SortBy, Desc: filter.SortDesc}
+	}
+
+	return filter, nil
+}
+
+// encodeCursor returns the opaque representation of a cursor sent to clients
+func encodeCursor(cursor *ticketsRepository.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	return encodeOpaque(cursor)
+}
+
+func decodeCursor(encoded string) (ticketsRepository.Cursor, error) {
+	cursor := ticketsRepository.Cursor{}
+
+	err := decodeOpaque(encoded, &cursor)
+	if err != nil || cursor.ID <= 0 {
+		return ticketsRepository.Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// encodeOpaque encodes a value so clients can send it back without relying on its contents
+func encodeOpaque(value interface{}) string {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(valueBytes)
+}
+
+func decodeOpaque(encoded string, value interface{}) error {
+	valueBytes, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(valueBytes, value)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+)
+
+func TestToRepositoryFilterVisibility(t *testing.T) {
+	c := require.New(t)
+
+	otherCreator := int64(99)
+
+	filter, err := GetTicketsFilter{CreatorID: &otherCreator}.toRepositoryFilter(creator)
+	c.Nil(err)
+	c.Equal(creatorID, *filter.CreatorID)
+
+	filter, err = GetTicketsFilter{CreatorID: &otherCreator}.toRepositoryFilter(admin)
+	c.Nil(err)
+	c.Equal(otherCreator, *filter.CreatorID)
+
+	filter, err = GetTicketsFilter{}.toRepositoryFilter(admin)
+	c.Nil(err)
+	c.Nil(filter.CreatorID)
+	c.Equal(ticketsRepository.SortByID, filter.SortBy)
+	c.Equal(defaultPageSize, filter.Limit)
+}
+
+func TestToRepositoryFilterValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter GetTicketsFilter
+		err    error
+	}{
+		{"invalid status", GetTicketsFilter{Statuses: []models.TicketStatus{"closed"}}, ErrInvalidStatus},
+		{"invalid severity", GetTicketsFilter{Severities: []models.TicketSeverity{9}}, ErrInvalidSeverity},
+		{"invalid sort field", GetTicketsFilter{SortBy: "password"}, ErrInvalidSortField},
+		{"invalid order", GetTicketsFilter{Order: "up"}, ErrInvalidSortOrder},
+		{"page size too big", GetTicketsFilter{PageSize: maxPageSize + 1}, ErrInvalidPageSize},
+		{"invalid cursor", GetTicketsFilter{Cursor: "not a cursor"}, ErrInvalidCursor},
+		{"after id with sort", GetTicketsFilter{SortBy: "createdAt", AfterID: 3}, ErrAfterIDWithSort},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := require.New(t)
+
+			_, err := test.filter.toRepositoryFilter(admin)
+			c.Equal(test.err, err)
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := require.New(t)
+
+	cursor := &ticketsRepository.Cursor{Value: "2021-04-02 10:00:00", ID: 20, SortBy: ticketsRepository.SortByCreatedAt}
+
+	filter, err := GetTicketsFilter{SortBy: "createdAt", Cursor: encodeCursor(cursor)}.toRepositoryFilter(admin)
+	c.Nil(err)
+	c.Equal(cursor, filter.After)
+
+	filter, err = GetTicketsFilter{AfterID: 7}.toRepositoryFilter(admin)
+	c.Nil(err)
+	c.Equal(&ticketsRepository.Cursor{Value: "7", ID: 7, SortBy: ticketsRepository.SortByID}, filter.After)
+
+	_, err = GetTicketsFilter{SortBy: "severity", Cursor: encodeCursor(cursor)}.toRepositoryFilter(admin)
+	c.Equal(ErrInvalidCursor, err)
+
+	_, err = GetTicketsFilter{SortBy: "createdAt", Order: "desc", Cursor: encodeCursor(cursor)}.toRepositoryFilter(admin)
+	c.Equal(ErrInvalidCursor, err)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+)
+
+// recordedFields the fields whose changes are kept in the history of a ticket, along with how
+// their values are recorded
+var recordedFields = []struct {
+	field models.TicketField
+	value func(ticket models.Ticket) *string
+}{
+	{models.TicketFieldTitle, func(ticket models.Ticket) *string { return &ticket.Title }},
+	{models.TicketFieldDescription, func(ticket models.Ticket) *string { return &ticket.Description }},
+	{models.TicketFieldOwner, func(ticket models.Ticket) *string {
+		if ticket.OwnerID == nil {
+			return nil
+		}
+
+		value := strconv.FormatInt(*ticket.OwnerID, 10)
+		return &value
+	}},
+	{models.TicketFieldTeam, func(ticket models.Ticket) *string {
+		if ticket.TeamID == nil {
+			return nil
+		}
+
+		value := strconv.FormatInt(*ticket.TeamID, 10)
+		return &value
+	}},
+	{models.TicketFieldPriority, func(ticket models.Ticket) *string {
+		value := strconv.Itoa(int(ticket.Priority))
+		return &value
+	}},
+	{models.TicketFieldSeverity, func(ticket models.Ticket) *string {
+		value := strconv.Itoa(int(ticket.Severity))
+		return &value
+	}},
+	{models.TicketFieldStatus, func(ticket models.Ticket) *string {
+		value := string(ticket.Status)
+		return &value
+	}},
+}
+
+// ticketChanges returns a change for every recorded field that differs between the ticket before
+// and after it was updated
+func ticketChanges(before models.Ticket, after models.Ticket, changedBy int64) []models.TicketChange {
+	changes := []models.TicketChange{}
+
+	for _, recorded := range recordedFields {
+		from, to := recorded.value(before), recorded.value(after)
+		if sameValue(from, to) {
+			continue
+		}
+
+		changes = append(changes, models.TicketChange{
+			TicketID:  after.TicketID,
+			CreatorID: after.CreatorID,
+			ChangedBy: changedBy,
+			Field:     recorded.field,
+			From:      from,
+			To:        to,
+		})
+	}
+
+	return changes
+}
+
+func sameValue(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// saveChanges adds the changes to the history of the ticket through the given repository
+func saveChanges(ctx context.Context, repo ticketsRepository.Repository, changes []models.TicketChange) error {
+	for _, change := range changes {
+		err := repo.SaveTicketChange(ctx, change)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTicketHistory returns every change made to a ticket, oldest first
+func (s service) GetTicketHistory(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketChange, error) {
+	_, err := s.GetTicket(ctx, ticketID, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ticketsRepo.GetTicketHistory(ctx, ticketID)
+}
+
+// GetTicketChanges returns every change made to the tickets created by the requester, oldest first
+func (s service) GetTicketChanges(ctx context.Context, requester models.Requester) ([]models.TicketChange, error) {
+	return s.ticketsRepo.GetTicketChanges(ctx, requester.UserID)
+}
+
+// GetTicketTimeline returns the changes and the comments of a ticket merged in the order they
+// happened. Internal notes are only included for the ones allowed to read them
+func (s service) GetTicketTimeline(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketActivity, error) {
+	changes, err := s.GetTicketHistory(ctx, ticketID, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.ticketsRepo.GetTicketComments(ctx, ticketID, requester.Can(models.PermissionTicketInternal))
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := make([]models.TicketActivity, 0, len(changes)+len(comments))
+
+	for i := range changes {
+		timeline = append(timeline, models.TicketActivity{
+			Type:     models.TicketActivityChange,
+			TicketID: ticketID,
+			ActorID:  changes[i].ChangedBy,
+			At:       changes[i].ChangedAt,
+			Change:   &changes[i],
+		})
+	}
+
+	for i := range comments {
+		var at time.Time
+		if comments[i].CreatedAt != nil {
+			at = *comments[i].CreatedAt
+		}
+
+		timeline = append(timeline, models.TicketActivity{
+			Type:     models.TicketActivityComment,
+			TicketID: ticketID,
+			ActorID:  comments[i].AuthorID,
+			At:       at,
+			Comment:  &comments[i],
+		})
+	}
+
+	// both lists are already sorted so the stable sort keeps their order on ties
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].At.Before(timeline[j].At)
+	})
+
+	return timeline, nil
+}
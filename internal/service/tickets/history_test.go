@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+func TestUpdateTicketRecordsEveryChangedField(t *testing.T) {
+	c := require.New(t)
+
+	repo := newTicketsRepoMock(newTestTicket())
+	s := New(repo, newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	_, err := s.UpdateTicket(context.Background(), httputils.MergePatchRequest(`{"title":"scanner","priority":4,"ownerID":3}`), 10, admin)
+	c.Nil(err)
+
+	changes, err := s.GetTicketHistory(context.Background(), 10, creator)
+	c.Nil(err)
+	c.Len(changes, 3)
+
+	fields := map[models.TicketField]models.TicketChange{}
+	for _, change := range changes {
+		c.Equal(adminID, change.ChangedBy)
+		fields[change.Field] = change
+	}
+
+	c.Equal("printer", *fields[models.TicketFieldTitle].From)
+	c.Equal("scanner", *fields[models.TicketFieldTitle].To)
+	c.Equal("4", *fields[models.TicketFieldPriority].To)
+	c.Nil(fields[models.TicketFieldOwner].From)
+	c.Equal("3", *fields[models.TicketFieldOwner].To)
+
+	_, err = s.GetTicketHistory(context.Background(), 10, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+}
+
+func TestGetTicketTimelineMergesChangesAndComments(t *testing.T) {
+	c := require.New(t)
+
+	start := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	commentedAt := start.Add(time.Minute)
+	notedAt := start.Add(2 * time.Minute)
+	resolved := string(models.TicketStatusResolved)
+
+	repo := newTicketsRepoMock(newTestTicket())
+	repo.changes = []models.TicketChange{
+		{ChangeID: 1, TicketID: 10, ChangedBy: adminID, Field: models.TicketFieldStatus, To: &resolved, ChangedAt: start.Add(3 * time.Minute)},
+	}
+	repo.comments = []models.TicketComment{
+		{CommentID: 1, TicketID: 10, AuthorID: creatorID, Body: "still broken", CreatedAt: &commentedAt},
+		{CommentID: 2, TicketID: 10, AuthorID: adminID, Body: "replace toner", Internal: true, CreatedAt: &notedAt},
+	}
+
+	s := New(repo, newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	timeline, err := s.GetTicketTimeline(context.Background(), 10, admin)
+	c.Nil(err)
+	c.Len(timeline, 3)
+	c.Equal(models.TicketActivityComment, timeline[0].Type)
+	c.Equal(creatorID, timeline[0].ActorID)
+	c.Equal(int64(2), timeline[1].Comment.CommentID)
+	c.Equal(models.TicketActivityChange, timeline[2].Type)
+
+	timeline, err = s.GetTicketTimeline(context.Background(), 10, creator)
+	c.Nil(err)
+	c.Len(timeline, 2)
+	c.Equal(models.TicketActivityChange, timeline[1].Type)
+}
+
+func TestGetTicketChangesOnlyListsOwnTickets(t *testing.T) {
+	c := require.New(t)
+
+	inProgress := string(models.TicketTypeInProgress)
+
+	repo := newTicketsRepoMock()
+	repo.changes = []models.TicketChange{
+		{ChangeID: 1, TicketID: 10, CreatorID: creatorID, ChangedBy: adminID, Field: models.TicketFieldStatus, To: &inProgress},
+	}
+
+	s := New(repo, newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	changes, err := s.GetTicketChanges(context.Background(), creator)
+	c.Nil(err)
+	c.Len(changes, 1)
+
+	changes, err = s.GetTicketChanges(context.Background(), stranger)
+	c.Nil(err)
+	c.Empty(changes)
+}
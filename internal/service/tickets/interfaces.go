@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/syned13/ticket-support-back/internal/models"
 	"github.com/syned13/ticket-support-back/pkg/httputils"
@@ -9,8 +11,34 @@ import (
 
 type Service interface {
 	CreateTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error)
-	GetTickets(ctx context.Context, userID int64, userType models.UserType, lastID int64) (GetTicketsResponse, error)
-	GetTicket(ctx context.Context, ticketID int64) (models.Ticket, error)
-	UpdateTicket(ctx context.Context, patchRequest httputils.PatchRequest, ticketID int64) (models.Ticket, error)
-	GetTicketChanges(ctx context.Context, creatorID int64) ([]models.TicketChange, error)
+	GetTickets(ctx context.Context, filter GetTicketsFilter, requester models.Requester) (GetTicketsResponse, error)
+	GetTicket(ctx context.Context, ticketID int64, requester models.Requester) (models.Ticket, error)
+	SearchTickets(ctx context.Context, query string, offset int, requester models.Requester) (SearchTicketsResponse, error)
+	AssignTicket(ctx context.Context, ticketID int64, ownerID int64, requester models.Requester) (models.Ticket, error)
+	UnassignTicket(ctx context.Context, ticketID int64, requester models.Requester) (models.Ticket, error)
+	TransferTicket(ctx context.Context, ticketID int64, teamID *int64, requester models.Requester) (models.Ticket, error)
+	UpdateTicket(ctx context.Context, patch httputils.Patch, ticketID int64, requester models.Requester) (models.Ticket, error)
+	GetActivity(ctx context.Context, filter GetActivityFilter, requester models.Requester) (GetActivityResponse, error)
+	GetTicketHistory(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketChange, error)
+	// Deprecated: GetTicketChanges serves /changes for older clients, use GetActivity instead
+	GetTicketChanges(ctx context.Context, requester models.Requester) ([]models.TicketChange, error)
+	GetTicketTimeline(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketActivity, error)
+	CreateComment(ctx context.Context, comment models.TicketComment, requester models.Requester) (models.TicketComment, error)
+	GetComments(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketComment, error)
+	CreateAttachment(ctx context.Context, upload AttachmentUpload, requester models.Requester) (models.TicketAttachment, error)
+	GetAttachments(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketAttachment, error)
+	OpenAttachment(ctx context.Context, ticketID int64, attachmentID int64, requester models.Requester) (models.TicketAttachment, io.ReadCloser, error)
+	GetSLATickets(ctx context.Context, within time.Duration, requester models.Requester) (SLATicketsResponse, error)
+	GetWorkflow() WorkflowResponse
 }
+
+// EventPublisher is told about everything that happens to the tickets. Taking an event means the
+// work it causes is saved or done, it returns an error otherwise and the event is published to it
+// again later. The same event may then be published more than once, the EventID tells them apart
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.TicketEvent) error
+}
+
+// Consumers the publishers the outbox relays the events to, by name. The name is what the outbox
+// records a consumer took an event under, so renaming one relays the pending events to it again
+type Consumers map[string]EventPublisher
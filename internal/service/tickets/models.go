@@ -1,9 +1,95 @@
 package service
 
-import "github.com/syned13/ticket-support-back/internal/models"
+import (
+	"io"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
 
 type GetTicketsResponse struct {
 	Tickets []models.Ticket `json:"tickets"`
 	Last    int64           `json:"last"`
 	Total   int             `json:"total"`
+	// Next is the cursor of the following page, empty on the last one
+	Next string `json:"next,omitempty"`
+}
+
+// GetTicketsFilter defines which tickets to list and how to sort them. Empty fields do not filter
+type GetTicketsFilter struct {
+	Statuses      []models.TicketStatus
+	Types         []models.TicketType
+	Severities    []models.TicketSeverity
+	Priorities    []models.TicketPriority
+	OwnerID       *int64
+	CreatorID     *int64
+	TeamID        *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// AllTeams lists the tickets of every team instead of the queues of the teams of the requester
+	AllTeams bool
+
+	// SortBy is the JSON name of the field to sort by, id by default
+	SortBy string
+	// Order is either asc or desc
+	Order    string
+	PageSize int
+	// Cursor is the next value of a previous response
+	Cursor string
+	// AfterID is the legacy pagination, only valid when sorting by id
+	AfterID int64
+}
+
+// WorkflowResponse describes the ticket status workflow
+type WorkflowResponse struct {
+	Statuses      []models.TicketStatus `json:"statuses"`
+	FinalStatuses []models.TicketStatus `json:"finalStatuses"`
+	Transitions   []Transition          `json:"transitions"`
+}
+
+// SearchTicketsResponse a page of search results. Next is the offset of the following page, nil on
+// the last one
+type SearchTicketsResponse struct {
+	Results []models.TicketSearchResult `json:"results"`
+	Next    *int                        `json:"next,omitempty"`
+	Total   int                         `json:"total"`
+}
+
+// SLATicketsResponse the open tickets that breached their SLA and the ones about to do so
+type SLATicketsResponse struct {
+	Breached []models.Ticket `json:"breached"`
+	AtRisk   []models.Ticket `json:"atRisk"`
+}
+
+// AttachmentUpload a file being attached to a ticket
+type AttachmentUpload struct {
+	TicketID int64
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+// GetActivityFilter defines which activity to list. Empty fields do not filter
+type GetActivityFilter struct {
+	TicketID *int64
+	ActorID  *int64
+	Types    []models.TicketActivityType
+	After    *time.Time
+	Before   *time.Time
+	// All lists the activity of every ticket instead of the ones made by the requester, only the ones
+	// allowed to read every ticket can do so
+	All      bool
+	PageSize int
+	// Cursor is the next value of a previous response
+	Cursor string
+}
+
+// GetActivityResponse a page of activity, newest first
+type GetActivityResponse struct {
+	Activity []models.TicketActivity `json:"activity"`
+	Total    int                     `json:"total"`
+	// Next is the cursor of the following page, empty on the last one
+	Next string `json:"next,omitempty"`
 }
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+)
+
+const (
+	outboxBatchSize      = 100
+	outboxInitialBackoff = time.Second
+	outboxMaxBackoff     = 5 * time.Minute
+	// outboxClaimDuration how long a claimed batch is skipped by the other dispatchers, its events are
+	// taken again after it when they were never marked
+	outboxClaimDuration = time.Minute
+)
+
+// saveEvents adds the events to the outbox through the given repository, usually one running a
+// transaction, so they are only relayed when the change they describe is committed
+func saveEvents(ctx context.Context, repo ticketsRepository.Repository, events ...models.TicketEvent) error {
+	for _, event := range events {
+		err := repo.SaveOutboxEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OutboxDispatcher periodically relays the events in the outbox to its consumers. The outbox keeps
+// which consumers took each event, an event is only marked as dispatched once all of them did, and
+// the ones that failed it get it again later. Events are then delivered at least once to each
+// consumer, though a retried event may reach it after later events of the same ticket
+type OutboxDispatcher struct {
+	ticketsRepo ticketsRepository.Repository
+	consumers   Consumers
+	interval    time.Duration
+	logger      *slog.Logger
+}
+
+// NewOutboxDispatcher returns a dispatcher that relays the pending events every interval. The default
+// logger is used when logger is nil
+func NewOutboxDispatcher(ticketsRepo ticketsRepository.Repository, consumers Consumers, interval time.Duration, logger *slog.Logger) *OutboxDispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &OutboxDispatcher{
+		ticketsRepo: ticketsRepo,
+		consumers:   consumers,
+		interval:    interval,
+		logger:      logger,
+	}
+}
+
+// Run relays the pending events until the context is done
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// full batches mean there may be more events waiting
+			for d.Dispatch(ctx) == outboxBatchSize {
+				if ctx.Err() != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Dispatch relays a batch of the pending events, oldest first, and returns how many of them it
+// claimed. An event a consumer fails to take, or one that cannot be read, does not hold back the
+// rest of the batch
+func (d *OutboxDispatcher) Dispatch(ctx context.Context) int {
+	now := time.Now().UTC()
+
+	events, err := d.ticketsRepo.ClaimOutboxEvents(ctx, now, now.Add(outboxClaimDuration), outboxBatchSize)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "claiming_outbox_events_failed", "error", err)
+		return 0
+	}
+
+	// the claimed events are relayed and marked even when the context is done
+	ctx = context.WithoutCancel(ctx)
+
+	for _, event := range events {
+		if event.Err != nil {
+			d.logger.ErrorContext(ctx, "reading_outbox_event_failed", "event_id", event.Event.EventID, "error", event.Err)
+
+			err = d.ticketsRepo.MarkOutboxEventDead(ctx, event.Event.EventID, event.Err.Error())
+			if err != nil {
+				d.logger.ErrorContext(ctx, "marking_outbox_event_failed", "event_id", event.Event.EventID, "error", err)
+			}
+
+			continue
+		}
+
+		deliveredTo, err := d.relay(ctx, event)
+		if err == nil {
+			err = d.ticketsRepo.MarkOutboxEventDispatched(ctx, event.Event.EventID)
+		} else {
+			retryAt := time.Now().UTC().Add(outboxBackoff(event.Attempts + 1))
+			err = d.ticketsRepo.MarkOutboxEventFailed(ctx, event.Event.EventID, deliveredTo, err.Error(), retryAt)
+		}
+
+		if err != nil {
+			d.logger.ErrorContext(ctx, "marking_outbox_event_failed", "event_id", event.Event.EventID, "error", err)
+		}
+	}
+
+	return len(events)
+}
+
+// relay publishes the event to the consumers that did not take it yet and returns the ones that
+// have it now, along with the first error of the rest
+func (d *OutboxDispatcher) relay(ctx context.Context, event models.OutboxEvent) ([]string, error) {
+	deliveredTo := append([]string{}, event.DeliveredTo...)
+
+	names := make([]string, 0, len(d.consumers))
+	for name := range d.consumers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var firstErr error
+
+	for _, name := range names {
+		if slices.Contains(event.DeliveredTo, name) {
+			continue
+		}
+
+		err := d.consumers[name].Publish(ctx, event.Event)
+		if err != nil {
+			d.logger.ErrorContext(ctx, "relaying_outbox_event_failed", "event_id", event.Event.EventID, "consumer", name, "error", err)
+
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", name, err)
+			}
+
+			continue
+		}
+
+		deliveredTo = append(deliveredTo, name)
+	}
+
+	return deliveredTo, firstErr
+}
+
+// outboxBackoff doubles the wait after every failed attempt
+func outboxBackoff(attempt int) time.Duration {
+	backoff := outboxInitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+
+	return backoff
+}
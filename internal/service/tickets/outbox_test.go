@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+// publisherMock records the events it takes and fails while err is set
+type publisherMock struct {
+	events []models.TicketEvent
+	err    error
+}
+
+func (m *publisherMock) Publish(ctx context.Context, event models.TicketEvent) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.events = append(m.events, event)
+
+	return nil
+}
+
+func TestUpdateTicketSavesChangeAndEventsTogether(t *testing.T) {
+	c := require.New(t)
+
+	repo := newTicketsRepoMock(newTestTicket())
+	s := New(repo, newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	patch := httputils.MergePatchRequest(`{"status":"in_progress"}`)
+
+	repo.changesErr = errors.New("connection lost")
+
+	_, err := s.UpdateTicket(context.Background(), patch, 10, admin)
+	c.Equal(repo.changesErr, err)
+	c.Equal(models.TicketTypePending, repo.tickets[10].Status)
+	c.Empty(repo.outbox)
+
+	repo.changesErr = nil
+
+	_, err = s.UpdateTicket(context.Background(), patch, 10, admin)
+	c.Nil(err)
+	c.Len(repo.changes, 1)
+	c.Len(repo.outbox, 2)
+	c.Equal(models.TicketEventUpdated, repo.outbox[0].event.Event.Type)
+	c.Equal(models.TicketEventStatusChanged, repo.outbox[1].event.Event.Type)
+	c.Equal(models.TicketTypePending, repo.outbox[1].event.Event.PreviousStatus)
+}
+
+func TestOutboxDispatcherRetriesUntilPublished(t *testing.T) {
+	c := require.New(t)
+
+	repo := newTicketsRepoMock()
+	c.Nil(repo.SaveOutboxEvent(context.Background(), models.NewTicketEvent(models.TicketEventCreated, newTestTicket(), creatorID)))
+	c.Nil(repo.SaveOutboxEvent(context.Background(), models.NewTicketEvent(models.TicketEventCommented, newTestTicket(), adminID)))
+
+	publisher := &publisherMock{err: errors.New("connection lost")}
+	dispatcher := NewOutboxDispatcher(repo, Consumers{"webhooks": publisher}, time.Second, nil)
+
+	// the failure of the first event does not hold back the second one
+	c.Equal(2, dispatcher.Dispatch(context.Background()))
+	c.Equal(1, repo.outbox[0].event.Attempts)
+	c.Equal(1, repo.outbox[1].event.Attempts)
+	c.False(repo.outbox[0].dispatched)
+	c.True(repo.outbox[0].retryAt.After(time.Now()))
+
+	// not due yet
+	publisher.err = nil
+	c.Equal(0, dispatcher.Dispatch(context.Background()))
+	c.Empty(publisher.events)
+
+	repo.outbox[0].retryAt = time.Time{}
+	repo.outbox[1].retryAt = time.Time{}
+	c.Equal(2, dispatcher.Dispatch(context.Background()))
+	c.Equal(int64(1), publisher.events[0].EventID)
+	c.Equal(int64(2), publisher.events[1].EventID)
+	c.True(repo.outbox[0].dispatched)
+	c.True(repo.outbox[1].dispatched)
+
+	c.Equal(0, dispatcher.Dispatch(context.Background()))
+}
+
+func TestOutboxDispatcherRetriesOnlyTheFailedConsumers(t *testing.T) {
+	c := require.New(t)
+
+	repo := newTicketsRepoMock()
+	c.Nil(repo.SaveOutboxEvent(context.Background(), models.NewTicketEvent(models.TicketEventCreated, newTestTicket(), creatorID)))
+
+	failing := &publisherMock{err: errors.New("connection lost")}
+	working := &publisherMock{}
+	dispatcher := NewOutboxDispatcher(repo, Consumers{"notifications": failing, "events": working}, time.Second, nil)
+
+	c.Equal(1, dispatcher.Dispatch(context.Background()))
+	c.False(repo.outbox[0].dispatched)
+	c.Equal([]string{"events"}, repo.outbox[0].event.DeliveredTo)
+	c.Len(working.events, 1)
+
+	failing.err = nil
+	repo.outbox[0].retryAt = time.Time{}
+
+	c.Equal(1, dispatcher.Dispatch(context.Background()))
+	c.True(repo.outbox[0].dispatched)
+	c.Len(failing.events, 1)
+	c.Len(working.events, 1)
+}
+
+func TestOutboxDispatcherGivesUpOnUnreadableEvents(t *testing.T) {
+	c := require.New(t)
+
+	repo := newTicketsRepoMock()
+	c.Nil(repo.SaveOutboxEvent(context.Background(), models.NewTicketEvent(models.TicketEventCreated, newTestTicket(), creatorID)))
+	c.Nil(repo.SaveOutboxEvent(context.Background(), models.NewTicketEvent(models.TicketEventCommented, newTestTicket(), adminID)))
+
+	repo.outbox[0].event.Err = errors.New("unexpected end of JSON input")
+
+	publisher := &publisherMock{}
+	dispatcher := NewOutboxDispatcher(repo, Consumers{"webhooks": publisher}, time.Second, nil)
+
+	c.Equal(2, dispatcher.Dispatch(context.Background()))
+	c.True(repo.outbox[0].dead)
+	c.True(repo.outbox[1].dispatched)
+	c.Len(publisher.events, 1)
+	c.Equal(int64(2), publisher.events[0].EventID)
+
+	c.Equal(0, dispatcher.Dispatch(context.Background()))
+}
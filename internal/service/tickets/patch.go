@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrImmutableField immutable field
+	ErrImmutableField = httputils.NewBadRequestError("only title, description, type, severity, priority, status and ownerID can be changed")
+	// ErrInvalidSeverity invalid severity
+	ErrInvalidSeverity = httputils.NewBadRequestError("invalid severity")
+	// ErrInvalidPriority invalid priority
+	ErrInvalidPriority = httputils.NewBadRequestError("invalid priority")
+)
+
+// patchTicket applies the patch to the JSON representation of the ticket and returns the result
+func patchTicket(ticket models.Ticket, patch httputils.Patch) (models.Ticket, error) {
+	document, err := json.Marshal(ticket)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	patchedDocument, err := patch.Apply(document)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patchedDocument))
+	decoder.DisallowUnknownFields()
+
+	patchedTicket := models.Ticket{}
+	err = decoder.Decode(&patchedTicket)
+	if err != nil {
+		return models.Ticket{}, httputils.NewBadRequestError("invalid patched ticket: " + err.Error())
+	}
+
+	return patchedTicket, nil
+}
+
+// applyTicketChanges copies the mutable fields of the patched ticket into the ticket, validating
+// each of them. It returns whether anything changed
+func applyTicketChanges(ticket *models.Ticket, patched models.Ticket, requester models.Requester) (bool, error) {
+	if patched.TicketID != ticket.TicketID ||
+		patched.CreatorID != ticket.CreatorID ||
+		!sameID(patched.TeamID, ticket.TeamID) ||
+		!sameTime(patched.CreatedAt, ticket.CreatedAt) ||
+		!sameTime(patched.UpdatedAt, ticket.UpdatedAt) ||
+		!sameTime(patched.ResolvedAt, ticket.ResolvedAt) ||
+		!sameTime(patched.FirstResponseDueAt, ticket.FirstResponseDueAt) ||
+		!sameTime(patched.FirstRespondedAt, ticket.FirstRespondedAt) ||
+		!sameTime(patched.DueAt, ticket.DueAt) ||
+		patched.Breached != ticket.Breached {
+		return false, ErrImmutableField
+	}
+
+	changed := false
+
+	if patched.Title != ticket.Title {
+		if patched.Title == "" {
+			return false, ErrMissingTitle
+		}
+
+		ticket.Title = patched.Title
+		changed = true
+	}
+
+	if patched.Description != ticket.Description {
+		if patched.Description == "" {
+			return false, ErrMissingDescription
+		}
+
+		ticket.Description = patched.Description
+		changed = true
+	}
+
+	if patched.Type != ticket.Type {
+		if !models.IsValidTicketType(patched.Type) {
+			return false, ErrInvalidTicketType
+		}
+
+		ticket.Type = patched.Type
+		changed = true
+	}
+
+	if patched.Severity != ticket.Severity {
+		if !patched.Severity.IsValid() {
+			return false, ErrInvalidSeverity
+		}
+
+		ticket.Severity = patched.Severity
+		changed = true
+	}
+
+	if patched.Priority != ticket.Priority {
+		if !patched.Priority.IsValid() {
+			return false, ErrInvalidPriority
+		}
+
+		ticket.Priority = patched.Priority
+		changed = true
+	}
+
+	if !sameID(patched.OwnerID, ticket.OwnerID) {
+		if !requester.Can(models.PermissionTicketAssign) {
+			return false, httputils.ForbiddenError
+		}
+
+		if patched.OwnerID != nil && *patched.OwnerID <= 0 {
+			return false, ErrInvalidOwnerID
+		}
+
+		ticket.OwnerID = patched.OwnerID
+		changed = true
+	}
+
+	if patched.Status != ticket.Status {
+		err := transitionStatus(ticket, patched.Status, requester)
+		if err != nil {
+			return false, err
+		}
+
+		changed = true
+	}
+
+	return changed, nil
+}
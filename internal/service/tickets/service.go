@@ -4,12 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/syned13/ticket-support-back/internal/models"
-	repository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams"
 	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
 	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
 	"github.com/syned13/ticket-support-back/pkg/httputils"
+	"github.com/syned13/ticket-support-back/pkg/storage"
+)
+
+const (
+	searchPageSize = 50
+	// maxSearchOffset keeps searches from ranking the whole table to skip deep pages, past it the
+	// query should be refined instead
+	maxSearchOffset = 500
 )
 
 var (
@@ -23,12 +34,12 @@ var (
 	ErrInvalidOwnerID     = httputils.NewBadRequestError("invalid owner id")
 	ErrInvalidStatus      = httputils.NewBadRequestError("invalid status")
 
-	// ErrMissingPatchOperation missing patch operation
-	ErrMissingPatchOperation = httputils.NewBadRequestError("missing patch operation")
-	// ErrMissingPatchPath missing patch path
-	ErrMissingPatchPath = httputils.NewBadRequestError("missing patch path")
-	// ErrMissingPatchValue missing patch value
-	ErrMissingPatchValue = httputils.NewBadRequestError("missing patch value")
+	// ErrMissingSearchQuery missing search query
+	ErrMissingSearchQuery = httputils.NewBadRequestError("missing search query")
+	// ErrInvalidOffset invalid offset
+	ErrInvalidOffset = httputils.NewBadRequestError(fmt.Sprintf("invalid offset, it must be between 0 and %d", maxSearchOffset))
+	// ErrMissingCommentBody missing comment body
+	ErrMissingCommentBody = httputils.NewBadRequestError("missing comment body")
 
 	ErrMissingCreatorID = errors.New("missing priority")
 )
@@ -36,12 +47,28 @@ var (
 type service struct {
 	ticketsRepo ticketsRepository.Repository
 	usersRepo   usersRepository.Repository
+	teamsRepo   teamsRepository.Repository
+	assigner    AssignmentStrategy
+	storage     storage.Storage
+	logger      *slog.Logger
 }
 
-func New(ticketsRepo ticketsRepository.Repository, usersRepo usersRepository.Repository) Service {
+// New returns a new tickets service. New tickets are routed to the team of their type, then the
+// assigner picks their owner, they are left unassigned when it is nil. Attachments are kept in the
+// given storage. Ticket events are saved in the outbox along with the changes they describe, see
+// OutboxDispatcher. The default logger is used when logger is nil
+func New(ticketsRepo ticketsRepository.Repository, usersRepo usersRepository.Repository, teamsRepo teamsRepository.Repository, assigner AssignmentStrategy, attachmentsStorage storage.Storage, logger *slog.Logger) Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	return service{
 		ticketsRepo: ticketsRepo,
 		usersRepo:   usersRepo,
+		teamsRepo:   teamsRepo,
+		assigner:    assigner,
+		storage:     attachmentsStorage,
+		logger:      logger,
 	}
 }
 
@@ -52,8 +79,40 @@ func (s service) CreateTicket(ctx context.Context, ticket models.Ticket) (models
 	}
 
 	ticket.Status = models.TicketTypePending
+	ticket.OwnerID = nil
+	ticket.TeamID = nil
+	ticket.FirstRespondedAt = nil
+	ticket.Breached = false
+	applySLAPolicy(&ticket, time.Now())
 
-	createdTicket, err := s.ticketsRepo.SaveTicket(ctx, ticket)
+	teamID, err := s.teamsRepo.GetTeamForTicketType(ctx, ticket.Type)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "routing_ticket_failed", "error", err)
+	} else {
+		ticket.TeamID = teamID
+	}
+
+	if s.assigner != nil {
+		ownerID, err := s.assigner.PickOwner(ctx, ticket)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "auto_assigning_ticket_failed", "error", err)
+		} else {
+			ticket.OwnerID = ownerID
+		}
+	}
+
+	var createdTicket models.Ticket
+
+	err = s.ticketsRepo.WithTx(ctx, func(repo ticketsRepository.Repository) error {
+		var err error
+
+		createdTicket, err = repo.SaveTicket(ctx, ticket)
+		if err != nil {
+			return err
+		}
+
+		return saveEvents(ctx, repo, models.NewTicketEvent(models.TicketEventCreated, createdTicket, createdTicket.CreatorID))
+	})
 	if err != nil {
 		return models.Ticket{}, err
 	}
@@ -74,7 +133,7 @@ func validateCreateTicketParams(ticket models.Ticket) error {
 		return ErrMissingType
 	}
 
-	if models.IsValidTicketType(ticket.Type) {
+	if !models.IsValidTicketType(ticket.Type) {
 		return ErrInvalidTicketType
 	}
 
@@ -94,46 +153,66 @@ func validateCreateTicketParams(ticket models.Ticket) error {
 	return nil
 }
 
-func (s service) GetTickets(ctx context.Context, userID int64, userType models.UserType, lastID int64) (GetTicketsResponse, error) {
-	var tickets []models.Ticket
-	var err error
-	var last int64
+// GetTickets returns a page of the tickets matching the filter. The ones allowed to read every ticket
+// see the queues of their teams, or all of them when they are in none or ask for every team, while
+// anybody else only sees their own ones
+func (s service) GetTickets(ctx context.Context, filter GetTicketsFilter, requester models.Requester) (GetTicketsResponse, error) {
+	repoFilter, err := filter.toRepositoryFilter(requester)
+	if err != nil {
+		return GetTicketsResponse{}, err
+	}
 
-	if userType == models.UserTypeAdmin {
-		tickets, last, err = s.ticketsRepo.GetTickets(ctx, lastID)
-	} else {
-		tickets, last, err = s.ticketsRepo.GetTicketsByCreator(ctx, userID, lastID)
+	if filter.TeamID == nil && !filter.AllTeams && requester.Can(models.PermissionTicketReadAll) {
+		repoFilter.TeamIDs, err = s.teamsRepo.ListUserTeamIDs(ctx, requester.UserID)
+		if err != nil {
+			return GetTicketsResponse{}, err
+		}
 	}
 
-	if errors.Is(err, ticketsRepository.ErrNotFound) {
-		return GetTicketsResponse{Last: 0, Total: 0, Tickets: []models.Ticket{}}, nil
+	tickets, next, err := s.ticketsRepo.ListTickets(ctx, repoFilter)
+	if errors.Is(err, ticketsRepository.ErrInvalidCursor) {
+		return GetTicketsResponse{}, ErrInvalidCursor
 	}
 
 	if err != nil {
 		return GetTicketsResponse{}, err
 	}
 
-	return GetTicketsResponse{Tickets: tickets, Last: last, Total: len(tickets)}, nil
+	var last int64
+	if len(tickets) > 0 {
+		last = tickets[len(tickets)-1].TicketID
+	}
+
+	return GetTicketsResponse{Tickets: tickets, Last: last, Total: len(tickets), Next: encodeCursor(next)}, nil
 }
 
-func (s service) GetTicket(ctx context.Context, ticketID int64) (models.Ticket, error) {
-	// TODO: only the own creator or an admin can get a ticket
-	ticket, err := s.ticketsRepo.GetTicket(ctx, ticketID)
-	if errors.Is(err, ticketsRepository.ErrNotFound) {
-		return models.Ticket{}, httputils.NewNotFoundError("ticket")
+// SearchTickets searches the tickets visible to the requester by title and description. There is no
+// next page when the current one is short or the next one would start past maxSearchOffset
+func (s service) SearchTickets(ctx context.Context, query string, offset int, requester models.Requester) (SearchTicketsResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return SearchTicketsResponse{}, ErrMissingSearchQuery
 	}
 
+	if offset < 0 || offset > maxSearchOffset {
+		return SearchTicketsResponse{}, ErrInvalidOffset
+	}
+
+	results, err := s.ticketsRepo.SearchTickets(ctx, query, visibleCreatorID(requester), searchPageSize, offset)
 	if err != nil {
-		return models.Ticket{}, err
+		return SearchTicketsResponse{}, err
 	}
 
-	return ticket, nil
-}
+	response := SearchTicketsResponse{Results: results, Total: len(results)}
+
+	if next := offset + len(results); len(results) == searchPageSize && next <= maxSearchOffset {
+		response.Next = &next
+	}
 
-func (s service) UpdateTicket(ctx context.Context, request httputils.PatchRequest, ticketID int64) (models.Ticket, error) {
-	ticketChange := models.TicketChange{}
-	updatedStatus := false
+	return response, nil
+}
 
+func (s service) GetTicket(ctx context.Context, ticketID int64, requester models.Requester) (models.Ticket, error) {
 	ticket, err := s.ticketsRepo.GetTicket(ctx, ticketID)
 	if errors.Is(err, ticketsRepository.ErrNotFound) {
 		return models.Ticket{}, httputils.NewNotFoundError("ticket")
@@ -143,68 +222,168 @@ func (s service) UpdateTicket(ctx context.Context, request httputils.PatchReques
 		return models.Ticket{}, err
 	}
 
-	ticketChange.TicketID = ticket.TicketID
-	ticketChange.CreatorID = ticket.CreatorID
+	err = authorizeTicketView(requester, ticket)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return ticket, nil
+}
+
+func (s service) UpdateTicket(ctx context.Context, patch httputils.Patch, ticketID int64, requester models.Requester) (models.Ticket, error) {
+	var updatedTicket models.Ticket
+
+	// the ticket is read locked so the changes made meanwhile, like the breach checker flagging it, are
+	// neither overwritten nor missing from its history. It is saved along with its history and its
+	// events so none of them gets lost
+	err := s.ticketsRepo.WithTx(ctx, func(repo ticketsRepository.Repository) error {
+		ticket, err := repo.GetTicketForUpdate(ctx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		err = authorizeTicketUpdate(requester, ticket)
+		if err != nil {
+			return err
+		}
 
-	// 	TODO: move this process to a new function to separate concerns
-	for _, op := range request {
-		if op.Op == "" {
-			return models.Ticket{}, ErrMissingPatchOperation
+		patchedTicket, err := patchTicket(ticket, patch)
+		if err != nil {
+			return err
 		}
 
-		if op.Path == "" {
-			return models.Ticket{}, ErrMissingPatchPath
+		previousTicket := ticket
+		previousStatus := ticket.Status
+		previousOwnerID := ticket.OwnerID
+		previousSeverity := ticket.Severity
+		previousPriority := ticket.Priority
+
+		changed, err := applyTicketChanges(&ticket, patchedTicket, requester)
+		if err != nil {
+			return err
 		}
 
-		if op.Value == "" {
-			return models.Ticket{}, ErrMissingPatchValue
+		if !changed {
+			updatedTicket = ticket
+			return nil
 		}
 
-		if op.Op != "update" { // TODO: remove maginc string
-			return models.Ticket{}, httputils.NewBadRequestError("invalid patch operation: " + op.Op)
+		now := time.Now()
+
+		if ticket.Severity != previousSeverity || ticket.Priority != previousPriority {
+			applySLAPolicy(&ticket, now)
 		}
 
-		switch op.Path {
-		case "ownerID":
-			id, ok := op.Value.(int64)
-			if !ok {
-				return models.Ticket{}, ErrInvalidOwnerID
-			}
+		if ticket.Status != previousStatus {
+			markFirstResponse(&ticket, requester, now)
+		}
+
+		// a breach is never taken back, not even when the deadlines move later
+		if ticket.Status != models.TicketStatusCancelled {
+			ticket.Breached = ticket.Breached || isBreached(ticket, now)
+		}
 
-			ticket.OwnerID = &id
-		case "status":
-			status, ok := op.Value.(string)
-			if !ok {
-				return models.Ticket{}, ErrInvalidStatus
+		if ticket.OwnerID != nil && !sameID(ticket.OwnerID, previousOwnerID) {
+			err = s.validateOwner(ctx, *ticket.OwnerID)
+			if err != nil {
+				return err
 			}
+		}
 
-			updatedStatus = true
-			// TODO: validate status, only valid statuses and only final statuses
-			ticket.Status = models.TicketStatus(status)
-			ticketChange.To = models.TicketStatus(status)
+		updatedTicket, err = repo.UpdateTicket(ctx, ticket)
+		if err != nil {
+			return err
+		}
+
+		err = saveChanges(ctx, repo, ticketChanges(previousTicket, updatedTicket, requester.UserID))
+		if err != nil {
+			return err
+		}
+
+		events := []models.TicketEvent{models.NewTicketEvent(models.TicketEventUpdated, updatedTicket, requester.UserID)}
+
+		if updatedTicket.Status != previousStatus {
+			event := models.NewTicketEvent(models.TicketEventStatusChanged, updatedTicket, requester.UserID)
+			event.PreviousStatus = previousStatus
+			events = append(events, event)
+		}
+
+		if updatedTicket.OwnerID != nil && !sameID(updatedTicket.OwnerID, previousOwnerID) {
+			events = append(events, models.NewTicketEvent(models.TicketEventAssigned, updatedTicket, requester.UserID))
 		}
-	}
 
-	updatedTicket, err := s.ticketsRepo.UpdateTicket(ctx, ticket)
+		return saveEvents(ctx, repo, events...)
+	})
 	if errors.Is(err, ticketsRepository.ErrNotFound) {
 		return models.Ticket{}, httputils.NewNotFoundError("ticket")
 	}
 
-	if errors.Is(err, repository.ErrNothingToUpdate) {
-		return models.Ticket{}, httputils.NewBadRequestError("nothing to update")
+	if err != nil {
+		return models.Ticket{}, err
 	}
 
-	if updatedStatus {
-		fmt.Println(ticketChange)
-		err = s.ticketsRepo.SaveTicketChange(ctx, ticketChange)
+	return updatedTicket, nil
+}
+
+func (s service) CreateComment(ctx context.Context, comment models.TicketComment, requester models.Requester) (models.TicketComment, error) {
+	if comment.Body == "" {
+		return models.TicketComment{}, ErrMissingCommentBody
+	}
+
+	ticket, err := s.GetTicket(ctx, comment.TicketID, requester)
+	if err != nil {
+		return models.TicketComment{}, err
+	}
+
+	// internal notes are meant for the ones allowed to read them only
+	if comment.Internal && !requester.Can(models.PermissionTicketInternal) {
+		return models.TicketComment{}, httputils.ForbiddenError
+	}
+
+	comment.AuthorID = requester.UserID
+
+	var createdComment models.TicketComment
+
+	err = s.ticketsRepo.WithTx(ctx, func(repo ticketsRepository.Repository) error {
+		var err error
+
+		createdComment, err = repo.SaveTicketComment(ctx, comment)
 		if err != nil {
-			fmt.Println("could not add change to change log")
+			return err
 		}
+
+		// internal notes are not seen by the creator so they do not count as a response
+		if !comment.Internal {
+			ticket, err = repo.GetTicketForUpdate(ctx, ticket.TicketID)
+			if err != nil {
+				return err
+			}
+
+			if markFirstResponse(&ticket, requester, time.Now()) {
+				ticket, err = repo.UpdateTicket(ctx, ticket)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		event := models.NewTicketEvent(models.TicketEventCommented, ticket, requester.UserID)
+		event.Comment = &createdComment
+
+		return saveEvents(ctx, repo, event)
+	})
+	if err != nil {
+		return models.TicketComment{}, err
 	}
 
-	return updatedTicket, nil
+	return createdComment, nil
 }
 
-func (s service) GetTicketChanges(ctx context.Context, creatorID int64) ([]models.TicketChange, error) {
-	return s.ticketsRepo.GetTicketChanges(ctx, creatorID)
+func (s service) GetComments(ctx context.Context, ticketID int64, requester models.Requester) ([]models.TicketComment, error) {
+	_, err := s.GetTicket(ctx, ticketID, requester)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ticketsRepo.GetTicketComments(ctx, ticketID, requester.Can(models.PermissionTicketInternal))
 }
@@ -0,0 +1,635 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	usersRepository "github.com/syned13/ticket-support-back/internal/repositories/users"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	creatorID  int64 = 1
+	strangerID int64 = 2
+	adminID    int64 = 3
+)
+
+var (
+	creator  = models.Requester{UserID: creatorID, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]}
+	stranger = models.Requester{UserID: strangerID, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]}
+	admin    = models.Requester{UserID: adminID, Role: models.RoleAdmin, Permissions: models.DefaultRolePermissions[models.RoleAdmin]}
+)
+
+// ticketsRepoMock is an in-memory tickets repository. Methods not overridden panic when called
+type ticketsRepoMock struct {
+	ticketsRepository.Repository
+	tickets     map[int64]models.Ticket
+	changes     []models.TicketChange
+	comments    []models.TicketComment
+	attachments []models.TicketAttachment
+	outbox      []outboxRow
+	// changesErr makes SaveTicketChange fail when set
+	changesErr error
+}
+
+// outboxRow is an event in the outbox of ticketsRepoMock
+type outboxRow struct {
+	event      models.OutboxEvent
+	retryAt    time.Time
+	dispatched bool
+	dead       bool
+}
+
+func newTicketsRepoMock(tickets ...models.Ticket) *ticketsRepoMock {
+	repo := &ticketsRepoMock{tickets: map[int64]models.Ticket{}}
+	for _, ticket := range tickets {
+		repo.tickets[ticket.TicketID] = ticket
+	}
+
+	return repo
+}
+
+// WithTx restores the tickets, the change log and the outbox when fn fails
+func (m *ticketsRepoMock) WithTx(ctx context.Context, fn func(repo ticketsRepository.Repository) error) error {
+	tickets := map[int64]models.Ticket{}
+	for ticketID, ticket := range m.tickets {
+		tickets[ticketID] = ticket
+	}
+
+	changes := append([]models.TicketChange{}, m.changes...)
+	outbox := append([]outboxRow{}, m.outbox...)
+
+	err := fn(m)
+	if err != nil {
+		m.tickets, m.changes, m.outbox = tickets, changes, outbox
+	}
+
+	return err
+}
+
+func (m *ticketsRepoMock) GetTicket(ctx context.Context, ticketID int64) (models.Ticket, error) {
+	ticket, ok := m.tickets[ticketID]
+	if !ok {
+		return models.Ticket{}, ticketsRepository.ErrNotFound
+	}
+
+	return ticket, nil
+}
+
+func (m *ticketsRepoMock) GetTicketForUpdate(ctx context.Context, ticketID int64) (models.Ticket, error) {
+	return m.GetTicket(ctx, ticketID)
+}
+
+func (m *ticketsRepoMock) UpdateTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
+	m.tickets[ticket.TicketID] = ticket
+	return ticket, nil
+}
+
+func (m *ticketsRepoMock) SaveTicketChange(ctx context.Context, ticketChange models.TicketChange) error {
+	if m.changesErr != nil {
+		return m.changesErr
+	}
+
+	m.changes = append(m.changes, ticketChange)
+	return nil
+}
+
+// ListActivity lists the changes only, without pagination
+func (m *ticketsRepoMock) ListActivity(ctx context.Context, filter ticketsRepository.ActivityFilter) ([]models.TicketActivity, *ticketsRepository.ActivityCursor, error) {
+	activity := []models.TicketActivity{}
+	for i, change := range m.changes {
+		if (filter.CreatorID != nil && change.CreatorID != *filter.CreatorID) ||
+			(filter.TicketID != nil && change.TicketID != *filter.TicketID) ||
+			(filter.ActorID != nil && change.ChangedBy != *filter.ActorID) {
+			continue
+		}
+
+		activity = append(activity, models.TicketActivity{
+			Type:     models.TicketActivityChange,
+			TicketID: change.TicketID,
+			ActorID:  change.ChangedBy,
+			Change:   &m.changes[i],
+		})
+	}
+
+	return activity, nil, nil
+}
+
+func (m *ticketsRepoMock) GetTicketHistory(ctx context.Context, ticketID int64) ([]models.TicketChange, error) {
+	changes := []models.TicketChange{}
+	for _, change := range m.changes {
+		if change.TicketID == ticketID {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (m *ticketsRepoMock) GetTicketChanges(ctx context.Context, creatorID int64) ([]models.TicketChange, error) {
+	changes := []models.TicketChange{}
+	for _, change := range m.changes {
+		if change.CreatorID == creatorID {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (m *ticketsRepoMock) GetTicketComments(ctx context.Context, ticketID int64, includeInternal bool) ([]models.TicketComment, error) {
+	comments := []models.TicketComment{}
+	for _, comment := range m.comments {
+		if comment.TicketID == ticketID && (includeInternal || !comment.Internal) {
+			comments = append(comments, comment)
+		}
+	}
+
+	return comments, nil
+}
+
+func (m *ticketsRepoMock) SaveTicketComment(ctx context.Context, comment models.TicketComment) (models.TicketComment, error) {
+	comment.CommentID = int64(len(m.comments) + 1)
+	m.comments = append(m.comments, comment)
+
+	return comment, nil
+}
+
+func (m *ticketsRepoMock) SearchTickets(ctx context.Context, query string, creatorID *int64, limit int, offset int) ([]models.TicketSearchResult, error) {
+	results := []models.TicketSearchResult{}
+	for _, ticket := range m.tickets {
+		if creatorID != nil && ticket.CreatorID != *creatorID {
+			continue
+		}
+
+		if strings.Contains(ticket.Title, query) || strings.Contains(ticket.Description, query) {
+			results = append(results, models.TicketSearchResult{Ticket: ticket})
+		}
+	}
+
+	return results, nil
+}
+
+// ListTickets filters by creator and team only, sorted by id and without pagination
+func (m *ticketsRepoMock) ListTickets(ctx context.Context, filter ticketsRepository.TicketFilter) ([]models.Ticket, *ticketsRepository.Cursor, error) {
+	tickets := []models.Ticket{}
+	for _, ticket := range m.tickets {
+		if filter.CreatorID != nil && ticket.CreatorID != *filter.CreatorID {
+			continue
+		}
+
+		if len(filter.TeamIDs) > 0 && (ticket.TeamID == nil || !containsID(filter.TeamIDs, *ticket.TeamID)) {
+			continue
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].TicketID < tickets[j].TicketID })
+
+	return tickets, nil, nil
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *ticketsRepoMock) SaveTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
+	ticket.TicketID = int64(len(m.tickets) + 1)
+	m.tickets[ticket.TicketID] = ticket
+
+	return ticket, nil
+}
+
+func (m *ticketsRepoMock) CountOpenTicketsByOwner(ctx context.Context) (map[int64]int, error) {
+	counts := map[int64]int{}
+	for _, ticket := range m.tickets {
+		if ticket.OwnerID != nil && !ticket.Status.IsFinal() {
+			counts[*ticket.OwnerID]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (m *ticketsRepoMock) SaveTicketAttachment(ctx context.Context, attachment models.TicketAttachment) (models.TicketAttachment, error) {
+	attachment.AttachmentID = int64(len(m.attachments) + 1)
+	m.attachments = append(m.attachments, attachment)
+
+	return attachment, nil
+}
+
+func (m *ticketsRepoMock) SaveOutboxEvent(ctx context.Context, event models.TicketEvent) error {
+	event.EventID = int64(len(m.outbox) + 1)
+	m.outbox = append(m.outbox, outboxRow{event: models.OutboxEvent{Event: event}})
+
+	return nil
+}
+
+func (m *ticketsRepoMock) ClaimOutboxEvents(ctx context.Context, now time.Time, claimedUntil time.Time, limit int) ([]models.OutboxEvent, error) {
+	events := []models.OutboxEvent{}
+	for i, row := range m.outbox {
+		if !row.dispatched && !row.dead && !row.retryAt.After(now) && len(events) < limit {
+			m.outbox[i].retryAt = claimedUntil
+			events = append(events, row.event)
+		}
+	}
+
+	return events, nil
+}
+
+func (m *ticketsRepoMock) MarkOutboxEventDispatched(ctx context.Context, eventID int64) error {
+	m.outbox[eventID-1].dispatched = true
+	m.outbox[eventID-1].event.Attempts++
+
+	return nil
+}
+
+func (m *ticketsRepoMock) MarkOutboxEventFailed(ctx context.Context, eventID int64, deliveredTo []string, reason string, retryAt time.Time) error {
+	m.outbox[eventID-1].retryAt = retryAt
+	m.outbox[eventID-1].event.DeliveredTo = deliveredTo
+	m.outbox[eventID-1].event.Attempts++
+
+	return nil
+}
+
+func (m *ticketsRepoMock) MarkOutboxEventDead(ctx context.Context, eventID int64, reason string) error {
+	m.outbox[eventID-1].dead = true
+	m.outbox[eventID-1].event.Attempts++
+
+	return nil
+}
+
+// usersRepoMock is an in-memory users repository. Methods not overridden panic when called
+type usersRepoMock struct {
+	usersRepository.Repository
+	users []models.User
+}
+
+// newUsersRepoMock returns a repository with the users behind the creator, stranger and admin requesters
+func newUsersRepoMock() *usersRepoMock {
+	return &usersRepoMock{users: []models.User{
+		{UserID: creatorID, Role: models.RoleRequester, Active: true},
+		{UserID: strangerID, Role: models.RoleRequester, Active: true},
+		{UserID: adminID, Role: models.RoleAdmin, Active: true},
+	}}
+}
+
+func (m *usersRepoMock) GetUser(ctx context.Context, userID int) (models.User, error) {
+	for _, user := range m.users {
+		if user.UserID == int64(userID) {
+			return user, nil
+		}
+	}
+
+	return models.User{}, usersRepository.ErrNotFound
+}
+
+func (m *usersRepoMock) ListActiveUsersWithPermission(ctx context.Context, permission models.Permission) ([]models.User, error) {
+	users := []models.User{}
+	for _, user := range m.users {
+		if models.DefaultRolePermissions[user.Role].Has(permission) && user.Active {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// teamsRepoMock is an in-memory teams repository. Methods not overridden panic when called
+type teamsRepoMock struct {
+	teamsRepository.Repository
+	teams []models.Team
+}
+
+func newTeamsRepoMock(teams ...models.Team) *teamsRepoMock {
+	return &teamsRepoMock{teams: teams}
+}
+
+func (m *teamsRepoMock) GetTeam(ctx context.Context, teamID int64) (models.Team, error) {
+	for _, team := range m.teams {
+		if team.TeamID == teamID {
+			return team, nil
+		}
+	}
+
+	return models.Team{}, teamsRepository.ErrNotFound
+}
+
+func (m *teamsRepoMock) GetTeamForTicketType(ctx context.Context, ticketType models.TicketType) (*int64, error) {
+	for _, team := range m.teams {
+		for _, routedType := range team.TicketTypes {
+			if routedType == ticketType {
+				return &team.TeamID, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *teamsRepoMock) ListUserTeamIDs(ctx context.Context, userID int64) ([]int64, error) {
+	teamIDs := []int64{}
+	for _, team := range m.teams {
+		if team.HasMember(userID) {
+			teamIDs = append(teamIDs, team.TeamID)
+		}
+	}
+
+	return teamIDs, nil
+}
+
+func newTestTicket() models.Ticket {
+	return models.Ticket{
+		TicketID:  10,
+		Title:     "printer",
+		CreatorID: creatorID,
+		Status:    models.TicketTypePending,
+	}
+}
+
+func TestGetTicketAuthorization(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	ticket, err := s.GetTicket(context.Background(), 10, creator)
+	c.Nil(err)
+	c.Equal(int64(10), ticket.TicketID)
+
+	ticket, err = s.GetTicket(context.Background(), 10, admin)
+	c.Nil(err)
+	c.Equal(int64(10), ticket.TicketID)
+
+	_, err = s.GetTicket(context.Background(), 10, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+}
+
+func TestTicketReadPathsAuthorization(t *testing.T) {
+	c := require.New(t)
+
+	ctx := context.Background()
+	ticketID := int64(10)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	_, err := s.GetComments(ctx, ticketID, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.GetTicketHistory(ctx, ticketID, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.GetTicketTimeline(ctx, ticketID, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.GetAttachments(ctx, ticketID, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.GetActivity(ctx, GetActivityFilter{TicketID: &ticketID}, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	tickets, err := s.GetTickets(ctx, GetTicketsFilter{}, stranger)
+	c.Nil(err)
+	c.Empty(tickets.Tickets)
+
+	results, err := s.SearchTickets(ctx, "printer", 0, stranger)
+	c.Nil(err)
+	c.Empty(results.Results)
+
+	tickets, err = s.GetTickets(ctx, GetTicketsFilter{AllTeams: true}, admin)
+	c.Nil(err)
+	c.Len(tickets.Tickets, 1)
+
+	results, err = s.SearchTickets(ctx, "printer", 0, creator)
+	c.Nil(err)
+	c.Len(results.Results, 1)
+}
+
+func TestGetTicketNotFound(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	_, err := s.GetTicket(context.Background(), 10, admin)
+	c.Equal(httputils.NewNotFoundError("ticket"), err)
+}
+
+func TestUpdateTicketAuthorization(t *testing.T) {
+	c := require.New(t)
+
+	request := httputils.PatchRequest{{Op: httputils.PatchOperationReplace, Path: "/status", Value: "cancelled"}}
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+	_, err := s.UpdateTicket(context.Background(), request, 10, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	s = New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+	ticket, err := s.UpdateTicket(context.Background(), request, 10, creator)
+	c.Nil(err)
+	c.Equal(models.TicketStatusCancelled, ticket.Status)
+
+	s = New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+	ticket, err = s.UpdateTicket(context.Background(), request, 10, admin)
+	c.Nil(err)
+	c.Equal(models.TicketStatusCancelled, ticket.Status)
+}
+
+func TestCreateComment(t *testing.T) {
+	c := require.New(t)
+
+	repo := newTicketsRepoMock(newTestTicket())
+	s := New(repo, newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	_, err := s.CreateComment(context.Background(), models.TicketComment{TicketID: 10}, creator)
+	c.Equal(ErrMissingCommentBody, err)
+
+	_, err = s.CreateComment(context.Background(), models.TicketComment{TicketID: 10, Body: "note", Internal: true}, creator)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.CreateComment(context.Background(), models.TicketComment{TicketID: 10, Body: "me too"}, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+	c.Empty(repo.comments)
+
+	comment, err := s.CreateComment(context.Background(), models.TicketComment{TicketID: 10, Body: "still broken"}, creator)
+	c.Nil(err)
+	c.Equal(creatorID, comment.AuthorID)
+
+	comment, err = s.CreateComment(context.Background(), models.TicketComment{TicketID: 10, Body: "replace toner", Internal: true, AuthorID: creatorID}, admin)
+	c.Nil(err)
+	c.Equal(adminID, comment.AuthorID)
+	c.True(comment.Internal)
+}
+
+func TestGetCommentsHidesInternalOnes(t *testing.T) {
+	c := require.New(t)
+
+	repo := newTicketsRepoMock(newTestTicket())
+	repo.comments = []models.TicketComment{
+		{CommentID: 1, TicketID: 10, AuthorID: creatorID, Body: "still broken"},
+		{CommentID: 2, TicketID: 10, AuthorID: adminID, Body: "replace toner", Internal: true},
+	}
+
+	s := New(repo, newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	comments, err := s.GetComments(context.Background(), 10, creator)
+	c.Nil(err)
+	c.Len(comments, 1)
+	c.Equal(int64(1), comments[0].CommentID)
+
+	comments, err = s.GetComments(context.Background(), 10, admin)
+	c.Nil(err)
+	c.Len(comments, 2)
+
+	_, err = s.GetComments(context.Background(), 10, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+}
+
+func TestGetActivityScopes(t *testing.T) {
+	c := require.New(t)
+
+	inProgress := string(models.TicketTypeInProgress)
+	ticketID := int64(10)
+
+	repo := newTicketsRepoMock(newTestTicket())
+	repo.changes = []models.TicketChange{
+		{ChangeID: 1, TicketID: 10, CreatorID: creatorID, ChangedBy: adminID, Field: models.TicketFieldStatus, To: &inProgress},
+	}
+
+	s := New(repo, newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	response, err := s.GetActivity(context.Background(), GetActivityFilter{}, creator)
+	c.Nil(err)
+	c.Len(response.Activity, 1)
+
+	response, err = s.GetActivity(context.Background(), GetActivityFilter{}, stranger)
+	c.Nil(err)
+	c.Len(response.Activity, 0)
+
+	_, err = s.GetActivity(context.Background(), GetActivityFilter{All: true}, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	_, err = s.GetActivity(context.Background(), GetActivityFilter{TicketID: &ticketID}, stranger)
+	c.Equal(httputils.ForbiddenError, err)
+
+	response, err = s.GetActivity(context.Background(), GetActivityFilter{All: true}, admin)
+	c.Nil(err)
+	c.Len(response.Activity, 1)
+
+	_, err = s.GetActivity(context.Background(), GetActivityFilter{Types: []models.TicketActivityType{"created"}}, admin)
+	c.Equal(ErrInvalidActivityType, err)
+
+	_, err = s.GetActivity(context.Background(), GetActivityFilter{Cursor: "bm9wZQ"}, admin)
+	c.Equal(ErrInvalidCursor, err)
+}
+
+func TestUpdateTicketReassignsOwner(t *testing.T) {
+	c := require.New(t)
+
+	request := httputils.PatchRequest{}
+	err := json.Unmarshal([]byte(`[{"op":"add","path":"/ownerID","value":3}]`), &request)
+	c.Nil(err)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	_, err = s.UpdateTicket(context.Background(), request, 10, creator)
+	c.Equal(httputils.ForbiddenError, err)
+
+	ticket, err := s.UpdateTicket(context.Background(), request, 10, admin)
+	c.Nil(err)
+	c.NotNil(ticket.OwnerID)
+	c.Equal(adminID, *ticket.OwnerID)
+}
+
+func TestUpdateTicketOptimisticTest(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	request := httputils.PatchRequest{
+		{Op: httputils.PatchOperationTest, Path: "/title", Value: "scanner"},
+		{Op: httputils.PatchOperationReplace, Path: "/title", Value: "printer on fire"},
+	}
+
+	_, err := s.UpdateTicket(context.Background(), request, 10, creator)
+	c.Equal(httputils.ErrPatchTestFailed, err)
+
+	request[0].Value = "printer"
+
+	ticket, err := s.UpdateTicket(context.Background(), request, 10, creator)
+	c.Nil(err)
+	c.Equal("printer on fire", ticket.Title)
+}
+
+func TestUpdateTicketImmutableFields(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	_, err := s.UpdateTicket(context.Background(), httputils.MergePatchRequest(`{"creatorID":2}`), 10, admin)
+	c.Equal(ErrImmutableField, err)
+
+	_, err = s.UpdateTicket(context.Background(), httputils.MergePatchRequest(`{"unknown":2}`), 10, admin)
+	c.NotNil(err)
+}
+
+func TestUpdateTicketMergePatch(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	ticket, err := s.UpdateTicket(context.Background(), httputils.MergePatchRequest(`{"title":"scanner","priority":3}`), 10, creator)
+	c.Nil(err)
+	c.Equal("scanner", ticket.Title)
+	c.Equal(models.TicketPriorityHigh, ticket.Priority)
+}
+
+func TestSearchTicketsVisibility(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	response, err := s.SearchTickets(context.Background(), "printer", 0, creator)
+	c.Nil(err)
+	c.Equal(1, response.Total)
+
+	response, err = s.SearchTickets(context.Background(), "printer", 0, admin)
+	c.Nil(err)
+	c.Equal(1, response.Total)
+
+	response, err = s.SearchTickets(context.Background(), "printer", 0, stranger)
+	c.Nil(err)
+	c.Equal(0, response.Total)
+
+	_, err = s.SearchTickets(context.Background(), "  ", 0, admin)
+	c.Equal(ErrMissingSearchQuery, err)
+}
+
+func TestSearchTicketsPagination(t *testing.T) {
+	c := require.New(t)
+
+	s := New(newTicketsRepoMock(newTestTicket()), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	response, err := s.SearchTickets(context.Background(), "printer", 0, admin)
+	c.Nil(err)
+	c.Nil(response.Next)
+
+	_, err = s.SearchTickets(context.Background(), "printer", -1, admin)
+	c.Equal(ErrInvalidOffset, err)
+
+	_, err = s.SearchTickets(context.Background(), "printer", maxSearchOffset+1, admin)
+	c.Equal(ErrInvalidOffset, err)
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	// defaultAtRiskWindow how close to a deadline a ticket has to be to be considered at risk
+	defaultAtRiskWindow = time.Hour
+)
+
+var (
+	// ErrInvalidAtRiskWindow invalid at risk window
+	ErrInvalidAtRiskWindow = httputils.NewBadRequestError("invalid at risk window")
+)
+
+// slaPolicy how long there is to first respond to and to resolve a ticket
+type slaPolicy struct {
+	FirstResponse time.Duration
+	Resolution    time.Duration
+}
+
+// slaPolicies holds the SLA policy of every urgency, which is the highest of the severity and
+// the priority of a ticket
+var slaPolicies = map[int]slaPolicy{
+	1: {FirstResponse: 24 * time.Hour, Resolution: 7 * 24 * time.Hour},
+	2: {FirstResponse: 8 * time.Hour, Resolution: 3 * 24 * time.Hour},
+	3: {FirstResponse: 4 * time.Hour, Resolution: 24 * time.Hour},
+	4: {FirstResponse: time.Hour, Resolution: 4 * time.Hour},
+}
+
+func policyFor(severity models.TicketSeverity, priority models.TicketPriority) slaPolicy {
+	urgency := int(severity)
+	if int(priority) > urgency {
+		urgency = int(priority)
+	}
+
+	if urgency < 1 {
+		urgency = 1
+	}
+
+	if urgency > len(slaPolicies) {
+		urgency = len(slaPolicies)
+	}
+
+	return slaPolicies[urgency]
+}
+
+// applySLAPolicy sets the SLA deadlines of the ticket, counting from its creation
+func applySLAPolicy(ticket *models.Ticket, now time.Time) {
+	start := now
+	if ticket.CreatedAt != nil {
+		start = *ticket.CreatedAt
+	}
+
+	policy := policyFor(ticket.Severity, ticket.Priority)
+
+	firstResponseDueAt := start.Add(policy.FirstResponse).UTC()
+	dueAt := start.Add(policy.Resolution).UTC()
+
+	ticket.FirstResponseDueAt = &firstResponseDueAt
+	ticket.DueAt = &dueAt
+}
+
+// isBreached returns whether the ticket missed any of its SLA deadlines at the given time
+func isBreached(ticket models.Ticket, now time.Time) bool {
+	if ticket.FirstResponseDueAt != nil {
+		respondedAt := now
+		if ticket.FirstRespondedAt != nil {
+			respondedAt = *ticket.FirstRespondedAt
+		}
+
+		if respondedAt.After(*ticket.FirstResponseDueAt) {
+			return true
+		}
+	}
+
+	if ticket.DueAt != nil {
+		resolvedAt := now
+		if ticket.ResolvedAt != nil {
+			resolvedAt = *ticket.ResolvedAt
+		}
+
+		if resolvedAt.After(*ticket.DueAt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// markFirstResponse records the first time somebody working on tickets responded to the ticket
+func markFirstResponse(ticket *models.Ticket, requester models.Requester, now time.Time) bool {
+	if ticket.FirstRespondedAt != nil || !requester.Can(models.PermissionTicketWork) {
+		return false
+	}
+
+	respondedAt := now.UTC()
+	ticket.FirstRespondedAt = &respondedAt
+
+	return true
+}
+
+// GetSLATickets returns the open tickets that already breached their SLA and the ones with a
+// deadline within the given window
+func (s service) GetSLATickets(ctx context.Context, within time.Duration, requester models.Requester) (SLATicketsResponse, error) {
+	if !requester.Can(models.PermissionSLARead) {
+		return SLATicketsResponse{}, httputils.ForbiddenError
+	}
+
+	if within < 0 {
+		return SLATicketsResponse{}, ErrInvalidAtRiskWindow
+	}
+
+	if within == 0 {
+		within = defaultAtRiskWindow
+	}
+
+	now := time.Now().UTC()
+
+	tickets, err := s.ticketsRepo.ListSLATickets(ctx, now.Add(within))
+	if err != nil {
+		return SLATicketsResponse{}, err
+	}
+
+	response := SLATicketsResponse{Breached: []models.Ticket{}, AtRisk: []models.Ticket{}}
+
+	for _, ticket := range tickets {
+		if ticket.Breached || isBreached(ticket, now) {
+			response.Breached = append(response.Breached, ticket)
+			continue
+		}
+
+		response.AtRisk = append(response.AtRisk, ticket)
+	}
+
+	return response, nil
+}
+
+// SLAChecker periodically flags the open tickets that missed any of their SLA deadlines
+type SLAChecker struct {
+	ticketsRepo ticketsRepository.Repository
+	interval    time.Duration
+	logger      *slog.Logger
+}
+
+// NewSLAChecker returns a checker that looks for breached tickets every interval. The default logger
+// is used when logger is nil
+func NewSLAChecker(ticketsRepo ticketsRepository.Repository, interval time.Duration, logger *slog.Logger) *SLAChecker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &SLAChecker{
+		ticketsRepo: ticketsRepo,
+		interval:    interval,
+		logger:      logger,
+	}
+}
+
+// Run checks for breached tickets until the context is done
+func (c *SLAChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Check flags the tickets that breached their SLA since the last check
+func (c *SLAChecker) Check(ctx context.Context) {
+	ticketIDs, err := c.ticketsRepo.FlagBreachedTickets(ctx, time.Now().UTC())
+	if err != nil {
+		c.logger.ErrorContext(ctx, "checking_sla_failed", "error", err)
+		return
+	}
+
+	if len(ticketIDs) > 0 {
+		c.logger.InfoContext(ctx, "sla_breached_tickets", "ticket_ids", ticketIDs)
+	}
+}
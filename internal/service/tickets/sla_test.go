@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+func TestApplySLAPolicyUsesHighestUrgency(t *testing.T) {
+	c := require.New(t)
+
+	createdAt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	ticket := models.Ticket{
+		Severity:  models.TicketSeverityLow,
+		Priority:  models.TicketPriorityVeryHigh,
+		CreatedAt: &createdAt,
+	}
+
+	applySLAPolicy(&ticket, time.Now())
+	c.Equal(createdAt.Add(time.Hour), *ticket.FirstResponseDueAt)
+	c.Equal(createdAt.Add(4*time.Hour), *ticket.DueAt)
+}
+
+func TestIsBreached(t *testing.T) {
+	createdAt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	respondedAt := createdAt.Add(30 * time.Minute)
+	lateResolvedAt := createdAt.Add(5 * time.Hour)
+
+	tests := []struct {
+		name             string
+		firstRespondedAt *time.Time
+		resolvedAt       *time.Time
+		now              time.Time
+		breached         bool
+	}{
+		{"within deadlines", nil, nil, createdAt.Add(30 * time.Minute), false},
+		{"missed first response", nil, nil, createdAt.Add(2 * time.Hour), true},
+		{"responded in time", &respondedAt, nil, createdAt.Add(2 * time.Hour), false},
+		{"missed resolution", &respondedAt, nil, createdAt.Add(5 * time.Hour), true},
+		{"resolved late", &respondedAt, &lateResolvedAt, createdAt.Add(6 * time.Hour), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := require.New(t)
+
+			ticket := models.Ticket{
+				Severity:         models.TicketSeverityVeryHigh,
+				Priority:         models.TicketPriorityVeryHigh,
+				CreatedAt:        &createdAt,
+				FirstRespondedAt: test.firstRespondedAt,
+				ResolvedAt:       test.resolvedAt,
+			}
+			applySLAPolicy(&ticket, createdAt)
+
+			c.Equal(test.breached, isBreached(ticket, test.now))
+		})
+	}
+}
+
+func TestUpdateTicketKeepsBreach(t *testing.T) {
+	c := require.New(t)
+
+	createdAt := time.Now().Add(-2 * time.Hour)
+	ticket := newTestTicket()
+	ticket.Severity = models.TicketSeverityVeryHigh
+	ticket.Priority = models.TicketPriorityVeryHigh
+	ticket.CreatedAt = &createdAt
+	applySLAPolicy(&ticket, createdAt)
+	ticket.Breached = true
+
+	s := New(newTicketsRepoMock(ticket), newUsersRepoMock(), newTeamsRepoMock(), nil, nil, nil)
+
+	// lowering the urgency moves the deadlines later, the missed ones are still missed
+	updatedTicket, err := s.UpdateTicket(context.Background(), httputils.MergePatchRequest(`{"severity":1,"priority":1}`), 10, admin)
+	c.Nil(err)
+	c.True(updatedTicket.DueAt.After(time.Now()))
+	c.True(updatedTicket.Breached)
+}
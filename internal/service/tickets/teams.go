@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	teamsRepository "github.com/syned13/ticket-support-back/internal/repositories/teams"
+	ticketsRepository "github.com/syned13/ticket-support-back/internal/repositories/tickets"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrInvalidTeamID invalid team id
+	ErrInvalidTeamID = httputils.NewBadRequestError("invalid team id")
+	// ErrSameTeam same team
+	ErrSameTeam = httputils.NewBadRequestError("the ticket already belongs to that team")
+)
+
+// TransferTicket moves the ticket to another team, or out of any team when teamID is nil. The owner
+// is kept only when they are a member of the new team. Only the ones allowed to work on tickets can
+func (s service) TransferTicket(ctx context.Context, ticketID int64, teamID *int64, requester models.Requester) (models.Ticket, error) {
+	if !requester.Can(models.PermissionTicketWork) {
+		return models.Ticket{}, httputils.ForbiddenError
+	}
+
+	var updatedTicket models.Ticket
+
+	err := s.ticketsRepo.WithTx(ctx, func(repo ticketsRepository.Repository) error {
+		ticket, err := repo.GetTicketForUpdate(ctx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		if ticket.Status.IsFinal() {
+			return ErrTicketInFinalStatus
+		}
+
+		if sameID(ticket.TeamID, teamID) {
+			return ErrSameTeam
+		}
+
+		previousTicket := ticket
+		ticket.TeamID = teamID
+
+		if teamID != nil {
+			team, err := s.teamsRepo.GetTeam(ctx, *teamID)
+			if errors.Is(err, teamsRepository.ErrNotFound) {
+				return ErrInvalidTeamID
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if ticket.OwnerID != nil && !team.HasMember(*ticket.OwnerID) {
+				ticket.OwnerID = nil
+			}
+		}
+
+		updatedTicket, err = repo.UpdateTicket(ctx, ticket)
+		if err != nil {
+			return err
+		}
+
+		err = saveChanges(ctx, repo, ticketChanges(previousTicket, updatedTicket, requester.UserID))
+		if err != nil {
+			return err
+		}
+
+		return saveEvents(ctx, repo, models.NewTicketEvent(models.TicketEventUpdated, updatedTicket, requester.UserID))
+	})
+	if errors.Is(err, ticketsRepository.ErrNotFound) {
+		return models.Ticket{}, httputils.NewNotFoundError("ticket")
+	}
+
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return updatedTicket, nil
+}
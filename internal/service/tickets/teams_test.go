@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	billingTeamID int64 = 1
+	supportTeamID int64 = 2
+)
+
+func newTestTeams() []models.Team {
+	return []models.Team{
+		{TeamID: billingTeamID, Name: "billing", TicketTypes: []models.TicketType{models.TicketTypeSupport}, MemberIDs: []int64{adminID}},
+		{TeamID: supportTeamID, Name: "support", MemberIDs: []int64{secondAdminID}},
+	}
+}
+
+func TestCreateTicketRoutesToTeam(t *testing.T) {
+	c := require.New(t)
+
+	ticketsRepo := newTicketsRepoMock()
+	teamsRepo := newTeamsRepoMock(newTestTeams()...)
+
+	strategy, err := NewAssignmentStrategy(AssignmentStrategyRoundRobin, ticketsRepo, newAdminsRepoMock(), teamsRepo)
+	c.Nil(err)
+
+	s := New(ticketsRepo, newAdminsRepoMock(), teamsRepo, strategy, nil, nil)
+
+	for i := 0; i < 2; i++ {
+		ticket, err := s.CreateTicket(context.Background(), models.Ticket{
+			Title:       "scanner",
+			Description: "it does not scan",
+			Type:        models.TicketTypeSupport,
+			Severity:    models.TicketSeverityLow,
+			Priority:    models.TicketPriorityLow,
+			CreatorID:   creatorID,
+		})
+		c.Nil(err)
+		c.Equal(billingTeamID, *ticket.TeamID)
+		c.Equal(adminID, *ticket.OwnerID)
+	}
+}
+
+func TestTransferTicket(t *testing.T) {
+	c := require.New(t)
+
+	ticket := newTestTicket()
+	ticket.TeamID = new(int64)
+	*ticket.TeamID = billingTeamID
+	ticket.OwnerID = new(int64)
+	*ticket.OwnerID = adminID
+
+	repo := newTicketsRepoMock(ticket)
+	s := New(repo, newAdminsRepoMock(), newTeamsRepoMock(newTestTeams()...), nil, nil, nil)
+
+	teamID := supportTeamID
+
+	_, err := s.TransferTicket(context.Background(), 10, &teamID, creator)
+	c.Equal(httputils.ForbiddenError, err)
+
+	invalidTeamID := int64(99)
+	_, err = s.TransferTicket(context.Background(), 10, &invalidTeamID, admin)
+	c.Equal(ErrInvalidTeamID, err)
+
+	transferred, err := s.TransferTicket(context.Background(), 10, &teamID, admin)
+	c.Nil(err)
+	c.Equal(supportTeamID, *transferred.TeamID)
+	c.Nil(transferred.OwnerID)
+
+	_, err = s.TransferTicket(context.Background(), 10, &teamID, admin)
+	c.Equal(ErrSameTeam, err)
+
+	history, err := s.GetTicketHistory(context.Background(), 10, admin)
+	c.Nil(err)
+	c.Len(history, 2)
+	c.Equal(models.TicketFieldOwner, history[0].Field)
+	c.Equal(models.TicketFieldTeam, history[1].Field)
+	c.Equal("1", *history[1].From)
+	c.Equal("2", *history[1].To)
+}
+
+func TestGetTicketsTeamQueue(t *testing.T) {
+	c := require.New(t)
+
+	billingTeamIDValue, supportTeamIDValue := billingTeamID, supportTeamID
+
+	repo := newTicketsRepoMock(
+		models.Ticket{TicketID: 1, CreatorID: creatorID, TeamID: &billingTeamIDValue},
+		models.Ticket{TicketID: 2, CreatorID: creatorID, TeamID: &supportTeamIDValue},
+		models.Ticket{TicketID: 3, CreatorID: strangerID},
+	)
+
+	s := New(repo, newAdminsRepoMock(), newTeamsRepoMock(newTestTeams()...), nil, nil, nil)
+
+	response, err := s.GetTickets(context.Background(), GetTicketsFilter{}, admin)
+	c.Nil(err)
+	c.Len(response.Tickets, 1)
+	c.Equal(int64(1), response.Tickets[0].TicketID)
+
+	response, err = s.GetTickets(context.Background(), GetTicketsFilter{TeamID: &supportTeamIDValue}, admin)
+	c.Nil(err)
+	c.Len(response.Tickets, 1)
+	c.Equal(int64(2), response.Tickets[0].TicketID)
+
+	response, err = s.GetTickets(context.Background(), GetTicketsFilter{AllTeams: true}, admin)
+	c.Nil(err)
+	c.Len(response.Tickets, 3)
+
+	// users in no team and requesters are not given a team queue
+	noTeamAgent := models.Requester{UserID: 7, Role: models.RoleAgent, Permissions: models.DefaultRolePermissions[models.RoleAgent]}
+	response, err = s.GetTickets(context.Background(), GetTicketsFilter{}, noTeamAgent)
+	c.Nil(err)
+	c.Len(response.Tickets, 3)
+
+	response, err = s.GetTickets(context.Background(), GetTicketsFilter{}, creator)
+	c.Nil(err)
+	c.Len(response.Tickets, 2)
+}
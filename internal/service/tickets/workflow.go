@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	// ErrTicketInFinalStatus ticket in final status
+	ErrTicketInFinalStatus = httputils.NewBadRequestError("ticket is in a final status")
+	// ErrSameStatus same status
+	ErrSameStatus = httputils.NewBadRequestError("ticket already has that status")
+)
+
+// Transition represents an allowed status change and the permission needed to perform it. Transitions
+// without permission can be performed by anybody who can update the ticket
+type Transition struct {
+	From       models.TicketStatus `json:"from"`
+	To         models.TicketStatus `json:"to"`
+	Permission models.Permission   `json:"permission,omitempty"`
+}
+
+// workflow holds every allowed status transition of a ticket
+var workflow = []Transition{
+	{From: models.TicketTypePending, To: models.TicketTypeInProgress, Permission: models.PermissionTicketWork},
+	{From: models.TicketTypePending, To: models.TicketStatusCancelled},
+	{From: models.TicketTypeInProgress, To: models.TicketTypePending, Permission: models.PermissionTicketWork},
+	{From: models.TicketTypeInProgress, To: models.TicketStatusResolved, Permission: models.PermissionTicketWork},
+	{From: models.TicketTypeInProgress, To: models.TicketStatusCancelled},
+}
+
+func findTransition(from, to models.TicketStatus) (Transition, bool) {
+	for _, transition := range workflow {
+		if transition.From == from && transition.To == to {
+			return transition, true
+		}
+	}
+
+	return Transition{}, false
+}
+
+func (t Transition) isAllowedFor(requester models.Requester) bool {
+	return t.Permission == "" || requester.Can(t.Permission)
+}
+
+// transitionStatus moves the ticket to the given status if the workflow allows the requester to do so
+func transitionStatus(ticket *models.Ticket, to models.TicketStatus, requester models.Requester) error {
+	if !models.IsValidTicketStatus(to) {
+		return ErrInvalidStatus
+	}
+
+	if ticket.Status.IsFinal() {
+		return ErrTicketInFinalStatus
+	}
+
+	if ticket.Status == to {
+		return ErrSameStatus
+	}
+
+	transition, ok := findTransition(ticket.Status, to)
+	if !ok {
+		return httputils.NewBadRequestError(fmt.Sprintf("invalid status transition from %s to %s", ticket.Status, to))
+	}
+
+	if !transition.isAllowedFor(requester) {
+		return httputils.ForbiddenError
+	}
+
+	ticket.Status = to
+
+	if to == models.TicketStatusResolved {
+		now := time.Now()
+		ticket.ResolvedAt = &now
+	}
+
+	return nil
+}
+
+// GetWorkflow returns the statuses of a ticket and the allowed transitions between them
+func (s service) GetWorkflow() WorkflowResponse {
+	statuses := []models.TicketStatus{
+		models.TicketTypePending,
+		models.TicketTypeInProgress,
+		models.TicketStatusResolved,
+		models.TicketStatusCancelled,
+	}
+
+	finalStatuses := []models.TicketStatus{}
+	for _, status := range statuses {
+		if status.IsFinal() {
+			finalStatuses = append(finalStatuses, status)
+		}
+	}
+
+	return WorkflowResponse{
+		Statuses:      statuses,
+		FinalStatuses: finalStatuses,
+		Transitions:   workflow,
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+func TestTransitionStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      models.TicketStatus
+		to        models.TicketStatus
+		requester models.Requester
+		err       error
+	}{
+		{"admin starts progress", models.TicketTypePending, models.TicketTypeInProgress, admin, nil},
+		{"user can not start progress", models.TicketTypePending, models.TicketTypeInProgress, creator, httputils.ForbiddenError},
+		{"admin resolves", models.TicketTypeInProgress, models.TicketStatusResolved, admin, nil},
+		{"user can not resolve", models.TicketTypeInProgress, models.TicketStatusResolved, creator, httputils.ForbiddenError},
+		{"user cancels", models.TicketTypePending, models.TicketStatusCancelled, creator, nil},
+		{"pending can not be resolved", models.TicketTypePending, models.TicketStatusResolved, admin, httputils.NewBadRequestError("invalid status transition from pending to resolved")},
+		{"resolved is final", models.TicketStatusResolved, models.TicketTypeInProgress, admin, ErrTicketInFinalStatus},
+		{"cancelled is final", models.TicketStatusCancelled, models.TicketTypePending, admin, ErrTicketInFinalStatus},
+		{"same status", models.TicketTypePending, models.TicketTypePending, admin, ErrSameStatus},
+		{"unknown status", models.TicketTypePending, "closed", admin, ErrInvalidStatus},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := require.New(t)
+
+			ticket := models.Ticket{Status: test.from}
+
+			err := transitionStatus(&ticket, test.to, test.requester)
+			c.Equal(test.err, err)
+
+			if test.err == nil {
+				c.Equal(test.to, ticket.Status)
+			} else {
+				c.Equal(test.from, ticket.Status)
+			}
+		})
+	}
+}
+
+func TestTransitionStatusSetsResolvedAt(t *testing.T) {
+	c := require.New(t)
+
+	ticket := models.Ticket{Status: models.TicketTypeInProgress}
+
+	err := transitionStatus(&ticket, models.TicketStatusResolved, admin)
+	c.Nil(err)
+	c.NotNil(ticket.ResolvedAt)
+}
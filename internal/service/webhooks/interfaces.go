@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// Service defines the webhooks related methods
+type Service interface {
+	// Publish saves an attempt to deliver the event to each subscribed webhook, Run makes them. It
+	// fails when they could not be saved
+	Publish(ctx context.Context, event models.TicketEvent) error
+	// Run makes the saved attempts, retrying the failed ones, until the context is done. It returns
+	// once the attempts in flight are recorded
+	Run(ctx context.Context)
+	CreateWebhook(ctx context.Context, request CreateWebhookRequest, requester models.Requester) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, requester models.Requester) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID int64, requester models.Requester) error
+	GetDeliveries(ctx context.Context, webhookID int64, lastID int64, requester models.Requester) (GetDeliveriesResponse, error)
+	ReplayDelivery(ctx context.Context, webhookID int64, deliveryID int64, requester models.Requester) (models.WebhookDelivery, error)
+}
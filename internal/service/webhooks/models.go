@@ -0,0 +1,35 @@
+package service
+
+import (
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+)
+
+// CreateWebhookRequest defines the endpoint to send events to and which events it wants
+type CreateWebhookRequest struct {
+	URL        string                   `json:"url"`
+	EventTypes []models.TicketEventType `json:"eventTypes"`
+}
+
+// GetDeliveriesResponse a page of deliveries, Last is the lastID of the following page
+type GetDeliveriesResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Last       int64                    `json:"last"`
+	Total      int                      `json:"total"`
+}
+
+// Payload is the body sent to the webhooks
+type Payload struct {
+	ID         string                 `json:"id"`
+	Type       models.TicketEventType `json:"type"`
+	OccurredAt time.Time              `json:"occurredAt"`
+	Data       PayloadData            `json:"data"`
+}
+
+// PayloadData what happened to the ticket
+type PayloadData struct {
+	Ticket         models.Ticket       `json:"ticket"`
+	ActorID        int64               `json:"actorID"`
+	PreviousStatus models.TicketStatus `json:"previousStatus,omitempty"`
+}
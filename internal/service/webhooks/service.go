@@ -0,0 +1,490 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/syned13/ticket-support-back/internal/models"
+	webhooksRepository "github.com/syned13/ticket-support-back/internal/repositories/webhooks"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the timestamp and the body, see Sign
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader carries the unix time the request was signed at
+	TimestampHeader = "X-Webhook-Timestamp"
+	// EventHeader carries the type of the event
+	EventHeader = "X-Webhook-Event"
+	// EventIDHeader carries the id of the event, the same on every attempt and replay
+	EventIDHeader = "X-Webhook-Event-ID"
+
+	defaultWorkers      = 4
+	defaultPollInterval = time.Second
+	deliveriesPageSize  = 50
+	maxAttempts         = 5
+	requestTimeout      = 10 * time.Second
+	// claimDuration how long a claimed attempt is skipped by the other workers, it is taken again
+	// after it when the worker never completed it
+	claimDuration  = 3 * requestTimeout
+	initialBackoff = 2 * time.Second
+	maxBackoff     = 5 * time.Minute
+)
+
+var (
+	// ErrInvalidURL invalid url
+	ErrInvalidURL = httputils.NewBadRequestError("invalid url, it must be an absolute http or https url")
+	// ErrMissingEventTypes missing event types
+	ErrMissingEventTypes = httputils.NewBadRequestError("missing event types")
+	// ErrInvalidEventType invalid event type
+	ErrInvalidEventType = httputils.NewBadRequestError("invalid event type")
+
+	errInactiveWebhook = errors.New("webhook is inactive")
+)
+
+// webhookEventTypes the events webhooks can subscribe to
+var webhookEventTypes = map[models.TicketEventType]bool{
+	models.TicketEventCreated:       true,
+	models.TicketEventUpdated:       true,
+	models.TicketEventStatusChanged: true,
+	models.TicketEventAssigned:      true,
+}
+
+type service struct {
+	repo         webhooksRepository.Repository
+	client       *http.Client
+	workers      int
+	pollInterval time.Duration
+	backoff      func(attempt int) time.Duration
+	logger       *slog.Logger
+}
+
+// New returns a new webhooks service. Run makes up to workers attempts at a time, a few when it is
+// not positive. A client with a short timeout is used when client is nil, and the default logger
+// when logger is nil
+func New(repo webhooksRepository.Repository, client *http.Client, workers int, logger *slog.Logger) Service {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return service{
+		repo:         repo,
+		client:       client,
+		workers:      workers,
+		pollInterval: defaultPollInterval,
+		backoff:      exponentialBackoff,
+		logger:       logger,
+	}
+}
+
+// exponentialBackoff doubles the wait after every failed attempt
+func exponentialBackoff(attempt int) time.Duration {
+	backoff := initialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
+
+// Sign returns the signature of a payload sent at the given unix timestamp. Receivers should
+// compute it with the secret of the webhook and compare it with the SignatureHeader
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish queues an attempt of the event for every subscribed webhook. Publishing an event again
+// queues nothing for the webhooks it was already queued for, so relaying it twice is harmless
+func (s service) Publish(ctx context.Context, event models.TicketEvent) error {
+	if !webhookEventTypes[event.Type] {
+		return nil
+	}
+
+	webhooks, err := s.repo.ListActiveWebhooks(ctx, event.Type)
+	if err != nil {
+		return fmt.Errorf("listing webhooks failed: %w", err)
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	// events relayed from the outbox keep their id when relayed again, so receivers can skip duplicates
+	eventID := strconv.FormatInt(event.EventID, 10)
+	if event.EventID == 0 {
+		eventID, err = generateRandomHex(16)
+		if err != nil {
+			return fmt.Errorf("generating event id failed: %w", err)
+		}
+	}
+
+	body, err := json.Marshal(Payload{
+		ID:         eventID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Data: PayloadData{
+			Ticket:         event.Ticket,
+			ActorID:        event.ActorID,
+			PreviousStatus: event.PreviousStatus,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("encoding webhook payload failed: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	for _, webhook := range webhooks {
+		err = s.repo.QueueDelivery(ctx, models.WebhookDelivery{
+			WebhookID:     webhook.WebhookID,
+			EventID:       eventID,
+			EventType:     event.Type,
+			Payload:       body,
+			Attempt:       1,
+			NextAttemptAt: &now,
+		})
+		if err != nil {
+			return fmt.Errorf("queueing webhook delivery failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Run makes the due attempts with a pool of workers until the context is done, then waits for the
+// attempts in flight. Attempts queued before a restart are picked up again
+func (s service) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// work makes the due attempts one after the other, polling for more when there are none
+func (s service) work(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if s.deliverNext(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// deliverNext makes the oldest due attempt, if any, and returns whether there was one
+func (s service) deliverNext(ctx context.Context) bool {
+	now := time.Now().UTC()
+
+	delivery, err := s.repo.ClaimDueDelivery(ctx, now, now.Add(claimDuration))
+	if errors.Is(err, webhooksRepository.ErrNotFound) {
+		return false
+	}
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "claiming_webhook_delivery_failed", "error", err)
+		return false
+	}
+
+	// an attempt in flight is finished and recorded even when the context is done, the request
+	// timeout bounds how long that takes
+	ctx = context.WithoutCancel(ctx)
+
+	webhook, err := s.repo.GetWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, webhooksRepository.ErrNotFound) {
+		// the webhook was deleted along with its deliveries
+		return true
+	}
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "getting_webhook_failed", "webhook_id", delivery.WebhookID, "error", err)
+		return true
+	}
+
+	if webhook.Active {
+		statusCode, err := s.send(ctx, webhook, delivery)
+		setOutcome(&delivery, statusCode, err)
+	} else {
+		setOutcome(&delivery, 0, errInactiveWebhook)
+	}
+
+	var next *models.WebhookDelivery
+
+	switch {
+	case delivery.Success || !webhook.Active:
+	case delivery.Attempt >= maxAttempts:
+		s.logger.WarnContext(ctx, "webhook_delivery_failed", "event_id", delivery.EventID, "webhook_id", webhook.WebhookID, "attempts", delivery.Attempt)
+	default:
+		retryAt := time.Now().UTC().Add(s.backoff(delivery.Attempt))
+		next = &models.WebhookDelivery{
+			WebhookID:     delivery.WebhookID,
+			EventID:       delivery.EventID,
+			EventType:     delivery.EventType,
+			Payload:       delivery.Payload,
+			Attempt:       delivery.Attempt + 1,
+			NextAttemptAt: &retryAt,
+		}
+	}
+
+	err = s.repo.CompleteDelivery(ctx, delivery, next)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "saving_webhook_delivery_failed", "error", err)
+	}
+
+	return true
+}
+
+// attempt sends the delivery once and records it, it is meant for replays
+func (s service) attempt(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) models.WebhookDelivery {
+	statusCode, err := s.send(ctx, webhook, delivery)
+	setOutcome(&delivery, statusCode, err)
+
+	savedDelivery, err := s.repo.SaveDelivery(ctx, delivery)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "saving_webhook_delivery_failed", "error", err)
+		return delivery
+	}
+
+	return savedDelivery
+}
+
+// setOutcome fills the result of an attempt in the delivery
+func setOutcome(delivery *models.WebhookDelivery, statusCode int, err error) {
+	if statusCode != 0 {
+		delivery.StatusCode = &statusCode
+	}
+
+	delivery.Success = err == nil
+	if err != nil {
+		message := err.Error()
+		delivery.Error = &message
+	}
+}
+
+func (s service) send(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	// the client may not have a timeout of its own, and the attempt must end before its claim does
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, delivery.Payload))
+	request.Header.Set(EventHeader, string(delivery.EventType))
+	request.Header.Set(EventIDHeader, delivery.EventID)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1<<16))
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return response.StatusCode, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+// CreateWebhook registers a webhook. The returned secret is the only time it is shown
+func (s service) CreateWebhook(ctx context.Context, request CreateWebhookRequest, requester models.Requester) (models.Webhook, error) {
+	if !requester.Can(models.PermissionWebhookManage) {
+		return models.Webhook{}, httputils.ForbiddenError
+	}
+
+	webhookURL, err := url.Parse(request.URL)
+	if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+		return models.Webhook{}, ErrInvalidURL
+	}
+
+	if len(request.EventTypes) == 0 {
+		return models.Webhook{}, ErrMissingEventTypes
+	}
+
+	eventTypes := []models.TicketEventType{}
+	seen := map[models.TicketEventType]bool{}
+
+	for _, eventType := range request.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return models.Webhook{}, ErrInvalidEventType
+		}
+
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	secret, err := generateRandomHex(32)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	return s.repo.CreateWebhook(ctx, models.Webhook{
+		URL:        webhookURL.String(),
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     true,
+		CreatorID:  requester.UserID,
+	})
+}
+
+// GetWebhooks returns every webhook, without their secrets
+func (s service) GetWebhooks(ctx context.Context, requester models.Requester) ([]models.Webhook, error) {
+	if !requester.Can(models.PermissionWebhookManage) {
+		return nil, httputils.ForbiddenError
+	}
+
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (s service) DeleteWebhook(ctx context.Context, webhookID int64, requester models.Requester) error {
+	if !requester.Can(models.PermissionWebhookManage) {
+		return httputils.ForbiddenError
+	}
+
+	err := s.repo.DeleteWebhook(ctx, webhookID)
+	if errors.Is(err, webhooksRepository.ErrNotFound) {
+		return httputils.NewNotFoundError("webhook")
+	}
+
+	return err
+}
+
+// GetDeliveries returns a page of the delivery log of a webhook, newest first
+func (s service) GetDeliveries(ctx context.Context, webhookID int64, lastID int64, requester models.Requester) (GetDeliveriesResponse, error) {
+	if !requester.Can(models.PermissionWebhookManage) {
+		return GetDeliveriesResponse{}, httputils.ForbiddenError
+	}
+
+	_, err := s.getWebhook(ctx, webhookID)
+	if err != nil {
+		return GetDeliveriesResponse{}, err
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, webhookID, lastID, deliveriesPageSize)
+	if err != nil {
+		return GetDeliveriesResponse{}, err
+	}
+
+	var last int64
+	if len(deliveries) > 0 {
+		last = deliveries[len(deliveries)-1].DeliveryID
+	}
+
+	return GetDeliveriesResponse{Deliveries: deliveries, Last: last, Total: len(deliveries)}, nil
+}
+
+// ReplayDelivery sends the payload of a past delivery again, once, and returns the new delivery
+func (s service) ReplayDelivery(ctx context.Context, webhookID int64, deliveryID int64, requester models.Requester) (models.WebhookDelivery, error) {
+	if !requester.Can(models.PermissionWebhookManage) {
+		return models.WebhookDelivery{}, httputils.ForbiddenError
+	}
+
+	webhook, err := s.getWebhook(ctx, webhookID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, webhookID, deliveryID)
+	if errors.Is(err, webhooksRepository.ErrNotFound) {
+		return models.WebhookDelivery{}, httputils.NewNotFoundError("delivery")
+	}
+
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	replay := models.WebhookDelivery{
+		WebhookID: webhook.WebhookID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Payload:   delivery.Payload,
+		Attempt:   1,
+		ReplayOf:  &delivery.DeliveryID,
+	}
+
+	return s.attempt(ctx, webhook, replay), nil
+}
+
+func (s service) getWebhook(ctx context.Context, webhookID int64) (models.Webhook, error) {
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if errors.Is(err, webhooksRepository.ErrNotFound) {
+		return models.Webhook{}, httputils.NewNotFoundError("webhook")
+	}
+
+	return webhook, err
+}
+
+func generateRandomHex(size int) (string, error) {
+	randomBytes := make([]byte, size)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(randomBytes), nil
+}
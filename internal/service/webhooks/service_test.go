@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syned13/ticket-support-back/internal/models"
+	webhooksRepository "github.com/syned13/ticket-support-back/internal/repositories/webhooks"
+	"github.com/syned13/ticket-support-back/pkg/httputils"
+)
+
+var (
+	admin = models.Requester{UserID: 1, Role: models.RoleAdmin, Permissions: models.DefaultRolePermissions[models.RoleAdmin]}
+	user  = models.Requester{UserID: 2, Role: models.RoleRequester, Permissions: models.DefaultRolePermissions[models.RoleRequester]}
+)
+
+// webhooksRepoMock is an in-memory webhooks repository. Methods not overridden panic when called
+type webhooksRepoMock struct {
+	webhooksRepository.Repository
+	mu         sync.Mutex
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+}
+
+func (m *webhooksRepoMock) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	webhook.WebhookID = int64(len(m.webhooks) + 1)
+	m.webhooks = append(m.webhooks, webhook)
+
+	return webhook, nil
+}
+
+func (m *webhooksRepoMock) GetWebhook(ctx context.Context, webhookID int64) (models.Webhook, error) {
+	for _, webhook := range m.webhooks {
+		if webhook.WebhookID == webhookID {
+			return webhook, nil
+		}
+	}
+
+	return models.Webhook{}, webhooksRepository.ErrNotFound
+}
+
+func (m *webhooksRepoMock) ListActiveWebhooks(ctx context.Context, eventType models.TicketEventType) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	for _, webhook := range m.webhooks {
+		if webhook.Active && webhook.Subscribes(eventType) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	return webhooks, nil
+}
+
+func (m *webhooksRepoMock) SaveDelivery(ctx context.Context, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery.DeliveryID = int64(len(m.deliveries) + 1)
+	m.deliveries = append(m.deliveries, delivery)
+
+	return delivery, nil
+}
+
+func (m *webhooksRepoMock) QueueDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, queued := range m.deliveries {
+		if queued.WebhookID == delivery.WebhookID && queued.EventID == delivery.EventID && queued.Attempt == 1 && queued.ReplayOf == nil {
+			return nil
+		}
+	}
+
+	delivery.DeliveryID = int64(len(m.deliveries) + 1)
+	m.deliveries = append(m.deliveries, delivery)
+
+	return nil
+}
+
+func (m *webhooksRepoMock) ClaimDueDelivery(ctx context.Context, now time.Time, claimedUntil time.Time) (models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, delivery := range m.deliveries {
+		if delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			m.deliveries[i].NextAttemptAt = &claimedUntil
+			return m.deliveries[i], nil
+		}
+	}
+
+	return models.WebhookDelivery{}, webhooksRepository.ErrNotFound
+}
+
+func (m *webhooksRepoMock) CompleteDelivery(ctx context.Context, delivery models.WebhookDelivery, next *models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery.NextAttemptAt = nil
+	m.deliveries[delivery.DeliveryID-1] = delivery
+
+	if next != nil {
+		next.DeliveryID = int64(len(m.deliveries) + 1)
+		m.deliveries = append(m.deliveries, *next)
+	}
+
+	return nil
+}
+
+// pending returns how many attempts are still to be made
+func (m *webhooksRepoMock) pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := 0
+	for _, delivery := range m.deliveries {
+		if delivery.NextAttemptAt != nil {
+			pending++
+		}
+	}
+
+	return pending
+}
+
+func (m *webhooksRepoMock) GetDelivery(ctx context.Context, webhookID int64, deliveryID int64) (models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID && delivery.DeliveryID == deliveryID {
+			return delivery, nil
+		}
+	}
+
+	return models.WebhookDelivery{}, webhooksRepository.ErrNotFound
+}
+
+// receiver is a webhook endpoint that fails the first requests it gets
+type receiver struct {
+	mu        sync.Mutex
+	failures  int
+	requests  int
+	signature bool
+	done      chan struct{}
+	closeOnce sync.Once
+	secret    string
+}
+
+func (rc *receiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	rc.signature = r.Header.Get(SignatureHeader) == Sign(rc.secret, r.Header.Get(TimestampHeader), body)
+	rc.requests++
+
+	if rc.requests <= rc.failures {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+	rc.closeOnce.Do(func() { close(rc.done) })
+}
+
+func TestDeliveryIsSignedAndRetried(t *testing.T) {
+	c := require.New(t)
+
+	repo := &webhooksRepoMock{}
+	rc := &receiver{failures: 2, done: make(chan struct{})}
+
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	s := New(repo, server.Client(), 0, nil).(service)
+	s.backoff = func(attempt int) time.Duration { return time.Millisecond }
+	s.pollInterval = time.Millisecond
+
+	webhook, err := s.CreateWebhook(context.Background(), CreateWebhookRequest{
+		URL:        server.URL,
+		EventTypes: []models.TicketEventType{models.TicketEventStatusChanged},
+	}, admin)
+	c.Nil(err)
+	c.NotEmpty(webhook.Secret)
+	rc.secret = webhook.Secret
+
+	event := models.NewTicketEvent(models.TicketEventStatusChanged, models.Ticket{TicketID: 10}, 1)
+	event.EventID = 42
+
+	// relaying the event again does not deliver it twice
+	c.Nil(s.Publish(context.Background(), event))
+	c.Nil(s.Publish(context.Background(), event))
+	c.Equal(1, repo.pending())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	go func() {
+		s.Run(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-rc.done:
+	case <-time.After(5 * time.Second):
+		c.FailNow("webhook was never delivered")
+	}
+
+	c.Eventually(func() bool { return repo.pending() == 0 }, 5*time.Second, time.Millisecond)
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		c.FailNow("run did not stop")
+	}
+
+	c.Len(repo.deliveries, 3)
+	c.Equal("42", repo.deliveries[0].EventID)
+
+	rc.mu.Lock()
+	c.True(rc.signature)
+	rc.mu.Unlock()
+
+	c.False(repo.deliveries[0].Success)
+	c.Equal(http.StatusServiceUnavailable, *repo.deliveries[0].StatusCode)
+	c.Equal(3, repo.deliveries[2].Attempt)
+	c.True(repo.deliveries[2].Success)
+
+	replay, err := s.ReplayDelivery(context.Background(), webhook.WebhookID, repo.deliveries[0].DeliveryID, admin)
+	c.Nil(err)
+	c.True(replay.Success)
+	c.Equal(repo.deliveries[0].EventID, replay.EventID)
+	c.Equal(repo.deliveries[0].DeliveryID, *replay.ReplayOf)
+}
+
+func TestRunResumesQueuedDeliveriesAndWaitsForThemToEnd(t *testing.T) {
+	c := require.New(t)
+
+	received := make(chan struct{})
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// an attempt queued before a restart
+	queuedAt := time.Now().Add(-time.Minute)
+	repo := &webhooksRepoMock{
+		webhooks: []models.Webhook{{WebhookID: 1, URL: server.URL, Secret: "secret", Active: true}},
+		deliveries: []models.WebhookDelivery{
+			{DeliveryID: 1, WebhookID: 1, EventID: "7", EventType: models.TicketEventCreated, Payload: []byte(`{}`), Attempt: 1, NextAttemptAt: &queuedAt},
+		},
+	}
+
+	s := New(repo, server.Client(), 1, nil).(service)
+	s.pollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	go func() {
+		s.Run(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		c.FailNow("queued delivery was never attempted")
+	}
+
+	cancel()
+
+	select {
+	case <-stopped:
+		c.FailNow("run returned with an attempt in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		c.FailNow("run did not stop")
+	}
+
+	c.Len(repo.deliveries, 1)
+	c.True(repo.deliveries[0].Success)
+	c.Nil(repo.deliveries[0].NextAttemptAt)
+}
+
+func TestCreateWebhookValidations(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   CreateWebhookRequest
+		requester models.Requester
+		err       error
+	}{
+		{"only admins", CreateWebhookRequest{URL: "https://hooks.com", EventTypes: []models.TicketEventType{models.TicketEventCreated}}, user, httputils.ForbiddenError},
+		{"relative url", CreateWebhookRequest{URL: "/hooks", EventTypes: []models.TicketEventType{models.TicketEventCreated}}, admin, ErrInvalidURL},
+		{"unsupported scheme", CreateWebhookRequest{URL: "ftp://hooks.com", EventTypes: []models.TicketEventType{models.TicketEventCreated}}, admin, ErrInvalidURL},
+		{"missing events", CreateWebhookRequest{URL: "https://hooks.com"}, admin, ErrMissingEventTypes},
+		{"comments are not sent", CreateWebhookRequest{URL: "https://hooks.com", EventTypes: []models.TicketEventType{models.TicketEventCommented}}, admin, ErrInvalidEventType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(&webhooksRepoMock{}, nil, 0, nil)
+
+			_, err := s.CreateWebhook(context.Background(), test.request, test.requester)
+			require.Equal(t, test.err, err)
+		})
+	}
+}
@@ -7,13 +7,24 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	env "github.com/caarlos0/env/v6"
+	"github.com/syned13/ticket-support-back/pkg/email"
+	"github.com/syned13/ticket-support-back/pkg/logger"
+	"github.com/syned13/ticket-support-back/pkg/storage"
 	"gopkg.in/yaml.v2"
 )
 
 const (
-	defaultPort = "5000"
+	defaultPort             = "5000"
+	defaultSLACheckInterval = time.Minute
+	defaultOutboxInterval   = time.Second
+
+	defaultReadTimeout     = 15 * time.Second
+	defaultWriteTimeout    = time.Minute
+	defaultIdleTimeout     = 2 * time.Minute
+	defaultShutdownTimeout = 30 * time.Second
 )
 
 var (
@@ -27,12 +38,61 @@ var (
 type AppConfig struct {
 	Environment string `yaml:"environment" validate:"required" env:"APP_ENVIRONMENT,required"`
 	Port        string `yaml:"port" validate:"required" env:"PORT"`
+	// AssignmentStrategy how new tickets get an owner: round_robin, least_loaded or empty to leave them unassigned
+	AssignmentStrategy string `yaml:"assignmentStrategy" env:"ASSIGNMENT_STRATEGY"`
+	// SLACheckInterval how often tickets are checked for missed SLA deadlines
+	SLACheckInterval time.Duration `yaml:"slaCheckInterval" env:"SLA_CHECK_INTERVAL"`
+	// OutboxInterval how often the ticket events waiting in the outbox are relayed
+	OutboxInterval time.Duration `yaml:"outboxInterval" env:"OUTBOX_INTERVAL"`
+	// RolesCacheTTL how long the permissions of the roles are cached, a minute when not set
+	RolesCacheTTL time.Duration `yaml:"rolesCacheTTL" env:"ROLES_CACHE_TTL"`
+	// SkipMigrations leaves the pending migrations to the migrate subcommand instead of applying them at startup
+	SkipMigrations bool `yaml:"skipMigrations" env:"SKIP_MIGRATIONS"`
 
 	DatabaseConfig struct {
 		DatabaseType string `yaml:"databaseType" validate:"required" env:"DATABASETYPE,required"`
 		Connection   string `yaml:"connection" validate:"required" env:"DATABASE_CONNECTION,required"`
 		DatabseName  string `yaml:"databaseName" validate:"required" env:"DATABASENAME,required"`
 	} `yaml:"databaseConfig"`
+
+	// LogConfig the level and format of the logs
+	LogConfig logger.Config `yaml:"log"`
+	// ServerConfig the timeouts of the HTTP server
+	ServerConfig ServerConfig `yaml:"server"`
+	// StorageConfig where ticket attachments are kept
+	StorageConfig storage.Config `yaml:"storage"`
+	// SMTPConfig how notification emails are sent, they are disabled when there is no host
+	SMTPConfig email.SMTPConfig `yaml:"smtp"`
+}
+
+// ServerConfig defines the timeouts of the HTTP server. Zero values take the defaults
+type ServerConfig struct {
+	// ReadTimeout how long reading a request, body included, may take
+	ReadTimeout time.Duration `yaml:"readTimeout" env:"SERVER_READ_TIMEOUT"`
+	// WriteTimeout how long writing a response may take, event streams are ended before it
+	WriteTimeout time.Duration `yaml:"writeTimeout" env:"SERVER_WRITE_TIMEOUT"`
+	// IdleTimeout how long keep-alive connections wait for the next request
+	IdleTimeout time.Duration `yaml:"idleTimeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout how long the in-flight requests are waited for when shutting down
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+func (c *ServerConfig) setDefaults() {
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = defaultReadTimeout
+	}
+
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = defaultWriteTimeout
+	}
+
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = defaultIdleTimeout
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = defaultShutdownTimeout
+	}
 }
 
 // GetConfig returns the application config to have
@@ -62,6 +122,16 @@ func getConfigFromFileContent(fileContent []byte) (*AppConfig, error) {
 		config.Port = defaultPort
 	}
 
+	if config.SLACheckInterval <= 0 {
+		config.SLACheckInterval = defaultSLACheckInterval
+	}
+
+	if config.OutboxInterval <= 0 {
+		config.OutboxInterval = defaultOutboxInterval
+	}
+
+	config.ServerConfig.setDefaults()
+
 	return config, nil
 }
 
@@ -82,5 +152,15 @@ func GetConfigFromEnv() (*AppConfig, error) {
 		config.Port = defaultPort
 	}
 
+	if config.SLACheckInterval <= 0 {
+		config.SLACheckInterval = defaultSLACheckInterval
+	}
+
+	if config.OutboxInterval <= 0 {
+		config.OutboxInterval = defaultOutboxInterval
+	}
+
+	config.ServerConfig.setDefaults()
+
 	return &config, nil
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -71,3 +72,15 @@ func TestGetConfigMissingValue(t *testing.T) {
 	c.NotNil(err)
 	c.True(errors.Is(err, ErrMissingValue))
 }
+
+func TestGetConfigServerDefaults(t *testing.T) {
+	c := require.New(t)
+
+	fileContent := "environment: dev\nserver:\n  writeTimeout: 90s"
+
+	appConfig, err := getConfigFromFileContent([]byte(fileContent))
+	c.Nil(err)
+	c.Equal(90*time.Second, appConfig.ServerConfig.WriteTimeout)
+	c.Equal(defaultReadTimeout, appConfig.ServerConfig.ReadTimeout)
+	c.Equal(defaultShutdownTimeout, appConfig.ServerConfig.ShutdownTimeout)
+}
@@ -0,0 +1,158 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSMTPPort = "25"
+	defaultTimeout  = 30 * time.Second
+)
+
+var (
+	// ErrMissingRecipient missing recipient
+	ErrMissingRecipient = errors.New("missing recipient")
+	// ErrMissingSMTPConfig missing smtp config
+	ErrMissingSMTPConfig = errors.New("missing smtp host or sender address")
+)
+
+// Message a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender sends emails
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// SMTPConfig defines how to reach the SMTP server. Authentication is skipped when there is no username
+type SMTPConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     string `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+}
+
+type smtpSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender returns a sender that delivers the emails through an SMTP server, upgrading the
+// connection with STARTTLS whenever the server supports it
+func NewSMTPSender(config SMTPConfig) (Sender, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, ErrMissingSMTPConfig
+	}
+
+	if config.Port == "" {
+		config.Port = defaultSMTPPort
+	}
+
+	return smtpSender{config: config}, nil
+}
+
+func (s smtpSender) Send(ctx context.Context, message Message) error {
+	if len(message.To) == 0 {
+		return ErrMissingRecipient
+	}
+
+	dialer := net.Dialer{Timeout: defaultTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, s.config.Port))
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.config.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(s.config.From)
+	if err != nil {
+		return err
+	}
+
+	for _, to := range message.To {
+		err = client.Rcpt(to)
+		if err != nil {
+			return fmt.Errorf("rejected recipient %s: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(s.format(message))
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// format returns the message with its headers, ready to be sent
+func (s smtpSender) format(message Message) []byte {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&buffer, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	buffer.WriteString("\r\n")
+
+	body := strings.ReplaceAll(message.Body, "\r\n", "\n")
+	buffer.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,123 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts every email and keeps what it receives. It speaks just enough SMTP for
+// net/smtp clients
+type fakeSMTPServer struct {
+	listener   net.Listener
+	recipients []string
+	data       chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	server := &fakeSMTPServer{listener: listener, data: make(chan string, 1)}
+	go server.serve()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return server
+}
+
+func (f *fakeSMTPServer) serve() {
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost fake ESMTP")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO"):
+			f.recipients = append(f.recipients, strings.TrimSpace(line[len("RCPT TO:"):]))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if dataLine == ".\r\n" {
+					break
+				}
+
+				data.WriteString(dataLine)
+			}
+
+			f.data <- data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	c := require.New(t)
+
+	server := newFakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	c.Nil(err)
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: host, Port: port, From: "support@tickets.com"})
+	c.Nil(err)
+
+	err = sender.Send(context.Background(), Message{
+		To:      []string{"erica@erica.com"},
+		Subject: "Ticket resolved",
+		Body:    "Your ticket was resolved\nBye",
+	})
+	c.Nil(err)
+
+	data := <-server.data
+	c.Equal([]string{"<erica@erica.com>"}, server.recipients)
+	c.Contains(data, "From: support@tickets.com\r\n")
+	c.Contains(data, "To: erica@erica.com\r\n")
+	c.Contains(data, "Subject: Ticket resolved\r\n")
+	c.Contains(data, "\r\n\r\nYour ticket was resolved\r\nBye")
+}
+
+func TestSMTPSenderSendWithoutRecipients(t *testing.T) {
+	c := require.New(t)
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: "localhost", From: "support@tickets.com"})
+	c.Nil(err)
+
+	err = sender.Send(context.Background(), Message{Subject: "hi"})
+	c.Equal(ErrMissingRecipient, err)
+}
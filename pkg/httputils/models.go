@@ -1,5 +1,30 @@
 package httputils
 
+import "encoding/json"
+
+const (
+	// PatchOperationAdd adds a value to an object or inserts it into an array
+	PatchOperationAdd = "add"
+	// PatchOperationRemove removes the value at the target location
+	PatchOperationRemove = "remove"
+	// PatchOperationReplace replaces the value at the target location
+	PatchOperationReplace = "replace"
+	// PatchOperationTest tests that the value at the target location is equal to the given one
+	PatchOperationTest = "test"
+)
+
+const (
+	// ContentTypeJSONPatch content type of a JSON Patch (RFC 6902) document
+	ContentTypeJSONPatch = "application/json-patch+json"
+	// ContentTypeMergePatch content type of a JSON Merge Patch (RFC 7396) document
+	ContentTypeMergePatch = "application/merge-patch+json"
+)
+
+// Patch represents a set of changes that can be applied to a JSON document
+type Patch interface {
+	Apply(document []byte) ([]byte, error)
+}
+
 // PatchOperation represents the request body of a patch request
 type PatchOperation struct {
 	Op    string      `json:"op"`
@@ -7,4 +32,8 @@ type PatchOperation struct {
 	Value interface{} `json:"value"`
 }
 
+// PatchRequest represents a JSON Patch (RFC 6902) document
 type PatchRequest []PatchOperation
+
+// MergePatchRequest represents a JSON Merge Patch (RFC 7396) document
+type MergePatchRequest json.RawMessage
@@ -0,0 +1,292 @@
+package httputils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatchDocument invalid patch document
+	ErrInvalidPatchDocument = NewBadRequestError("invalid patch document")
+	// ErrInvalidPatchPath invalid patch path
+	ErrInvalidPatchPath = NewBadRequestError("invalid patch path")
+	// ErrPatchTestFailed the value of a test operation did not match
+	ErrPatchTestFailed = ErrorResponse{
+		Code:    http.StatusConflict,
+		Message: "patch test operation failed",
+	}
+)
+
+// Apply applies the JSON Patch operations to the document, in order. The document is left
+// untouched if any operation fails
+func (p PatchRequest) Apply(document []byte) ([]byte, error) {
+	var doc interface{}
+	err := json.Unmarshal(document, &doc)
+	if err != nil {
+		return nil, ErrInvalidPatchDocument
+	}
+
+	for _, op := range p {
+		doc, err = op.apply(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+func (op PatchOperation) apply(doc interface{}) (interface{}, error) {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case PatchOperationAdd:
+		return addValue(doc, tokens, op.Value)
+	case PatchOperationRemove:
+		return removeValue(doc, tokens)
+	case PatchOperationReplace:
+		doc, err = removeValue(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		return addValue(doc, tokens, op.Value)
+	case PatchOperationTest:
+		current, err := getValue(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if !jsonEqual(current, op.Value) {
+			return nil, ErrPatchTestFailed
+		}
+
+		return doc, nil
+	case "":
+		return nil, NewBadRequestError("missing patch operation")
+	default:
+		return nil, NewBadRequestError("invalid patch operation: " + op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, ErrInvalidPatchPath
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+func getValue(doc interface{}, tokens []string) (interface{}, error) {
+	current := doc
+
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, pathNotFoundError(tokens)
+			}
+
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			current = node[index]
+		default:
+			return nil, pathNotFoundError(tokens)
+		}
+	}
+
+	return current, nil
+}
+
+func addValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parent, err := getValue(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		index := len(node)
+		if last != "-" {
+			index, err = arrayIndex(last, len(node))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		updated := append(node[:index:index], append([]interface{}{value}, node[index:]...)...)
+
+		return replaceParent(doc, tokens[:len(tokens)-1], updated)
+	default:
+		return nil, pathNotFoundError(tokens)
+	}
+}
+
+func removeValue(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	parent, err := getValue(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok {
+			return nil, pathNotFoundError(tokens)
+		}
+
+		delete(node, last)
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		updated := append(node[:index:index], node[index+1:]...)
+
+		return replaceParent(doc, tokens[:len(tokens)-1], updated)
+	default:
+		return nil, pathNotFoundError(tokens)
+	}
+}
+
+// replaceParent sets the array found at tokens to value. Needed because arrays change their
+// length when a value is added or removed
+func replaceParent(doc interface{}, tokens []string, value []interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	grandParent, err := getValue(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+
+	switch node := grandParent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		node[index] = value
+	}
+
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, NewBadRequestError("invalid array index: " + token)
+	}
+
+	return index, nil
+}
+
+func pathNotFoundError(tokens []string) error {
+	return NewBadRequestError(fmt.Sprintf("path not found: /%s", strings.Join(tokens, "/")))
+}
+
+// jsonEqual compares two values the way they would be compared as JSON
+func jsonEqual(a, b interface{}) bool {
+	aBytes, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	bBytes, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	var aValue, bValue interface{}
+	if json.Unmarshal(aBytes, &aValue) != nil || json.Unmarshal(bBytes, &bValue) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+// Apply merges the patch into the document following RFC 7396: null values remove members and
+// objects are merged recursively
+func (p MergePatchRequest) Apply(document []byte) ([]byte, error) {
+	var doc, patch interface{}
+
+	err := json.Unmarshal(document, &doc)
+	if err != nil {
+		return nil, ErrInvalidPatchDocument
+	}
+
+	err = json.Unmarshal(p, &patch)
+	if err != nil {
+		return nil, ErrInvalidPatchDocument
+	}
+
+	return json.Marshal(mergePatch(doc, patch))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
@@ -0,0 +1,141 @@
+package httputils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchRequestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    PatchRequest
+		expected string
+	}{
+		{
+			name:     "add object member",
+			document: `{"foo":"bar"}`,
+			patch:    PatchRequest{{Op: PatchOperationAdd, Path: "/baz", Value: "qux"}},
+			expected: `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:     "add array element",
+			document: `{"foo":["bar","baz"]}`,
+			patch:    PatchRequest{{Op: PatchOperationAdd, Path: "/foo/1", Value: "qux"}},
+			expected: `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:     "append array element",
+			document: `{"foo":["bar"]}`,
+			patch:    PatchRequest{{Op: PatchOperationAdd, Path: "/foo/-", Value: "qux"}},
+			expected: `{"foo":["bar","qux"]}`,
+		},
+		{
+			name:     "remove object member",
+			document: `{"baz":"qux","foo":"bar"}`,
+			patch:    PatchRequest{{Op: PatchOperationRemove, Path: "/baz"}},
+			expected: `{"foo":"bar"}`,
+		},
+		{
+			name:     "remove array element",
+			document: `{"foo":["bar","qux","baz"]}`,
+			patch:    PatchRequest{{Op: PatchOperationRemove, Path: "/foo/1"}},
+			expected: `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:     "replace value",
+			document: `{"baz":"qux","foo":"bar"}`,
+			patch:    PatchRequest{{Op: PatchOperationReplace, Path: "/baz", Value: "boo"}},
+			expected: `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:     "escaped pointer",
+			document: `{"a/b":1,"m~n":2}`,
+			patch:    PatchRequest{{Op: PatchOperationReplace, Path: "/a~1b", Value: 3}, {Op: PatchOperationReplace, Path: "/m~0n", Value: 4}},
+			expected: `{"a/b":3,"m~n":4}`,
+		},
+		{
+			name:     "successful test",
+			document: `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch:    PatchRequest{{Op: PatchOperationTest, Path: "/baz", Value: "qux"}, {Op: PatchOperationTest, Path: "/foo/1", Value: 2}},
+			expected: `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := require.New(t)
+
+			result, err := test.patch.Apply([]byte(test.document))
+			c.Nil(err)
+			c.JSONEq(test.expected, string(result))
+		})
+	}
+}
+
+func TestPatchRequestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    PatchRequest
+		err      error
+	}{
+		{
+			name:     "failed test",
+			document: `{"baz":"qux"}`,
+			patch:    PatchRequest{{Op: PatchOperationTest, Path: "/baz", Value: "bar"}},
+			err:      ErrPatchTestFailed,
+		},
+		{
+			name:     "replace missing member",
+			document: `{"foo":"bar"}`,
+			patch:    PatchRequest{{Op: PatchOperationReplace, Path: "/baz", Value: "qux"}},
+			err:      NewBadRequestError("path not found: /baz"),
+		},
+		{
+			name:     "add to missing parent",
+			document: `{"foo":"bar"}`,
+			patch:    PatchRequest{{Op: PatchOperationAdd, Path: "/baz/bat", Value: "qux"}},
+			err:      NewBadRequestError("path not found: /baz"),
+		},
+		{
+			name:     "array index out of bounds",
+			document: `{"foo":["bar"]}`,
+			patch:    PatchRequest{{Op: PatchOperationAdd, Path: "/foo/2", Value: "qux"}},
+			err:      NewBadRequestError("invalid array index: 2"),
+		},
+		{
+			name:     "path without leading slash",
+			document: `{"foo":"bar"}`,
+			patch:    PatchRequest{{Op: PatchOperationReplace, Path: "foo", Value: "qux"}},
+			err:      ErrInvalidPatchPath,
+		},
+		{
+			name:     "unknown operation",
+			document: `{"foo":"bar"}`,
+			patch:    PatchRequest{{Op: "update", Path: "/foo", Value: "qux"}},
+			err:      NewBadRequestError("invalid patch operation: update"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := require.New(t)
+
+			_, err := test.patch.Apply([]byte(test.document))
+			c.Equal(test.err, err)
+		})
+	}
+}
+
+func TestMergePatchRequestApply(t *testing.T) {
+	c := require.New(t)
+
+	document := `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`
+	patch := MergePatchRequest(`{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`)
+
+	result, err := patch.Apply([]byte(document))
+	c.Nil(err)
+	c.JSONEq(`{"title":"Hello!","author":{"givenName":"John"},"tags":["example"],"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`, string(result))
+}
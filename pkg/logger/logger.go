@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	// FormatJSON one json object per record
+	FormatJSON = "json"
+	// FormatText key=value pairs, easier to read locally
+	FormatText = "text"
+)
+
+// ErrUnknownFormat unknown log format
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// Config defines how much is logged and how
+type Config struct {
+	// Level the minimum level logged: debug, info, warn or error, info when not set
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+	// Format json or text, json when not set
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// New returns a logger writing to w. The request ID in the context of a record is added to it and
+// secrets are redacted, see Redact
+func New(w io.Writer, config Config) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	if config.Level != "" {
+		err := level.UnmarshalText([]byte(config.Level))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", config.Level, err)
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+
+	var handler slog.Handler
+
+	switch strings.ToLower(config.Format) {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, config.Format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID in ctx, empty when there is none
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request ID in the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
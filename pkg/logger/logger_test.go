@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	c := require.New(t)
+
+	buffer := &bytes.Buffer{}
+
+	logger, err := New(buffer, Config{Level: "warn"})
+	c.Nil(err)
+
+	ctx := WithRequestID(context.Background(), "abc123")
+
+	logger.InfoContext(ctx, "dropped")
+	c.Empty(buffer.String())
+
+	logger.WarnContext(ctx, "connecting_failed",
+		"connection", "postgresql://postgres:postgres@db:5432/tickets_db",
+		"error", errors.New("dial postgresql://postgres:hunter2@db:5432/tickets_db: refused"),
+		"header", "Bearer eyJhbGciOiJIUzI1NiJ9.e30.sig",
+	)
+
+	record := map[string]interface{}{}
+	c.Nil(json.Unmarshal(buffer.Bytes(), &record))
+	c.Equal("connecting_failed", record["msg"])
+	c.Equal("abc123", record["request_id"])
+	c.Equal("[REDACTED]", record["connection"])
+	c.Equal("dial postgresql://postgres:[REDACTED]@db:5432/tickets_db: refused", record["error"])
+	c.Equal("Bearer [REDACTED]", record["header"])
+
+	_, err = New(buffer, Config{Level: "loud"})
+	c.NotNil(err)
+
+	_, err = New(buffer, Config{Format: "xml"})
+	c.True(errors.Is(err, ErrUnknownFormat))
+}
+
+func TestRedact(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal("host=db user=postgres password=[REDACTED] dbname=tickets", Redact("host=db user=postgres password=s3cret dbname=tickets"))
+	c.Equal("no secrets here", Redact("no secrets here"))
+}
+
+func TestPgxLogger(t *testing.T) {
+	c := require.New(t)
+
+	buffer := &bytes.Buffer{}
+
+	logger, err := New(buffer, Config{Level: "debug"})
+	c.Nil(err)
+
+	NewPgxLogger(logger).Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]interface{}{
+		"sql":  "UPDATE users SET password = $1 WHERE user_id = $2",
+		"args": []interface{}{"$2a$10$hash", 1},
+	})
+
+	record := map[string]interface{}{}
+	c.Nil(json.Unmarshal(buffer.Bytes(), &record))
+	c.Equal("DEBUG", record["level"])
+	c.Equal("pgx: Query", record["msg"])
+	c.NotContains(record, "args")
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type pgxLogger struct {
+	logger *slog.Logger
+}
+
+// NewPgxLogger returns a pgx logger writing to logger. Queries, logged by pgx as info, are logged as
+// debug. Their arguments are left out, as they carry password hashes and tokens
+func NewPgxLogger(logger *slog.Logger) pgx.Logger {
+	return pgxLogger{logger: logger}
+}
+
+func (l pgxLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	slogLevel := pgxLevel(level)
+	if !l.logger.Enabled(ctx, slogLevel) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, len(data))
+	for key, value := range data {
+		if key == "args" {
+			continue
+		}
+
+		attrs = append(attrs, slog.Any(key, value))
+	}
+
+	l.logger.LogAttrs(ctx, slogLevel, "pgx: "+msg, attrs...)
+}
+
+func pgxLevel(level pgx.LogLevel) slog.Level {
+	switch {
+	case level <= pgx.LogLevelError:
+		return slog.LevelError
+	case level == pgx.LogLevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelDebug
+	}
+}
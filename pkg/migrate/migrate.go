@@ -0,0 +1,408 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// lockID the key of the advisory lock held while migrating, so only one runner changes the schema
+// at a time
+const lockID int64 = 7243519061
+
+// undefinedTable the code of the postgres error of a table that does not exist
+const undefinedTable = "42P01"
+
+const createTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var (
+	// ErrMissingPool missing pool
+	ErrMissingPool = errors.New("missing pool")
+	// ErrInvalidFileName invalid migration file name
+	ErrInvalidFileName = errors.New("invalid migration file name, expected NNNN_name.up.sql or NNNN_name.down.sql")
+	// ErrDuplicateVersion duplicate migration version
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	// ErrIncompleteMigration incomplete migration
+	ErrIncompleteMigration = errors.New("every migration needs an up and a down file")
+	// ErrUnknownVersion unknown version
+	ErrUnknownVersion = errors.New("unknown migration version")
+	// ErrInvalidSteps invalid steps
+	ErrInvalidSteps = errors.New("the number of migrations to roll back must be positive")
+)
+
+// Migration a versioned change of the schema
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus a migration and when it was applied, nil when it is pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back the migrations of a database. Applied migrations are tracked in
+// the schema_migrations table
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New returns a migrator of the migrations in the root of files, see Load
+func New(pool *pgxpool.Pool, files fs.FS) (*Migrator, error) {
+	if pool == nil {
+		return nil, ErrMissingPool
+	}
+
+	migrations, err := Load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Load reads the migrations in the root of files sorted by version. Every migration is a pair of
+// NNNN_name.up.sql and NNNN_name.down.sql files, other files are ignored
+func Load(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isSQLFile(entry.Name()) {
+			continue
+		}
+
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+
+		content, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, version)
+		}
+
+		if matches[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("%w: %d_%s", ErrIncompleteMigration, migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func isSQLFile(name string) bool {
+	return strings.HasSuffix(name, ".sql")
+}
+
+// isUndefinedTable returns whether the error is postgres telling the table does not exist
+func isUndefinedTable(err error) bool {
+	pgErr := &pgconn.PgError{}
+	return errors.As(err, &pgErr) && pgErr.Code == undefinedTable
+}
+
+// Latest returns the version of the last migration, 0 when there are none
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version of the last applied migration, 0 when none was applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var version int64
+
+	err := m.pool.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if isUndefinedTable(err) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Status returns every migration along with when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied := map[int64]time.Time{}
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+
+		applied, err = appliedMigrations(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Up applies every pending migration in order and returns them. Each migration is applied in its own
+// transaction, so a failing one leaves the database as it was before it
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	done := []Migration{}
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		err = m.checkKnown(applied)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err = runMigration(ctx, conn, migration, migration.up, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, NOW())`)
+			if err != nil {
+				return err
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, ErrInvalidSteps
+	}
+
+	done := []Migration{}
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		err = m.checkKnown(applied)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			err = runMigration(ctx, conn, migration, migration.down, `DELETE FROM schema_migrations WHERE version = $1 AND name = $2`)
+			if err != nil {
+				return err
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Force marks the migrations up to the version as applied and the later ones as pending, without
+// running any of them. It is meant to fix the tracking table after changing the schema by hand
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && !m.hasVersion(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+
+		defer tx.Rollback(ctx)
+
+		_, err = tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version > $1", version)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+
+			_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, NOW())
+				ON CONFLICT (version) DO NOTHING`, migration.Version, migration.Name)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit(ctx)
+	})
+}
+
+// withLock runs fn holding the advisory lock of the migrations on a connection where the tracking
+// table exists
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	if err != nil {
+		return err
+	}
+
+	// the lock belongs to the session, so it is released on the same connection even when ctx is done
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	_, err = conn.Exec(ctx, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// checkKnown fails when the database has migrations this migrator does not know about, which
+// happens when it was migrated by a newer version of the application
+func (m *Migrator) checkKnown(applied map[int64]time.Time) error {
+	for version := range applied {
+		if !m.hasVersion(version) {
+			return fmt.Errorf("%w: %d was applied to the database", ErrUnknownVersion, version)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) hasVersion(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appliedMigrations(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// runMigration runs the script of the migration and records it with the tracking query in the same
+// transaction
+func runMigration(ctx context.Context, conn *pgxpool.Conn, migration Migration, script string, trackingQuery string) error {
+	err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, script)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, trackingQuery, migration.Version, migration.Name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	c := require.New(t)
+
+	migrations, err := Load(fstest.MapFS{
+		"0002_add_teams.up.sql":        {Data: []byte("CREATE TABLE teams ();")},
+		"0002_add_teams.down.sql":      {Data: []byte("DROP TABLE teams;")},
+		"0001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"0001_initial_schema.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations.go":                {Data: []byte("package migrations")},
+		"seeds/0001_demo_users.up.sql": {Data: []byte("INSERT INTO users DEFAULT VALUES;")},
+	})
+	c.Nil(err)
+	c.Len(migrations, 2)
+	c.Equal(int64(1), migrations[0].Version)
+	c.Equal("initial_schema", migrations[0].Name)
+	c.Equal("CREATE TABLE users ();", migrations[0].up)
+	c.Equal("DROP TABLE teams;", migrations[1].down)
+}
+
+func TestLoadInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		err   error
+	}{
+		{"invalid name", fstest.MapFS{"initial.up.sql": {}}, ErrInvalidFileName},
+		{"zero version", fstest.MapFS{"0000_initial.up.sql": {}}, ErrInvalidFileName},
+		{"missing down", fstest.MapFS{"0001_initial.up.sql": {Data: []byte("SELECT 1;")}}, ErrIncompleteMigration},
+		{"duplicate version", fstest.MapFS{
+			"0001_initial.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_initial.down.sql": {Data: []byte("SELECT 1;")},
+			"0001_teams.up.sql":     {Data: []byte("SELECT 1;")},
+		}, ErrDuplicateVersion},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := require.New(t)
+
+			_, err := Load(test.files)
+			c.True(errors.Is(err, test.err), err)
+		})
+	}
+}
+
+func TestNewMissingPool(t *testing.T) {
+	c := require.New(t)
+
+	_, err := New(nil, fstest.MapFS{})
+	c.Equal(ErrMissingPool, err)
+}
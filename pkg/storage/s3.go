@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3AmzDateFormat = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	defaultS3Region = "us-east-1"
+)
+
+var (
+	// ErrMissingS3Config missing s3 config
+	ErrMissingS3Config = errors.New("missing s3 endpoint, bucket or credentials")
+)
+
+// S3Config defines how to reach an S3 compatible service. Objects are addressed path style, so
+// any service that supports it, like MinIO, works
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Region          string `yaml:"region" env:"S3_REGION"`
+	Bucket          string `yaml:"bucket" env:"S3_BUCKET"`
+	AccessKeyID     string `yaml:"accessKeyID" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secretAccessKey" env:"S3_SECRET_ACCESS_KEY"`
+}
+
+type s3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3 returns a storage backed by an S3 compatible service. The default http client is used
+// when client is nil
+func NewS3(config S3Config, client *http.Client) (Storage, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, ErrMissingS3Config
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Region == "" {
+		config.Region = defaultS3Region
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return s3Storage{
+		config:   config,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+func (s s3Storage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	request, err := s.newRequest(ctx, http.MethodPut, key, content)
+	if err != nil {
+		return err
+	}
+
+	request.ContentLength = size
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := s.do(request)
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+func (s s3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Body, nil
+}
+
+func (s s3Storage) Delete(ctx context.Context, key string) error {
+	request, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.do(request)
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+func (s s3Storage) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	path := strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.config.Bucket + "/" + key
+
+	objectURL := *s.endpoint
+	objectURL.Path = path
+	objectURL.RawPath = uriEncodePath(path)
+
+	return http.NewRequestWithContext(ctx, method, objectURL.String(), body)
+}
+
+// do signs and sends the request, turning error responses into errors
+func (s s3Storage) do(request *http.Request) (*http.Response, error) {
+	s.sign(request, s.now().UTC())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		return response, nil
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+
+	return nil, fmt.Errorf("s3 %s %s failed with status %d: %s", request.Method, request.URL.Path, response.StatusCode, message)
+}
+
+// sign adds the AWS signature version 4 headers to the request. The payload is not signed so
+// uploads can be streamed
+func (s s3Storage) sign(request *http.Request, now time.Time) {
+	amzDate := now.Format(s3AmzDateFormat)
+	date := now.Format(s3DateFormat)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + unsignedPayload + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.config.Region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, s.config.Region)
+	signingKey = hmacSHA256(signingKey, s3Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// uriEncodePath escapes everything but the unreserved characters and the slashes, as AWS expects
+func uriEncodePath(value string) string {
+	var builder strings.Builder
+
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			builder.WriteByte(b)
+		case b == '/':
+			builder.WriteByte(b)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+
+	return builder.String()
+}
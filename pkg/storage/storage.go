@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// TypeLocal stores the objects in the local filesystem
+	TypeLocal = "local"
+	// TypeS3 stores the objects in an S3 compatible service
+	TypeS3 = "s3"
+)
+
+var (
+	// ErrNotFound object not found
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey invalid object key
+	ErrInvalidKey = errors.New("invalid object key")
+	// ErrUnknownType unknown storage type
+	ErrUnknownType = errors.New("unknown storage type")
+)
+
+// Storage stores binary objects by key
+type Storage interface {
+	// Put stores the content under the key, replacing any previous object
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	// Get returns the content of the object. The caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config defines which storage to use and how to reach it
+type Config struct {
+	Type      string `yaml:"type" env:"STORAGE_TYPE"`
+	LocalPath string `yaml:"localPath" env:"STORAGE_LOCAL_PATH"`
+	S3        S3Config
+}
+
+// New returns the storage defined by the config, the local one when no type is given
+func New(config Config) (Storage, error) {
+	switch config.Type {
+	case "", TypeLocal:
+		return NewLocal(config.LocalPath)
+	case TypeS3:
+		return NewS3(config.S3, nil)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, config.Type)
+	}
+}
+
+// validateKey rejects the keys that could escape the storage root. Keys are slash separated
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a minimal stand-in of an S3 compatible service that keeps the objects in memory
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=access/") ||
+		!strings.Contains(authorization, "/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") ||
+		r.Header.Get("X-Amz-Date") == "" {
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = rw.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testStorage(t *testing.T, storage Storage) {
+	c := require.New(t)
+	ctx := context.Background()
+
+	content := []byte("some screenshot")
+
+	err := storage.Put(ctx, "tickets/1/file", bytes.NewReader(content), int64(len(content)), "image/png")
+	c.Nil(err)
+
+	reader, err := storage.Get(ctx, "tickets/1/file")
+	c.Nil(err)
+
+	stored, err := ioutil.ReadAll(reader)
+	c.Nil(err)
+	c.Nil(reader.Close())
+	c.Equal(content, stored)
+
+	c.Nil(storage.Delete(ctx, "tickets/1/file"))
+
+	_, err = storage.Get(ctx, "tickets/1/file")
+	c.Equal(ErrNotFound, err)
+
+	err = storage.Put(ctx, "tickets/../../etc/passwd", bytes.NewReader(content), int64(len(content)), "")
+	c.Equal(ErrInvalidKey, err)
+}
+
+func TestLocalStorage(t *testing.T) {
+	c := require.New(t)
+
+	storage, err := NewLocal(t.TempDir())
+	c.Nil(err)
+
+	testStorage(t, storage)
+}
+
+func TestS3Storage(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	defer server.Close()
+
+	storage, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "attachments",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+	}, server.Client())
+	c.Nil(err)
+
+	testStorage(t, storage)
+}